@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 
 	"github.com/devtheops/gsbt/internal/config"
+	"github.com/devtheops/gsbt/internal/connector"
 	"github.com/invopop/jsonschema"
 )
 
@@ -27,6 +29,15 @@ func main() {
 	// or programmatically here if strictly necessary.
 	// For now, we rely on the struct tags and comments.
 
+	// The connection block is validated per connector type
+	connectionType := reflect.TypeOf(config.Connection{})
+	r.Mapper = func(t reflect.Type) *jsonschema.Schema {
+		if t == connectionType {
+			return connectionSchema(r)
+		}
+		return nil
+	}
+
 	schema := r.Reflect(&config.Config{})
 	
 	// Set schema metadata
@@ -62,3 +73,43 @@ func main() {
 
 	fmt.Printf("Schema successfully generated at %s\n", absPath)
 }
+
+// connectionSchema builds a oneOf schema with one branch per registered
+// connector type, each combining the common connection fields with the
+// connector's options and pinning "type" to the connector's name.
+func connectionSchema(r *jsonschema.Reflector) *jsonschema.Schema {
+	// Reflect common fields without the mapper to avoid recursing into ourselves
+	plain := *r
+	plain.Mapper = nil
+	common := plain.ReflectFromType(reflect.TypeOf(config.Connection{}))
+
+	schema := &jsonschema.Schema{}
+	for _, typ := range connector.Types() {
+		reg, _ := connector.Lookup(typ)
+		opts := reg.Schema(&plain)
+
+		branch := &jsonschema.Schema{
+			Type:                 "object",
+			Title:                typ,
+			Properties:           jsonschema.NewProperties(),
+			AdditionalProperties: jsonschema.FalseSchema,
+			Required:             []string{"type"},
+		}
+		for pair := common.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			branch.Properties.Set(pair.Key, pair.Value)
+		}
+		branch.Properties.Set("type", &jsonschema.Schema{Type: "string", Const: typ})
+		for pair := opts.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			branch.Properties.Set(pair.Key, pair.Value)
+		}
+		for _, req := range opts.Required {
+			if req != "type" {
+				branch.Required = append(branch.Required, req)
+			}
+		}
+
+		schema.OneOf = append(schema.OneOf, branch)
+	}
+
+	return schema
+}
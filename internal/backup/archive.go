@@ -4,6 +4,7 @@ package backup
 import (
 	"archive/tar"
 	"compress/gzip"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -14,6 +15,12 @@ import (
 // CreateArchive compresses the contents of srcDir into a .tar.gz at destPath.
 // The archive stores paths relative to srcDir.
 func CreateArchive(srcDir, destPath string) error {
+	return CreateArchiveContext(context.Background(), srcDir, destPath)
+}
+
+// CreateArchiveContext is CreateArchive stopping early when ctx is done. The
+// incomplete archive is left for the caller to remove.
+func CreateArchiveContext(ctx context.Context, srcDir, destPath string) error {
 	if srcDir == "" {
 		return fmt.Errorf("srcDir is required")
 	}
@@ -43,6 +50,9 @@ func CreateArchive(srcDir, destPath string) error {
 		if walkErr != nil {
 			return walkErr
 		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
 		// Skip the root directory header
 		if path == srcDir {
@@ -71,7 +81,7 @@ func CreateArchive(srcDir, destPath string) error {
 			}
 			defer file.Close()
 
-			if _, err := io.Copy(tarWriter, file); err != nil {
+			if _, err := io.Copy(tarWriter, &contextReader{ctx: ctx, r: file}); err != nil {
 				return fmt.Errorf("copy %s: %w", relPath, err)
 			}
 		}
@@ -80,7 +90,24 @@ func CreateArchive(srcDir, destPath string) error {
 	})
 }
 
+// contextReader fails reads once ctx is done, so copying a large file stops
+// promptly on cancellation
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// timestampLayout names archives by their UTC creation time
+const timestampLayout = "2006-01-02_150405"
+
 // TimestampedFilename returns a UTC timestamped filename in gsbt format.
 func TimestampedFilename() string {
-	return time.Now().UTC().Format("2006-01-02_150405") + ".tar.gz"
+	return time.Now().UTC().Format(timestampLayout) + ".tar.gz"
 }
@@ -4,6 +4,8 @@ package backup
 import (
 	"archive/tar"
 	"compress/gzip"
+	"context"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
@@ -74,3 +76,13 @@ func TestTimestampedFilename(t *testing.T) {
 		t.Fatalf("unexpected timestamp length: %d", len(name))
 	}
 }
+
+func TestCreateArchiveContextCancelled(t *testing.T) {
+	src := t.TempDir()
+	os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0o644)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := CreateArchiveContext(ctx, src, filepath.Join(t.TempDir(), "a.tar.gz")); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,78 @@
+// internal/backup/archives.go
+package backup
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Archive is a backup archive found in a backup location
+type Archive struct {
+	Path string
+	Time time.Time
+	Size int64
+
+	// Manifest is nil for archives written before manifests existed
+	Manifest *Manifest
+}
+
+// Good reports whether the archive passed the sanity guards. Archives
+// without a manifest predate the guards and are trusted.
+func (a Archive) Good() bool {
+	return a.Manifest == nil || a.Manifest.Status != StatusSuspicious
+}
+
+// ListArchives returns the archives in dir, oldest first. A missing
+// directory has no archives.
+func ListArchives(dir string) ([]Archive, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var archives []Archive
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".tar.gz") {
+			continue
+		}
+		created, err := time.Parse(timestampLayout, strings.TrimSuffix(name, ".tar.gz"))
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		a := Archive{Path: filepath.Join(dir, name), Time: created, Size: info.Size()}
+		if m, err := ReadManifest(a.Path); err == nil {
+			a.Manifest = &m
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		archives = append(archives, a)
+	}
+
+	sort.Slice(archives, func(i, j int) bool { return archives[i].Time.Before(archives[j].Time) })
+	return archives, nil
+}
+
+// Remove deletes the archive and its manifest
+func (a Archive) Remove() error {
+	if err := os.Remove(a.Path); err != nil {
+		return err
+	}
+	if err := os.Remove(ManifestPath(a.Path)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+// internal/backup/consistency.go
+package backup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/devtheops/gsbt/internal/connector"
+)
+
+// ConsistencyPolicy decides what happens when files keep changing while
+// they are downloaded
+type ConsistencyPolicy string
+
+const (
+	// ConsistencyWarn keeps the archive and reports the changed files
+	ConsistencyWarn ConsistencyPolicy = "warn"
+	// ConsistencyFail fails the backup without writing an archive
+	ConsistencyFail ConsistencyPolicy = "fail"
+	// ConsistencyMark keeps the archive but marks it inconsistent in its manifest
+	ConsistencyMark ConsistencyPolicy = "mark"
+)
+
+// ParseConsistency validates a policy name, defaulting to warn when empty
+func ParseConsistency(s string) (ConsistencyPolicy, error) {
+	switch p := ConsistencyPolicy(strings.ToLower(s)); p {
+	case "":
+		return ConsistencyWarn, nil
+	case ConsistencyWarn, ConsistencyFail, ConsistencyMark:
+		return p, nil
+	}
+	return "", fmt.Errorf("unsupported consistency policy %q (want warn, fail or mark)", s)
+}
+
+// InconsistentError is returned by Backup under ConsistencyFail
+type InconsistentError struct {
+	Files []string
+}
+
+func (e *InconsistentError) Error() string {
+	return fmt.Sprintf("files changed during download: %s", strings.Join(e.Files, ", "))
+}
+
+// settle re-lists the remote after a transfer and compares size and mtime
+// with the listing the files were downloaded from. Changed files, and torn
+// ones whose download didn't match the listed size, are transferred again,
+// up to ConsistencyRetries times; files is updated with the new listing.
+// It returns the files that are still changing or were deleted in the
+// meantime, and those of them whose last download was still torn.
+func (m *Manager) settle(ctx context.Context, conn connector.Connector, files []connector.FileInfo, torn []string, transfer func([]connector.FileInfo) ([]string, error)) (changed, stillTorn []string, err error) {
+	pending := map[string]bool{}
+	for _, f := range files {
+		if !f.IsDir {
+			pending[f.Path] = true
+		}
+	}
+	isTorn := map[string]bool{}
+	for _, p := range torn {
+		isTorn[p] = true
+	}
+
+	var gone []string
+	for attempt := 0; ; attempt++ {
+		current, err := m.list(ctx, conn)
+		if err != nil {
+			return nil, nil, fmt.Errorf("re-list: %w", err)
+		}
+		byPath := make(map[string]connector.FileInfo, len(current))
+		for _, f := range current {
+			byPath[f.Path] = f
+		}
+
+		changed, stillTorn = nil, nil
+		var retry []connector.FileInfo
+		for i, f := range files {
+			if !pending[f.Path] {
+				continue
+			}
+			now, ok := byPath[f.Path]
+			switch {
+			case !ok:
+				gone = append(gone, f.Path)
+			case now.Size != f.Size || !now.ModTime.Equal(f.ModTime) || isTorn[f.Path]:
+				changed = append(changed, f.Path)
+				if isTorn[f.Path] {
+					stillTorn = append(stillTorn, f.Path)
+				}
+				files[i] = now
+				retry = append(retry, now)
+			}
+		}
+
+		if len(changed) == 0 || attempt >= m.ConsistencyRetries {
+			return append(changed, gone...), stillTorn, nil
+		}
+
+		if m.Progress != nil {
+			m.Progress.Message(fmt.Sprintf("%d files changed during download, fetching again (attempt %d/%d)",
+				len(changed), attempt+1, m.ConsistencyRetries))
+		}
+		if torn, err = transfer(retry); err != nil {
+			return nil, nil, err
+		}
+
+		pending = map[string]bool{}
+		for _, p := range changed {
+			pending[p] = true
+		}
+		isTorn = map[string]bool{}
+		for _, p := range torn {
+			isTorn[p] = true
+		}
+	}
+}
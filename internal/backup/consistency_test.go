@@ -0,0 +1,199 @@
+// internal/backup/consistency_test.go
+package backup
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devtheops/gsbt/internal/connector"
+)
+
+// growingConnector simulates a save file the game is still writing: every
+// listing sees one more line until the file settles after `settle` lists
+type growingConnector struct {
+	mockConnector
+	settle int
+	lists  int
+}
+
+func (g *growingConnector) List(ctx context.Context) ([]connector.FileInfo, error) {
+	if g.lists < g.settle {
+		g.lists++
+	}
+	data := strings.Repeat("line\n", g.lists)
+	g.data = map[string]string{"world.sav": data, "static.cfg": "cfg"}
+	mtime := time.Date(2024, 1, 1, 0, g.lists, 0, 0, time.UTC)
+	return []connector.FileInfo{
+		{Path: "world.sav", Size: int64(len(data)), ModTime: mtime},
+		{Path: "static.cfg", Size: 3},
+	}, nil
+}
+
+func (g *growingConnector) Download(ctx context.Context, remotePath string, w io.Writer) error {
+	_, err := io.WriteString(w, g.data[remotePath])
+	return err
+}
+
+// writingConnector simulates a save file written to while it downloads:
+// each of the first `grows` downloads appends a line before sending, so it
+// delivers more bytes than the listing reported
+type writingConnector struct {
+	mockConnector
+	save      string
+	grows     int
+	downloads int
+}
+
+func (w *writingConnector) List(ctx context.Context) ([]connector.FileInfo, error) {
+	return []connector.FileInfo{{Path: "world.sav", Size: int64(len(w.save))}}, nil
+}
+
+func (w *writingConnector) Download(ctx context.Context, remotePath string, out io.Writer) error {
+	if w.downloads < w.grows {
+		w.save += "line\n"
+	}
+	w.downloads++
+	_, err := io.WriteString(out, w.save)
+	return err
+}
+
+func TestManagerBackupConsistency(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("settles after retry", func(t *testing.T) {
+		conn := &growingConnector{settle: 2}
+		mgr := Manager{BackupLocation: t.TempDir(), ConsistencyRetries: 2, Consistency: ConsistencyFail}
+		archive, stats, err := mgr.Backup(ctx, conn)
+		if err != nil {
+			t.Fatalf("Backup error: %v", err)
+		}
+		if len(stats.Changed) != 0 || stats.Bytes != 10+3 {
+			t.Errorf("stats = %+v, want no changes and 13 bytes", stats)
+		}
+		assertStaged(t, mgr, "world.sav", "line\nline\n")
+		m, err := ReadManifest(archive)
+		if err != nil || m.Status != StatusOK || m.Files != 2 {
+			t.Errorf("manifest = %+v (%v), want status ok", m, err)
+		}
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		conn := &growingConnector{settle: 10}
+		mgr := Manager{BackupLocation: t.TempDir(), ConsistencyRetries: 1, Consistency: ConsistencyFail}
+		archive, _, err := mgr.Backup(ctx, conn)
+		var inconsistent *InconsistentError
+		if !errors.As(err, &inconsistent) || len(inconsistent.Files) != 1 || inconsistent.Files[0] != "world.sav" {
+			t.Fatalf("err = %v, want InconsistentError for world.sav", err)
+		}
+		if archive != "" {
+			t.Errorf("archive = %q, want none", archive)
+		}
+		// initial list, then one re-list per transfer
+		if conn.lists != 3 {
+			t.Errorf("listed %d times, want 3", conn.lists)
+		}
+	})
+
+	for _, tc := range []struct {
+		policy ConsistencyPolicy
+		status string
+	}{
+		{ConsistencyWarn, StatusOK},
+		{ConsistencyMark, StatusInconsistent},
+	} {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			conn := &growingConnector{settle: 10}
+			mgr := Manager{BackupLocation: t.TempDir(), Consistency: tc.policy}
+			archive, stats, err := mgr.Backup(ctx, conn)
+			if err != nil {
+				t.Fatalf("Backup error: %v", err)
+			}
+			if len(stats.Changed) != 1 || stats.Changed[0] != "world.sav" {
+				t.Errorf("changed = %v, want [world.sav]", stats.Changed)
+			}
+			m, err := ReadManifest(archive)
+			if err != nil || m.Status != tc.status || len(m.Changed) != 1 {
+				t.Errorf("manifest = %+v (%v), want status %s", m, err, tc.status)
+			}
+		})
+	}
+}
+
+func TestManagerBackupGrowingDownload(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("settles after retry", func(t *testing.T) {
+		conn := &writingConnector{save: "line\n", grows: 1}
+		mgr := Manager{BackupLocation: t.TempDir(), ConsistencyRetries: 2, Consistency: ConsistencyFail}
+		_, stats, err := mgr.Backup(ctx, conn)
+		if err != nil {
+			t.Fatalf("Backup error: %v", err)
+		}
+		if len(stats.Changed) != 0 || stats.Bytes != 10 || conn.downloads != 2 {
+			t.Errorf("stats = %+v after %d downloads, want no changes, 10 bytes, 2 downloads", stats, conn.downloads)
+		}
+		assertStaged(t, mgr, "world.sav", "line\nline\n")
+	})
+
+	// A download still torn after the retries is never archived as good,
+	// whatever the consistency policy
+	t.Run("warn", func(t *testing.T) {
+		conn := &writingConnector{save: "line\n", grows: 10}
+		mgr := Manager{BackupLocation: t.TempDir(), ConsistencyRetries: 1, Consistency: ConsistencyWarn}
+		archive, _, err := mgr.Backup(ctx, conn)
+		var suspicious *SuspiciousError
+		if !errors.As(err, &suspicious) || !strings.Contains(err.Error(), "incomplete download: world.sav") {
+			t.Fatalf("err = %v, want SuspiciousError for world.sav", err)
+		}
+		if archive != "" {
+			t.Errorf("archive = %q, want none", archive)
+		}
+	})
+
+	t.Run("warn with guard_action mark", func(t *testing.T) {
+		conn := &writingConnector{save: "line\n", grows: 10}
+		mgr := Manager{BackupLocation: t.TempDir(), ConsistencyRetries: 1, Consistency: ConsistencyWarn}
+		mgr.Guards.Policy = GuardMark
+		archive, stats, err := mgr.Backup(ctx, conn)
+		if err != nil {
+			t.Fatalf("Backup error: %v", err)
+		}
+		if len(stats.Changed) != 1 || stats.Changed[0] != "world.sav" {
+			t.Errorf("changed = %v, want [world.sav]", stats.Changed)
+		}
+		m, err := ReadManifest(archive)
+		if err != nil || m.Status != StatusSuspicious || len(m.Suspicious) != 1 {
+			t.Errorf("manifest = %+v (%v), want status suspicious", m, err)
+		}
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		conn := &writingConnector{save: "line\n", grows: 10}
+		mgr := Manager{BackupLocation: t.TempDir(), ConsistencyRetries: 1, Consistency: ConsistencyFail}
+		_, _, err := mgr.Backup(ctx, conn)
+		var inconsistent *InconsistentError
+		if !errors.As(err, &inconsistent) {
+			t.Fatalf("err = %v, want InconsistentError", err)
+		}
+	})
+}
+
+func TestParseConsistency(t *testing.T) {
+	for in, want := range map[string]ConsistencyPolicy{
+		"":     ConsistencyWarn,
+		"warn": ConsistencyWarn,
+		"FAIL": ConsistencyFail,
+		"mark": ConsistencyMark,
+	} {
+		if got, err := ParseConsistency(in); err != nil || got != want {
+			t.Errorf("ParseConsistency(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseConsistency("ignore"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
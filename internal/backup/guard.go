@@ -0,0 +1,83 @@
+// internal/backup/guard.go
+package backup
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GuardPolicy decides what happens to a backup that fails a sanity guard
+type GuardPolicy string
+
+const (
+	// GuardFail fails the backup without writing an archive
+	GuardFail GuardPolicy = "fail"
+	// GuardMark keeps the archive but marks it suspicious in its manifest
+	GuardMark GuardPolicy = "mark"
+)
+
+// ParseGuardPolicy validates a policy name, defaulting to fail when empty
+func ParseGuardPolicy(s string) (GuardPolicy, error) {
+	switch p := GuardPolicy(strings.ToLower(s)); p {
+	case "":
+		return GuardFail, nil
+	case GuardFail, GuardMark:
+		return p, nil
+	}
+	return "", fmt.Errorf("unsupported guard action %q (want fail or mark)", s)
+}
+
+// Guards catch backups that look wrong, like zero files from a mistyped
+// remote_path or a wiped server. Zero values disable a check.
+type Guards struct {
+	MinFiles int
+	MinBytes int64
+	// MaxShrinkPercent is how much smaller than the previous good archive a
+	// backup may be
+	MaxShrinkPercent int
+
+	Policy GuardPolicy
+}
+
+// SuspiciousError is returned by Backup when a guard fails under GuardFail
+type SuspiciousError struct {
+	Reasons []string
+}
+
+func (e *SuspiciousError) Error() string {
+	return "backup looks wrong: " + strings.Join(e.Reasons, "; ")
+}
+
+// check returns the guards stats fails. previous is the manifest of the
+// newest good archive, nil if there is none.
+func (g Guards) check(stats Stats, previous *Manifest) []string {
+	var reasons []string
+	if g.MinFiles > 0 && stats.Files < g.MinFiles {
+		reasons = append(reasons, fmt.Sprintf("%d files, want at least %d", stats.Files, g.MinFiles))
+	}
+	if g.MinBytes > 0 && stats.Bytes < g.MinBytes {
+		reasons = append(reasons, fmt.Sprintf("%d bytes, want at least %d", stats.Bytes, g.MinBytes))
+	}
+	if g.MaxShrinkPercent > 0 && previous != nil && previous.Bytes > 0 && stats.Bytes < previous.Bytes {
+		shrink := (previous.Bytes - stats.Bytes) * 100 / previous.Bytes
+		if shrink > int64(g.MaxShrinkPercent) {
+			reasons = append(reasons, fmt.Sprintf("%d%% smaller than the previous backup (%d bytes), allowed %d%%",
+				shrink, previous.Bytes, g.MaxShrinkPercent))
+		}
+	}
+	return reasons
+}
+
+// previousGood returns the manifest of the newest good archive in dir
+func previousGood(dir string) (*Manifest, error) {
+	archives, err := ListArchives(dir)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(archives) - 1; i >= 0; i-- {
+		if a := archives[i]; a.Good() {
+			return a.Manifest, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,75 @@
+// internal/backup/guard_test.go
+package backup
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/devtheops/gsbt/internal/connector"
+)
+
+func TestManagerBackupGuards(t *testing.T) {
+	ctx := context.Background()
+	world := &mockConnector{
+		files: []connector.FileInfo{{Path: "world.sav", Size: 100}},
+		data:  map[string]string{"world.sav": strings.Repeat("x", 100)},
+	}
+	empty := &mockConnector{}
+
+	t.Run("min files", func(t *testing.T) {
+		mgr := Manager{BackupLocation: t.TempDir(), Guards: Guards{MinFiles: 1}}
+		archive, _, err := mgr.Backup(ctx, empty)
+		var suspicious *SuspiciousError
+		if !errors.As(err, &suspicious) || archive != "" {
+			t.Fatalf("Backup = %q, %v; want SuspiciousError", archive, err)
+		}
+		if _, _, err := mgr.Backup(ctx, world); err != nil {
+			t.Errorf("Backup with one file: %v", err)
+		}
+	})
+
+	t.Run("mark", func(t *testing.T) {
+		mgr := Manager{BackupLocation: t.TempDir(), Guards: Guards{MinBytes: 1000, Policy: GuardMark}}
+		archive, stats, err := mgr.Backup(ctx, world)
+		if err != nil {
+			t.Fatalf("Backup error: %v", err)
+		}
+		m, err := ReadManifest(archive)
+		if err != nil || m.Status != StatusSuspicious || len(m.Suspicious) != 1 || len(stats.Suspicious) != 1 {
+			t.Errorf("manifest = %+v (%v), want suspicious", m, err)
+		}
+	})
+
+	t.Run("shrink", func(t *testing.T) {
+		dir := t.TempDir()
+		writeArchive(t, dir, "2024-01-01_000000", &Manifest{Status: StatusOK, Bytes: 1000})
+		// a suspicious archive is no baseline
+		writeArchive(t, dir, "2024-01-02_000000", &Manifest{Status: StatusSuspicious, Bytes: 100})
+
+		mgr := Manager{BackupLocation: dir, Guards: Guards{MaxShrinkPercent: 50}}
+		_, _, err := mgr.Backup(ctx, world)
+		var suspicious *SuspiciousError
+		if !errors.As(err, &suspicious) || !strings.Contains(err.Error(), "90% smaller") {
+			t.Fatalf("err = %v, want 90%% shrink", err)
+		}
+
+		mgr.Guards.MaxShrinkPercent = 95
+		if _, _, err := mgr.Backup(ctx, world); err != nil {
+			t.Errorf("Backup within allowed shrink: %v", err)
+		}
+	})
+}
+
+func TestParseGuardPolicy(t *testing.T) {
+	if p, err := ParseGuardPolicy(""); err != nil || p != GuardFail {
+		t.Errorf("default = %q, %v; want fail", p, err)
+	}
+	if p, err := ParseGuardPolicy("Mark"); err != nil || p != GuardMark {
+		t.Errorf("Mark = %q, %v", p, err)
+	}
+	if _, err := ParseGuardPolicy("warn"); err == nil {
+		t.Error("expected error for unknown action")
+	}
+}
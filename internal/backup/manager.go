@@ -7,10 +7,12 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/devtheops/gsbt/internal/connector"
 	"github.com/devtheops/gsbt/internal/progress"
+	"github.com/devtheops/gsbt/internal/throttle"
 )
 
 // Manager coordinates backup operations for a single server.
@@ -18,6 +20,40 @@ type Manager struct {
 	TempDir        string
 	BackupLocation string
 	Progress       progress.Reporter
+
+	// RetryAttempts is how often a failed file download is retried after
+	// reconnecting, waiting RetryDelay (doubled each time with RetryBackoff)
+	RetryAttempts int
+	RetryDelay    time.Duration
+	RetryBackoff  bool
+
+	// Bandwidth throttles downloads; empty means unlimited
+	Bandwidth throttle.Limiter
+
+	// Consistency decides what happens to files that are still changing
+	// after ConsistencyRetries extra transfers (default warn)
+	Consistency        ConsistencyPolicy
+	ConsistencyRetries int
+
+	// Guards are sanity checks on the downloaded files
+	Guards Guards
+
+	// MaxStorage caps the total size of the archives in BackupLocation; the
+	// oldest are pruned once a backup succeeds, keeping the newest KeepGood
+	// good ones
+	MaxStorage int64
+	KeepGood   int
+
+	// Timeouts, zero for none. IdleTimeout aborts a download that receives
+	// nothing for that long (it is retried like any failed download);
+	// ServerTimeout bounds the whole backup.
+	ConnectTimeout time.Duration
+	ListTimeout    time.Duration
+	IdleTimeout    time.Duration
+	ServerTimeout  time.Duration
+
+	// statVolume is replaced in tests
+	statVolume func(path string) (volume, error)
 }
 
 // Stats represents a summary of a backup run.
@@ -25,10 +61,30 @@ type Stats struct {
 	Files    int
 	Bytes    int64
 	Duration time.Duration
+
+	// Changed lists files that changed during the download and never settled
+	Changed []string
+	// Suspicious lists the failed guards of a backup kept under GuardMark
+	Suspicious []string
+	// Pruned lists archives deleted to stay within MaxStorage
+	Pruned []string
 }
 
 // Backup pulls files via connector, archives them, and writes to backup location.
 func (m *Manager) Backup(ctx context.Context, conn connector.Connector) (string, Stats, error) {
+	var (
+		archivePath string
+		stats       Stats
+	)
+	err := withTimeout(ctx, m.ServerTimeout, "server_timeout", func(ctx context.Context) error {
+		var err error
+		archivePath, stats, err = m.backup(ctx, conn)
+		return err
+	})
+	return archivePath, stats, err
+}
+
+func (m *Manager) backup(ctx context.Context, conn connector.Connector) (string, Stats, error) {
 	start := time.Now()
 	stats := Stats{}
 
@@ -48,31 +104,172 @@ func (m *Manager) Backup(ctx context.Context, conn connector.Connector) (string,
 	if err := os.MkdirAll(tempDir, 0o755); err != nil {
 		return "", stats, fmt.Errorf("create temp dir: %w", err)
 	}
+	if err := os.MkdirAll(m.BackupLocation, 0o755); err != nil {
+		return "", stats, fmt.Errorf("create backup dir: %w", err)
+	}
+	removePartial(m.BackupLocation)
 
-	if err := conn.Connect(ctx); err != nil {
+	if err := m.connect(ctx, conn); err != nil {
 		return "", stats, err
 	}
 	defer conn.Close()
 
 	// List files
-	files, err := conn.List(ctx)
+	files, err := m.list(ctx, conn)
 	if err != nil {
 		return "", stats, fmt.Errorf("list: %w", err)
 	}
 
-	var totalSize int64
-	for _, f := range files {
-		if !f.IsDir {
-			stats.Files++
-			stats.Bytes += f.Size
-			totalSize += f.Size
-		}
+	stats.Files, stats.Bytes = countFiles(files)
+	totalSize := stats.Bytes
+
+	quota, err := m.reserve(tempDir, totalSize)
+	if err != nil {
+		return "", stats, err
 	}
 	if m.Progress != nil {
 		m.Progress.Start(totalSize, len(files))
 	}
 
-	// Download files to temp dir
+	// Connectors that support it update the previous staging copy in place
+	transfer := func(batch []connector.FileInfo) ([]string, error) {
+		return m.download(ctx, conn, batch, tempDir)
+	}
+	synced := false
+	if ds, ok := conn.(connector.DeltaSyncer); ok {
+		synced, err = ds.SyncTo(ctx, files, tempDir)
+		if err != nil {
+			return "", stats, fmt.Errorf("sync: %w", err)
+		}
+		if synced {
+			if m.Progress != nil {
+				m.Progress.Message(fmt.Sprintf("synced %d files with delta transfer", stats.Files))
+			}
+			// A sync needs the full list to keep the other files; unchanged
+			// ones are skipped anyway
+			transfer = func([]connector.FileInfo) ([]string, error) {
+				if _, err := ds.SyncTo(ctx, files, tempDir); err != nil {
+					return nil, fmt.Errorf("sync: %w", err)
+				}
+				return nil, nil
+			}
+		}
+	}
+	var torn []string
+	if !synced {
+		if torn, err = transfer(files); err != nil {
+			return "", stats, err
+		}
+	}
+
+	stats.Changed, torn, err = m.settle(ctx, conn, files, torn, transfer)
+	if err != nil {
+		return "", stats, err
+	}
+	_, stats.Bytes = countFiles(files)
+	if len(stats.Changed) > 0 && m.Consistency == ConsistencyFail {
+		return "", stats, &InconsistentError{Files: stats.Changed}
+	}
+
+	// Shrinking is measured against the newest archive that passed the guards
+	var previous *Manifest
+	if m.Guards.MaxShrinkPercent > 0 {
+		if previous, err = previousGood(m.BackupLocation); err != nil {
+			return "", stats, fmt.Errorf("read previous archives: %w", err)
+		}
+	}
+	stats.Suspicious = m.Guards.check(stats, previous)
+	// A file that never downloaded whole would be archived truncated, which
+	// no consistency policy should let pass as a good backup
+	if len(torn) > 0 {
+		stats.Suspicious = append(stats.Suspicious, fmt.Sprintf("incomplete download: %s", strings.Join(torn, ", ")))
+	}
+	if len(stats.Suspicious) > 0 && m.Guards.Policy != GuardMark {
+		return "", stats, &SuspiciousError{Reasons: stats.Suspicious}
+	}
+
+	if m.Progress != nil {
+		m.Progress.Close()
+	}
+
+	// Old archives only go for the quota now that the backup is good, and
+	// only those the filesystem needs gone before the new one is written
+	deleted, quota, err := m.makeRoom(quota, stats.Bytes)
+	for _, a := range deleted {
+		stats.Pruned = append(stats.Pruned, a.Path)
+	}
+	if err != nil {
+		return "", stats, err
+	}
+
+	// The archive is written under a temporary name so an interrupted run
+	// never leaves a truncated archive behind
+	archivePath := filepath.Join(m.BackupLocation, TimestampedFilename())
+	partialPath := archivePath + partialSuffix
+	if err := CreateArchiveContext(ctx, tempDir, partialPath); err != nil {
+		os.Remove(partialPath)
+		return "", stats, fmt.Errorf("create archive: %w", err)
+	}
+	if err := os.Rename(partialPath, archivePath); err != nil {
+		os.Remove(partialPath)
+		return "", stats, fmt.Errorf("create archive: %w", err)
+	}
+
+	manifest := Manifest{
+		Created: start.UTC(),
+		Files:   stats.Files,
+		Bytes:   stats.Bytes,
+		Status:  StatusOK,
+		Changed: stats.Changed,
+
+		Suspicious: stats.Suspicious,
+	}
+	switch {
+	case len(stats.Suspicious) > 0:
+		manifest.Status = StatusSuspicious
+	case len(stats.Changed) > 0 && m.Consistency == ConsistencyMark:
+		manifest.Status = StatusInconsistent
+	}
+	if err := WriteManifest(archivePath, manifest); err != nil {
+		return "", stats, err
+	}
+
+	for _, a := range quota {
+		if err := a.Remove(); err != nil {
+			// The new archive is written; the next run prunes again
+			if m.Progress != nil {
+				m.Progress.Message(fmt.Sprintf("prune for quota: %v", err))
+			}
+			break
+		}
+		stats.Pruned = append(stats.Pruned, a.Path)
+	}
+
+	stats.Duration = time.Since(start)
+	return archivePath, stats, nil
+}
+
+// connect opens the connection within ConnectTimeout
+func (m *Manager) connect(ctx context.Context, conn connector.Connector) error {
+	return withTimeout(ctx, m.ConnectTimeout, "connect_timeout", conn.Connect)
+}
+
+// list lists the remote files within ListTimeout
+func (m *Manager) list(ctx context.Context, conn connector.Connector) ([]connector.FileInfo, error) {
+	var files []connector.FileInfo
+	err := withTimeout(ctx, m.ListTimeout, "list_timeout", func(ctx context.Context) error {
+		var err error
+		files, err = conn.List(ctx)
+		return err
+	})
+	return files, err
+}
+
+// download fetches files one by one into tempDir. It returns the files
+// whose size didn't match the listing, which most likely changed while
+// they were downloaded; settle fetches them again.
+func (m *Manager) download(ctx context.Context, conn connector.Connector, files []connector.FileInfo, tempDir string) ([]string, error) {
+	var torn []string
 	for _, file := range files {
 		localPath := filepath.Join(tempDir, file.Path)
 		if file.IsDir {
@@ -82,63 +279,140 @@ func (m *Manager) Backup(ctx context.Context, conn connector.Connector) (string,
 		}
 
 		if err := os.MkdirAll(filepath.Dir(localPath), 0o755); err != nil {
-			return "", stats, fmt.Errorf("mkdir for %s: %w", file.Path, err)
-		}
-
-		f, err := os.Create(localPath)
-		if err != nil {
-			return "", stats, fmt.Errorf("create %s: %w", file.Path, err)
+			return nil, fmt.Errorf("mkdir for %s: %w", file.Path, err)
 		}
 
 		if m.Progress != nil {
 			m.Progress.FileStart(file.Path, file.Size)
 		}
 
-		// Wrap writer to report progress periodically
-		pw := &progressWriter{w: f, cb: func(written int64) {
-			if m.Progress != nil {
-				m.Progress.FileProgress(file.Path, written, file.Size)
-			}
-		}}
-
-		if err := conn.Download(ctx, file.Path, pw); err != nil {
-			f.Close()
-			return "", stats, fmt.Errorf("download %s: %w", file.Path, err)
+		n, err := m.fetch(ctx, conn, file, localPath)
+		if err != nil {
+			return nil, fmt.Errorf("download %s: %w", file.Path, err)
+		}
+		if n != file.Size {
+			torn = append(torn, file.Path)
 		}
-		f.Close()
 
 		if m.Progress != nil {
 			m.Progress.FileDone(file.Path)
 		}
 	}
+	return torn, nil
+}
 
-	if m.Progress != nil {
-		m.Progress.Close()
+// fetch downloads one file to localPath and returns its size. Failed
+// attempts are retried on a fresh connection, continuing from the bytes
+// already written if the connector can resume.
+func (m *Manager) fetch(ctx context.Context, conn connector.Connector, file connector.FileInfo, localPath string) (n int64, err error) {
+	f, err := os.Create(localPath)
+	if err != nil {
+		return 0, err
 	}
+	defer f.Close()
+	// Never leave a truncated file in the staging copy
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(localPath)
+		}
+	}()
 
-	// Build archive path
-	archiveDir := m.BackupLocation
-	if err := os.MkdirAll(archiveDir, 0o755); err != nil {
-		return "", stats, fmt.Errorf("create backup dir: %w", err)
+	resumer, canResume := conn.(connector.ResumableDownloader)
+	delay := m.RetryDelay
+	var offset int64
+	for attempt := 0; ; attempt++ {
+		err := m.fetchOnce(ctx, conn, resumer, file, f, &offset, attempt > 0)
+		if err == nil {
+			return offset, nil
+		}
+		if attempt >= m.RetryAttempts || ctx.Err() != nil {
+			return 0, err
+		}
+
+		// Keep what was received if the rest can be requested, else start over
+		if !canResume || offset >= file.Size {
+			offset = 0
+		}
+		if err := f.Truncate(offset); err != nil {
+			return 0, err
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+
+		if m.Progress != nil {
+			m.Progress.Message(fmt.Sprintf("retrying %s from %d/%d bytes (attempt %d/%d): %v",
+				file.Path, offset, file.Size, attempt+1, m.RetryAttempts, err))
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(delay):
+		}
+		if m.RetryBackoff {
+			delay *= 2
+		}
 	}
+}
 
-	archivePath := filepath.Join(archiveDir, TimestampedFilename())
-	if err := CreateArchive(tempDir, archivePath); err != nil {
-		return "", stats, fmt.Errorf("create archive: %w", err)
+// fetchOnce makes one download attempt into f from offset, which is
+// advanced by the bytes written. Retries reconnect first, since the
+// connection is likely dead after a failed transfer.
+func (m *Manager) fetchOnce(ctx context.Context, conn connector.Connector, resumer connector.ResumableDownloader, file connector.FileInfo, f *os.File, offset *int64, reconnect bool) error {
+	if reconnect {
+		conn.Close()
+		if err := m.connect(ctx, conn); err != nil {
+			return fmt.Errorf("reconnect: %w", err)
+		}
 	}
 
-	stats.Duration = time.Since(start)
-	return archivePath, stats, nil
+	// The watchdog cancels the attempt when the connector stops delivering
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	stalled := &TimeoutError{Setting: "idle_transfer_timeout", After: m.IdleTimeout}
+	idle := newWatchdog(m.IdleTimeout, func() { cancel(stalled) })
+	defer idle.stop()
+
+	// Wrap writer to report progress periodically
+	pw := &progressWriter{ctx: ctx, w: m.Bandwidth.Writer(ctx, f), n: *offset, idle: idle, cb: func(written int64) {
+		if m.Progress != nil {
+			m.Progress.FileProgress(file.Path, written, file.Size)
+		}
+	}}
+	defer func() { *offset = pw.n }()
+
+	var err error
+	if *offset > 0 {
+		err = resumer.DownloadFrom(ctx, file.Path, *offset, pw)
+	} else {
+		err = conn.Download(ctx, file.Path, pw)
+	}
+	if err != nil && context.Cause(ctx) == stalled {
+		return stalled
+	}
+	return err
 }
 
 // progressWriter wraps an io.Writer to report incremental bytes written.
+// Writes fail once ctx is done, which aborts a connector's copy loop even
+// if it doesn't watch the context itself. Each write kicks the idle
+// watchdog; time spent in the write (e.g. throttled) doesn't count as idle.
 type progressWriter struct {
-	w  io.Writer
-	n  int64
-	cb func(written int64)
+	ctx  context.Context
+	w    io.Writer
+	n    int64
+	idle *watchdog
+	cb   func(written int64)
 }
 
 func (p *progressWriter) Write(b []byte) (int, error) {
+	if err := p.ctx.Err(); err != nil {
+		return 0, err
+	}
+	p.idle.stop()
+	defer p.idle.kick()
+
 	n, err := p.w.Write(b)
 	p.n += int64(n)
 	if p.cb != nil {
@@ -147,8 +421,33 @@ func (p *progressWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
+// countFiles returns the number and total size of the files in a listing,
+// leaving out directories
+func countFiles(files []connector.FileInfo) (int, int64) {
+	var n int
+	var size int64
+	for _, f := range files {
+		if !f.IsDir {
+			n++
+			size += f.Size
+		}
+	}
+	return n, size
+}
+
 // Restore uploads an archive's contents back to the remote via connector.
 // Not implemented yet; placeholder for future work.
 func (m *Manager) Restore(ctx context.Context, conn connector.Connector, r io.Reader) error {
 	return fmt.Errorf("restore not implemented")
 }
+
+// partialSuffix marks an archive that is still being written
+const partialSuffix = ".partial"
+
+// removePartial deletes archives left behind by a run that was killed
+func removePartial(dir string) {
+	partial, _ := filepath.Glob(filepath.Join(dir, "*.tar.gz"+partialSuffix))
+	for _, p := range partial {
+		os.Remove(p)
+	}
+}
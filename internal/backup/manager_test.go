@@ -3,13 +3,17 @@ package backup
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/devtheops/gsbt/internal/connector"
+	"github.com/devtheops/gsbt/internal/throttle"
 )
 
 // mockConnector is a simple in-memory connector for tests.
@@ -48,6 +52,7 @@ func TestManagerBackup(t *testing.T) {
 	mock := &mockConnector{
 		files: []connector.FileInfo{
 			{Path: "file1.txt", Size: 5, ModTime: time.Now()},
+			{Path: "nested", Size: 4096, IsDir: true},
 			{Path: "nested/file2.txt", Size: 5, ModTime: time.Now()},
 		},
 		data: map[string]string{
@@ -78,3 +83,231 @@ func TestManagerBackup(t *testing.T) {
 		t.Fatalf("stats bytes = %d, want %d", stats.Bytes, len("hello")+len("world"))
 	}
 }
+
+// deltaConnector is a mockConnector that also implements DeltaSyncer
+type deltaConnector struct {
+	mockConnector
+	sync      bool
+	syncedTo  string
+	downloads int
+}
+
+func (d *deltaConnector) SyncTo(ctx context.Context, files []connector.FileInfo, localDir string) (bool, error) {
+	if !d.sync {
+		return false, nil
+	}
+	d.syncedTo = localDir
+	for _, f := range files {
+		os.MkdirAll(filepath.Join(localDir, filepath.Dir(f.Path)), 0o755)
+		os.WriteFile(filepath.Join(localDir, f.Path), []byte(d.data[f.Path]), 0o644)
+	}
+	return true, nil
+}
+
+func (d *deltaConnector) Download(ctx context.Context, remotePath string, w io.Writer) error {
+	d.downloads++
+	return d.mockConnector.Download(ctx, remotePath, w)
+}
+
+func TestManagerBackupDeltaSync(t *testing.T) {
+	for _, sync := range []bool{true, false} {
+		tmp := t.TempDir()
+		conn := &deltaConnector{
+			mockConnector: mockConnector{
+				files: []connector.FileInfo{{Path: "world.sav", Size: 5}},
+				data:  map[string]string{"world.sav": "hello"},
+			},
+			sync: sync,
+		}
+
+		mgr := Manager{BackupLocation: tmp, TempDir: filepath.Join(tmp, "staging")}
+		if _, _, err := mgr.Backup(context.Background(), conn); err != nil {
+			t.Fatalf("sync=%v: Backup error: %v", sync, err)
+		}
+
+		if sync && (conn.syncedTo != mgr.TempDir || conn.downloads != 0) {
+			t.Errorf("sync=true: synced to %q with %d downloads", conn.syncedTo, conn.downloads)
+		}
+		if !sync && conn.downloads != 1 {
+			t.Errorf("sync=false: %d downloads, want 1", conn.downloads)
+		}
+		if data, _ := os.ReadFile(filepath.Join(mgr.TempDir, "world.sav")); string(data) != "hello" {
+			t.Errorf("sync=%v: staged %q", sync, data)
+		}
+	}
+}
+
+// flakyConnector drops the connection after failAfter bytes for the first
+// `failures` downloads, optionally supporting resume
+type flakyConnector struct {
+	mockConnector
+	resumable bool
+	failAfter int
+	failures  int
+	offsets   []int64
+	connects  int
+}
+
+func (f *flakyConnector) Connect(ctx context.Context) error {
+	f.connects++
+	return f.mockConnector.Connect(ctx)
+}
+
+func (f *flakyConnector) Download(ctx context.Context, remotePath string, w io.Writer) error {
+	return f.download(remotePath, 0, w)
+}
+
+func (f *flakyConnector) download(remotePath string, offset int64, w io.Writer) error {
+	if !f.connected {
+		return io.ErrClosedPipe
+	}
+	f.offsets = append(f.offsets, offset)
+	data := f.data[remotePath][offset:]
+	if f.failures > 0 {
+		f.failures--
+		w.Write([]byte(data[:f.failAfter]))
+		f.connected = false
+		return io.ErrUnexpectedEOF
+	}
+	_, err := w.Write([]byte(data))
+	return err
+}
+
+// resumableConnector adds DownloadFrom to flakyConnector
+type resumableConnector struct{ flakyConnector }
+
+func (r *resumableConnector) DownloadFrom(ctx context.Context, remotePath string, offset int64, w io.Writer) error {
+	return r.download(remotePath, offset, w)
+}
+
+func TestManagerBackupRetriesDownloads(t *testing.T) {
+	payload := "0123456789abcdefghij"
+	newFlaky := func() flakyConnector {
+		return flakyConnector{
+			mockConnector: mockConnector{
+				files: []connector.FileInfo{{Path: "world.sav", Size: int64(len(payload))}},
+				data:  map[string]string{"world.sav": payload},
+			},
+			failAfter: 8,
+			failures:  2,
+		}
+	}
+
+	t.Run("resume", func(t *testing.T) {
+		conn := &resumableConnector{newFlaky()}
+		mgr := Manager{BackupLocation: t.TempDir(), RetryAttempts: 2}
+		if _, _, err := mgr.Backup(context.Background(), conn); err != nil {
+			t.Fatalf("Backup: %v", err)
+		}
+		if fmt.Sprint(conn.offsets) != "[0 8 16]" || conn.connects != 3 {
+			t.Errorf("offsets = %v after %d connects, want [0 8 16] after 3", conn.offsets, conn.connects)
+		}
+		assertStaged(t, mgr, "world.sav", payload)
+	})
+
+	t.Run("restart", func(t *testing.T) {
+		flaky := newFlaky()
+		conn := &flaky
+		mgr := Manager{BackupLocation: t.TempDir(), RetryAttempts: 2}
+		if _, _, err := mgr.Backup(context.Background(), conn); err != nil {
+			t.Fatalf("Backup: %v", err)
+		}
+		if fmt.Sprint(conn.offsets) != "[0 0 0]" {
+			t.Errorf("offsets = %v, want [0 0 0]", conn.offsets)
+		}
+		assertStaged(t, mgr, "world.sav", payload)
+	})
+
+	t.Run("attempts exhausted", func(t *testing.T) {
+		conn := &resumableConnector{newFlaky()}
+		mgr := Manager{BackupLocation: t.TempDir(), RetryAttempts: 1}
+		_, _, err := mgr.Backup(context.Background(), conn)
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("expected download error, got %v", err)
+		}
+	})
+
+	t.Run("size mismatch", func(t *testing.T) {
+		// A download that doesn't match the listing is handled like a file
+		// that changed during the download
+		flaky := newFlaky()
+		flaky.failures = 0
+		flaky.files[0].Size = 25
+		mgr := Manager{BackupLocation: t.TempDir(), ConsistencyRetries: 1, Consistency: ConsistencyFail}
+		_, _, err := mgr.Backup(context.Background(), &flaky)
+		var inconsistent *InconsistentError
+		if !errors.As(err, &inconsistent) || len(flaky.offsets) != 2 {
+			t.Fatalf("err = %v after %d downloads, want InconsistentError after 2", err, len(flaky.offsets))
+		}
+	})
+}
+
+func assertStaged(t *testing.T, mgr Manager, name, want string) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(mgr.BackupLocation, ".tmp", name))
+	if err != nil || string(data) != want {
+		t.Errorf("staged %s = %q (%v), want %q", name, data, err, want)
+	}
+}
+
+func TestManagerBackupBandwidth(t *testing.T) {
+	payload := strings.Repeat("x", 64<<10)
+	conn := &mockConnector{
+		files: []connector.FileInfo{{Path: "world.sav", Size: int64(len(payload))}},
+		data:  map[string]string{"world.sav": payload},
+	}
+
+	// 64 KiB at 128 KiB/s, less the 32 KiB burst, takes ~250ms
+	mgr := Manager{
+		BackupLocation: t.TempDir(),
+		Bandwidth:      throttle.NewLimiter(throttle.NewBucket(throttle.Schedule{Rate: 128 << 10})),
+	}
+	start := time.Now()
+	if _, _, err := mgr.Backup(context.Background(), conn); err != nil {
+		t.Fatalf("Backup error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("throttled backup took %v, want >= 200ms", elapsed)
+	}
+	assertStaged(t, mgr, "world.sav", payload)
+}
+
+// cancelConnector cancels the backup halfway through a download
+type cancelConnector struct {
+	mockConnector
+	cancel context.CancelFunc
+}
+
+func (c *cancelConnector) Download(ctx context.Context, remotePath string, w io.Writer) error {
+	if _, err := w.Write([]byte("hel")); err != nil {
+		return err
+	}
+	c.cancel()
+	_, err := w.Write([]byte("lo"))
+	return err
+}
+
+func TestManagerBackupCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conn := &cancelConnector{
+		mockConnector: mockConnector{files: []connector.FileInfo{{Path: "world.sav", Size: 5}}},
+		cancel:        cancel,
+	}
+
+	dir := t.TempDir()
+	// left behind by a killed run
+	os.WriteFile(filepath.Join(dir, "2024-01-01_000000.tar.gz.partial"), nil, 0o644)
+
+	mgr := Manager{BackupLocation: dir, RetryAttempts: 3}
+	archive, _, err := mgr.Backup(ctx, conn)
+	if !errors.Is(err, context.Canceled) || archive != "" {
+		t.Fatalf("Backup = %q, %v; want context.Canceled", archive, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".tmp", "world.sav")); !os.IsNotExist(err) {
+		t.Errorf("partial download left in staging: %v", err)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(dir, "*.tar.gz*")); len(leftovers) != 0 {
+		t.Errorf("archives left behind: %v", leftovers)
+	}
+}
@@ -0,0 +1,60 @@
+// internal/backup/manifest.go
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Manifest status values
+const (
+	StatusOK           = "ok"
+	StatusInconsistent = "inconsistent"
+	StatusSuspicious   = "suspicious"
+)
+
+// Manifest describes an archive. It is stored next to it as <timestamp>.json.
+type Manifest struct {
+	Created time.Time `json:"created"`
+	Files   int       `json:"files"`
+	Bytes   int64     `json:"bytes"`
+	Status  string    `json:"status"`
+
+	// Changed lists files that kept changing while they were downloaded
+	Changed []string `json:"changed,omitempty"`
+	// Suspicious lists the sanity guards the backup failed
+	Suspicious []string `json:"suspicious,omitempty"`
+}
+
+// ManifestPath returns the manifest location for an archive
+func ManifestPath(archivePath string) string {
+	return strings.TrimSuffix(archivePath, ".tar.gz") + ".json"
+}
+
+// WriteManifest stores m next to archivePath
+func WriteManifest(archivePath string, m Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(ManifestPath(archivePath), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	return nil
+}
+
+// ReadManifest loads the manifest of archivePath
+func ReadManifest(archivePath string) (Manifest, error) {
+	var m Manifest
+	data, err := os.ReadFile(ManifestPath(archivePath))
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("invalid manifest %s: %w", ManifestPath(archivePath), err)
+	}
+	return m, nil
+}
@@ -0,0 +1,56 @@
+// internal/backup/prune.go
+package backup
+
+import (
+	"time"
+)
+
+// PruneResult lists what Prune deleted and what it kept past its age
+type PruneResult struct {
+	Deleted []Archive
+	// Protected archives were old enough to delete but are the last good
+	// ones while the newest backups are suspicious
+	Protected []Archive
+}
+
+// Prune deletes archives in dir older than maxAge. When the newest archive
+// is suspicious, the newest keepGood good archives are kept regardless of
+// age so a broken server cannot age out the last usable backups. With
+// dryRun nothing is removed.
+func Prune(dir string, maxAge time.Duration, keepGood int, now time.Time, dryRun bool) (PruneResult, error) {
+	var res PruneResult
+	if maxAge <= 0 {
+		return res, nil
+	}
+	archives, err := ListArchives(dir)
+	if err != nil || len(archives) == 0 {
+		return res, err
+	}
+
+	protect := map[string]bool{}
+	if !archives[len(archives)-1].Good() {
+		for i := len(archives) - 1; i >= 0 && len(protect) < keepGood; i-- {
+			if archives[i].Good() {
+				protect[archives[i].Path] = true
+			}
+		}
+	}
+
+	cutoff := now.Add(-maxAge)
+	for _, a := range archives {
+		if !a.Time.Before(cutoff) {
+			continue
+		}
+		if protect[a.Path] {
+			res.Protected = append(res.Protected, a)
+			continue
+		}
+		if !dryRun {
+			if err := a.Remove(); err != nil {
+				return res, err
+			}
+		}
+		res.Deleted = append(res.Deleted, a)
+	}
+	return res, nil
+}
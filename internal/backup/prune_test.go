@@ -0,0 +1,106 @@
+// internal/backup/prune_test.go
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeArchive creates an empty archive named after stamp, with a manifest
+// unless m is nil
+func writeArchive(t *testing.T, dir, stamp string, m *Manifest) string {
+	t.Helper()
+	path := filepath.Join(dir, stamp+".tar.gz")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if m != nil {
+		if err := WriteManifest(path, *m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func archiveNames(archives []Archive) []string {
+	var names []string
+	for _, a := range archives {
+		names = append(names, filepath.Base(a.Path))
+	}
+	return names
+}
+
+func TestListArchives(t *testing.T) {
+	dir := t.TempDir()
+	writeArchive(t, dir, "2024-01-02_000000", &Manifest{Status: StatusSuspicious})
+	writeArchive(t, dir, "2024-01-01_000000", nil)
+	os.WriteFile(filepath.Join(dir, "notes.tar.gz"), nil, 0o644)
+	os.Mkdir(filepath.Join(dir, ".tmp"), 0o755)
+
+	archives, err := ListArchives(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 2 || !archives[0].Good() || archives[1].Good() {
+		t.Fatalf("archives = %+v", archives)
+	}
+	if archives[0].Manifest != nil || archives[1].Manifest == nil {
+		t.Errorf("manifests = %v, %v", archives[0].Manifest, archives[1].Manifest)
+	}
+
+	if archives, err := ListArchives(filepath.Join(dir, "missing")); err != nil || archives != nil {
+		t.Errorf("missing dir = %v, %v", archives, err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	month := 30 * 24 * time.Hour
+
+	t.Run("by age", func(t *testing.T) {
+		dir := t.TempDir()
+		old := writeArchive(t, dir, "2023-12-01_000000", &Manifest{Status: StatusOK})
+		writeArchive(t, dir, "2024-01-20_000000", &Manifest{Status: StatusOK})
+
+		res, err := Prune(dir, month, 3, now, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := archiveNames(res.Deleted); len(got) != 1 || got[0] != "2023-12-01_000000.tar.gz" {
+			t.Errorf("deleted = %v", got)
+		}
+		for _, p := range []string{old, ManifestPath(old)} {
+			if _, err := os.Stat(p); !os.IsNotExist(err) {
+				t.Errorf("%s still exists", p)
+			}
+		}
+	})
+
+	t.Run("keeps last good when newest are suspicious", func(t *testing.T) {
+		dir := t.TempDir()
+		writeArchive(t, dir, "2023-11-01_000000", nil)
+		writeArchive(t, dir, "2023-11-02_000000", &Manifest{Status: StatusOK})
+		writeArchive(t, dir, "2023-11-03_000000", &Manifest{Status: StatusInconsistent})
+		writeArchive(t, dir, "2023-11-04_000000", &Manifest{Status: StatusSuspicious})
+		writeArchive(t, dir, "2024-01-30_000000", &Manifest{Status: StatusSuspicious})
+
+		res, err := Prune(dir, month, 2, now, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		deleted, protected := archiveNames(res.Deleted), archiveNames(res.Protected)
+		if len(deleted) != 2 || deleted[0] != "2023-11-01_000000.tar.gz" || deleted[1] != "2023-11-04_000000.tar.gz" {
+			t.Errorf("deleted = %v", deleted)
+		}
+		if len(protected) != 2 || protected[0] != "2023-11-02_000000.tar.gz" {
+			t.Errorf("protected = %v", protected)
+		}
+
+		// dry run leaves everything in place
+		if archives, _ := ListArchives(dir); len(archives) != 5 {
+			t.Errorf("dry run removed archives, %d left", len(archives))
+		}
+	})
+}
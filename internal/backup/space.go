@@ -0,0 +1,195 @@
+// internal/backup/space.go
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/devtheops/gsbt/internal/bytesize"
+)
+
+// spaceHeadroom is added to space estimates for filesystem overhead
+const spaceHeadroom = 1.05
+
+// volume is a filesystem and its free space
+type volume struct {
+	id   string
+	free int64
+}
+
+// SpaceError is returned by Backup when a filesystem is too full to start
+type SpaceError struct {
+	Path string
+	Need int64
+	Free int64
+}
+
+func (e *SpaceError) Error() string {
+	return fmt.Sprintf("not enough space on %s: need %s, %s free", e.Path, bytesize.Format(e.Need), bytesize.Format(e.Free))
+}
+
+// QuotaError is returned by Backup when max_storage can't fit another
+// archive even after pruning
+type QuotaError struct {
+	Max  int64
+	Need int64
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("storage quota of %s exceeded: %s needed after pruning every archive allowed",
+		bytesize.Format(e.Max), bytesize.Format(e.Need))
+}
+
+// reserve makes sure a backup of total bytes fits before anything is
+// downloaded: within the MaxStorage quota once the oldest archives are
+// pruned, keeping the newest KeepGood good ones, and on the temp and backup
+// filesystems, with room to stage the files and write the archive, which
+// may be as large as the files when they don't compress. Nothing is
+// deleted yet, so a failing backup keeps every old archive; it returns the
+// archives to prune once the backup has succeeded.
+func (m *Manager) reserve(tempDir string, total int64) ([]Archive, error) {
+	archives, err := ListArchives(m.BackupLocation)
+	if err != nil {
+		return nil, fmt.Errorf("read archives: %w", err)
+	}
+
+	var prune []Archive
+	if m.MaxStorage > 0 {
+		// the previous archive is the best guess for the next one's size
+		incoming := total
+		if len(archives) > 0 {
+			incoming = archives[len(archives)-1].Size
+		}
+		if prune, err = planQuota(archives, m.MaxStorage, incoming, m.KeepGood); err != nil {
+			return nil, err
+		}
+	}
+	var freed int64
+	for _, a := range prune {
+		freed += a.Size
+	}
+
+	if err := m.checkSpace(tempDir, total, freed); err != nil {
+		return nil, err
+	}
+	return prune, nil
+}
+
+// makeRoom deletes the oldest of the archives reserve planned to prune
+// until the backup filesystem has room for an archive of size bytes. It
+// returns the deleted archives and those left to delete once the new
+// archive is written.
+func (m *Manager) makeRoom(planned []Archive, size int64) (deleted, rest []Archive, err error) {
+	vol, err := m.stat()(m.BackupLocation)
+	if err != nil {
+		// Without the free space, the archive write will tell
+		return nil, planned, nil
+	}
+	for len(planned) > 0 && withHeadroom(size) > vol.free {
+		if err := planned[0].Remove(); err != nil {
+			return deleted, planned, fmt.Errorf("prune for quota: %w", err)
+		}
+		vol.free += planned[0].Size
+		deleted, planned = append(deleted, planned[0]), planned[1:]
+	}
+	return deleted, planned, nil
+}
+
+func (m *Manager) stat() func(path string) (volume, error) {
+	if m.statVolume != nil {
+		return m.statVolume
+	}
+	return statVolume
+}
+
+// withHeadroom adds filesystem overhead to a size estimate
+func withHeadroom(n int64) int64 {
+	return int64(float64(n) * spaceHeadroom)
+}
+
+// checkSpace compares the space needed for staging and the archive with
+// what the filesystems have free. Bytes freed by pruning only count for the
+// archive, since the files are staged before anything is pruned.
+func (m *Manager) checkSpace(tempDir string, total, freed int64) error {
+	stat := m.stat()
+	tempVol, err := stat(tempDir)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	backupVol, err := stat(m.BackupLocation)
+	if err != nil {
+		return err
+	}
+
+	// Staged files from the previous run are overwritten in place
+	staging := max(total-dirSize(tempDir), 0)
+	archive := total
+
+	if tempVol.id == backupVol.id {
+		if n := withHeadroom(staging); n > backupVol.free {
+			return &SpaceError{Path: m.BackupLocation, Need: n, Free: backupVol.free}
+		}
+		if n := withHeadroom(staging + archive); n > backupVol.free+freed {
+			return &SpaceError{Path: m.BackupLocation, Need: n, Free: backupVol.free + freed}
+		}
+		return nil
+	}
+	if n := withHeadroom(staging); n > tempVol.free {
+		return &SpaceError{Path: tempDir, Need: n, Free: tempVol.free}
+	}
+	if n := withHeadroom(archive); n > backupVol.free+freed {
+		return &SpaceError{Path: m.BackupLocation, Need: n, Free: backupVol.free + freed}
+	}
+	return nil
+}
+
+// planQuota picks the oldest archives to delete so the rest plus an
+// incoming archive fit in max. The newest keepGood good archives are never
+// picked.
+func planQuota(archives []Archive, max, incoming int64, keepGood int) ([]Archive, error) {
+	keep := map[string]bool{}
+	for i := len(archives) - 1; i >= 0 && len(keep) < keepGood; i-- {
+		if archives[i].Good() {
+			keep[archives[i].Path] = true
+		}
+	}
+
+	used := incoming
+	for _, a := range archives {
+		used += a.Size
+	}
+	var prune []Archive
+	for _, a := range archives {
+		if used <= max {
+			break
+		}
+		if keep[a.Path] {
+			continue
+		}
+		prune = append(prune, a)
+		used -= a.Size
+	}
+	if used > max {
+		return nil, &QuotaError{Max: max, Need: used}
+	}
+	return prune, nil
+}
+
+// dirSize sums the sizes of the files below dir
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,159 @@
+// internal/backup/space_test.go
+package backup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/devtheops/gsbt/internal/connector"
+)
+
+func fakeVolumes(free map[string]int64) func(string) (volume, error) {
+	return func(path string) (volume, error) {
+		for prefix, n := range free {
+			if strings.HasPrefix(path, prefix) {
+				return volume{id: prefix, free: n}, nil
+			}
+		}
+		return volume{}, errors.New("unknown volume")
+	}
+}
+
+func TestManagerBackupSpaceCheck(t *testing.T) {
+	ctx := context.Background()
+	conn := &mockConnector{
+		files: []connector.FileInfo{{Path: "world.sav", Size: 1000}},
+		data:  map[string]string{"world.sav": strings.Repeat("x", 1000)},
+	}
+
+	t.Run("shared volume", func(t *testing.T) {
+		dir := t.TempDir()
+		// staging plus archive need 2100 bytes with headroom
+		mgr := Manager{BackupLocation: dir, statVolume: fakeVolumes(map[string]int64{dir: 2000})}
+		_, _, err := mgr.Backup(ctx, conn)
+		var space *SpaceError
+		if !errors.As(err, &space) || space.Path != dir || space.Need != 2100 {
+			t.Fatalf("err = %v, want SpaceError needing 2100 bytes", err)
+		}
+
+		mgr.statVolume = fakeVolumes(map[string]int64{dir: 2100})
+		if _, _, err := mgr.Backup(ctx, conn); err != nil {
+			t.Fatalf("Backup error: %v", err)
+		}
+		// the staged copy is reused, so the next run only needs the archive
+		mgr.statVolume = fakeVolumes(map[string]int64{dir: 1050})
+		if _, _, err := mgr.Backup(ctx, conn); err != nil {
+			t.Errorf("Backup with staged files: %v", err)
+		}
+	})
+
+	t.Run("separate temp volume", func(t *testing.T) {
+		backups, temp := t.TempDir(), t.TempDir()
+		mgr := Manager{BackupLocation: backups, TempDir: temp,
+			statVolume: fakeVolumes(map[string]int64{backups: 1 << 20, temp: 500})}
+		_, _, err := mgr.Backup(ctx, conn)
+		var space *SpaceError
+		if !errors.As(err, &space) || space.Path != temp {
+			t.Fatalf("err = %v, want SpaceError for %s", err, temp)
+		}
+	})
+}
+
+func TestManagerBackupQuota(t *testing.T) {
+	ctx := context.Background()
+	conn := &mockConnector{
+		files: []connector.FileInfo{{Path: "world.sav", Size: 5}},
+		data:  map[string]string{"world.sav": "hello"},
+	}
+
+	dir := t.TempDir()
+	oldest := writeArchive(t, dir, "2024-01-01_000000", &Manifest{Status: StatusOK})
+	middle := writeArchive(t, dir, "2024-01-02_000000", &Manifest{Status: StatusOK})
+	newest := writeArchive(t, dir, "2024-01-03_000000", &Manifest{Status: StatusOK})
+	for _, p := range []string{oldest, middle, newest} {
+		os.WriteFile(p, make([]byte, 100), 0o644)
+	}
+
+	// a failing backup leaves the old archives alone
+	mgr := Manager{BackupLocation: dir, MaxStorage: 300, KeepGood: 2, Guards: Guards{MinFiles: 2}}
+	if _, stats, err := mgr.Backup(ctx, conn); err == nil || len(stats.Pruned) != 0 {
+		t.Fatalf("Backup = %v, pruned %v, want a guard failure and nothing pruned", err, stats.Pruned)
+	}
+	if archives, _ := ListArchives(dir); len(archives) != 3 {
+		t.Fatalf("%d archives left after a failed backup, want 3", len(archives))
+	}
+
+	// room for three archives: the oldest goes to make room for the new one
+	mgr.Guards = Guards{}
+	archive, stats, err := mgr.Backup(ctx, conn)
+	if err != nil {
+		t.Fatalf("Backup error: %v", err)
+	}
+	if len(stats.Pruned) != 1 || stats.Pruned[0] != oldest {
+		t.Errorf("pruned = %v, want %s", stats.Pruned, oldest)
+	}
+	if _, err := os.Stat(oldest); !os.IsNotExist(err) {
+		t.Errorf("%s not pruned", oldest)
+	}
+	os.Remove(archive)
+	os.Remove(ManifestPath(archive))
+
+	// both remaining archives are protected
+	mgr.MaxStorage = 150
+	_, _, err = mgr.Backup(ctx, conn)
+	var quota *QuotaError
+	if !errors.As(err, &quota) {
+		t.Fatalf("err = %v, want QuotaError", err)
+	}
+	if archives, _ := ListArchives(dir); len(archives) != 2 {
+		t.Errorf("%d archives left, want 2", len(archives))
+	}
+}
+
+func TestManagerMakeRoom(t *testing.T) {
+	dir := t.TempDir()
+	var planned []Archive
+	for _, name := range []string{"2024-01-01_000000", "2024-01-02_000000"} {
+		p := writeArchive(t, dir, name, nil)
+		planned = append(planned, Archive{Path: p, Size: 100})
+	}
+
+	// only the oldest has to go for the archive to fit now
+	mgr := Manager{BackupLocation: dir, statVolume: fakeVolumes(map[string]int64{dir: 50})}
+	deleted, rest, err := mgr.makeRoom(planned, 100)
+	if err != nil {
+		t.Fatalf("makeRoom error: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].Path != planned[0].Path || len(rest) != 1 {
+		t.Errorf("deleted %v, left %v, want only the oldest deleted", deleted, rest)
+	}
+	if _, err := os.Stat(planned[1].Path); err != nil {
+		t.Errorf("%s deleted early: %v", planned[1].Path, err)
+	}
+}
+
+func TestPlanQuota(t *testing.T) {
+	archives := []Archive{
+		{Path: "a", Size: 100},
+		{Path: "b", Size: 100, Manifest: &Manifest{Status: StatusOK}},
+		{Path: "c", Size: 100, Manifest: &Manifest{Status: StatusSuspicious}},
+	}
+
+	prune, err := planQuota(archives, 1000, 100, 1)
+	if err != nil || len(prune) != 0 {
+		t.Errorf("under quota = %v, %v", prune, err)
+	}
+
+	// b is the newest good archive; the suspicious one can go
+	prune, err = planQuota(archives, 200, 100, 1)
+	if err != nil || len(prune) != 2 || prune[0].Path != "a" || prune[1].Path != "c" {
+		t.Errorf("prune = %+v, %v; want a and c", prune, err)
+	}
+
+	if _, err := planQuota(archives, 150, 100, 1); err == nil {
+		t.Error("expected QuotaError")
+	}
+}
@@ -0,0 +1,66 @@
+// internal/backup/timeout.go
+package backup
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TimeoutError reports which configured limit stopped a backup
+type TimeoutError struct {
+	// Setting is the config key of the limit, e.g. "list_timeout"
+	Setting string
+	After   time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Setting == "idle_transfer_timeout" {
+		return fmt.Sprintf("transfer stalled: no data for %s (%s)", e.After, e.Setting)
+	}
+	return fmt.Sprintf("timed out after %s (%s)", e.After, e.Setting)
+}
+
+// withTimeout runs fn with a deadline of d, or without one if d is zero.
+// When the deadline is what stopped fn, a TimeoutError is returned instead
+// of whatever fn failed with.
+func withTimeout(ctx context.Context, d time.Duration, setting string, fn func(context.Context) error) error {
+	if d <= 0 {
+		return fn(ctx)
+	}
+	timeout := &TimeoutError{Setting: setting, After: d}
+	ctx, cancel := context.WithTimeoutCause(ctx, d, timeout)
+	defer cancel()
+
+	err := fn(ctx)
+	if err != nil && context.Cause(ctx) == timeout {
+		return timeout
+	}
+	return err
+}
+
+// watchdog cancels a transfer that hasn't made progress for a while.
+// Every write kicks it; a nil watchdog does nothing.
+type watchdog struct {
+	timer *time.Timer
+	idle  time.Duration
+}
+
+func newWatchdog(idle time.Duration, fire func()) *watchdog {
+	if idle <= 0 {
+		return nil
+	}
+	return &watchdog{timer: time.AfterFunc(idle, fire), idle: idle}
+}
+
+func (w *watchdog) kick() {
+	if w != nil {
+		w.timer.Reset(w.idle)
+	}
+}
+
+func (w *watchdog) stop() {
+	if w != nil {
+		w.timer.Stop()
+	}
+}
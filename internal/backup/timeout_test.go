@@ -0,0 +1,99 @@
+// internal/backup/timeout_test.go
+package backup
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/devtheops/gsbt/internal/connector"
+)
+
+// hangConnector blocks in the chosen operation until its context is done.
+// Downloads send half the file first and hang only stalls times.
+type hangConnector struct {
+	mockConnector
+	hang   string
+	stalls int
+}
+
+func (h *hangConnector) wait(ctx context.Context, op string) error {
+	if h.hang != op {
+		return nil
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (h *hangConnector) Connect(ctx context.Context) error {
+	h.connected = true
+	return h.wait(ctx, "connect")
+}
+
+func (h *hangConnector) List(ctx context.Context) ([]connector.FileInfo, error) {
+	return h.files, h.wait(ctx, "list")
+}
+
+func (h *hangConnector) Download(ctx context.Context, remotePath string, w io.Writer) error {
+	data := h.data[remotePath]
+	if h.hang == "download" && h.stalls > 0 {
+		h.stalls--
+		w.Write([]byte(data[:len(data)/2]))
+		return h.wait(ctx, "download")
+	}
+	_, err := io.WriteString(w, data)
+	return err
+}
+
+func TestManagerBackupTimeouts(t *testing.T) {
+	const short = 50 * time.Millisecond
+	tests := []struct {
+		name    string
+		hang    string
+		mgr     Manager
+		setting string
+	}{
+		{"connect", "connect", Manager{ConnectTimeout: short}, "connect_timeout"},
+		{"list", "list", Manager{ListTimeout: short}, "list_timeout"},
+		{"idle", "download", Manager{IdleTimeout: short}, "idle_transfer_timeout"},
+		{"server", "download", Manager{ServerTimeout: short, RetryAttempts: 10}, "server_timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &hangConnector{
+				mockConnector: mockConnector{
+					files: []connector.FileInfo{{Path: "world.sav", Size: 4}},
+					data:  map[string]string{"world.sav": "data"},
+				},
+				hang:   tt.hang,
+				stalls: 100,
+			}
+			mgr := tt.mgr
+			mgr.BackupLocation = t.TempDir()
+
+			_, _, err := mgr.Backup(context.Background(), conn)
+			var timeout *TimeoutError
+			if !errors.As(err, &timeout) || timeout.Setting != tt.setting {
+				t.Fatalf("err = %v, want %s", err, tt.setting)
+			}
+		})
+	}
+}
+
+func TestManagerBackupRetriesStalledDownload(t *testing.T) {
+	conn := &hangConnector{
+		mockConnector: mockConnector{
+			files: []connector.FileInfo{{Path: "world.sav", Size: 4}},
+			data:  map[string]string{"world.sav": "data"},
+		},
+		hang:   "download",
+		stalls: 1,
+	}
+	mgr := Manager{BackupLocation: t.TempDir(), IdleTimeout: 50 * time.Millisecond, RetryAttempts: 1}
+	if _, _, err := mgr.Backup(context.Background(), conn); err != nil {
+		t.Fatalf("Backup error: %v", err)
+	}
+	assertStaged(t, mgr, "world.sav", "data")
+}
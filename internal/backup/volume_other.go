@@ -0,0 +1,11 @@
+// internal/backup/volume_other.go
+//go:build !linux && !darwin && !freebsd && !windows
+
+package backup
+
+import "errors"
+
+// statVolume is not implemented here; the space check is skipped
+func statVolume(path string) (volume, error) {
+	return volume{}, errors.ErrUnsupported
+}
@@ -0,0 +1,27 @@
+// internal/backup/volume_unix.go
+//go:build linux || darwin || freebsd
+
+package backup
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// statVolume reports the filesystem holding path and its space available
+// to unprivileged users
+func statVolume(path string) (volume, error) {
+	var fs unix.Statfs_t
+	if err := unix.Statfs(path, &fs); err != nil {
+		return volume{}, fmt.Errorf("statfs %s: %w", path, err)
+	}
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil {
+		return volume{}, fmt.Errorf("stat %s: %w", path, err)
+	}
+	return volume{
+		id:   fmt.Sprint(st.Dev),
+		free: int64(fs.Bavail) * int64(fs.Bsize),
+	}, nil
+}
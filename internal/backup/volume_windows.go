@@ -0,0 +1,29 @@
+// internal/backup/volume_windows.go
+//go:build windows
+
+package backup
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"golang.org/x/sys/windows"
+)
+
+// statVolume reports the volume holding path and its space available to
+// the current user
+func statVolume(path string) (volume, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return volume{}, err
+	}
+	name, err := windows.UTF16PtrFromString(abs)
+	if err != nil {
+		return volume{}, err
+	}
+	var free, total, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(name, &free, &total, &totalFree); err != nil {
+		return volume{}, fmt.Errorf("free space of %s: %w", path, err)
+	}
+	return volume{id: filepath.VolumeName(abs), free: int64(free)}, nil
+}
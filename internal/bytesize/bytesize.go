@@ -0,0 +1,96 @@
+// internal/bytesize/bytesize.go
+package bytesize
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var unitPowers = map[string]int{"": 0, "k": 1, "m": 2, "g": 3, "t": 4}
+
+var sizeRe = regexp.MustCompile(`^([0-9]*\.?[0-9]+)\s*([kmgt]?)(i?)(bytes?|b|bits?|bps)?$`)
+
+// parse reads a number with an optional unit into bytes, or bits when the
+// unit says so. KB/MB/GB are decimal, KiB/MiB/GiB binary.
+func parse(s string) (value float64, bits bool, ok bool) {
+	m := sizeRe.FindStringSubmatch(strings.ToLower(s))
+	if m == nil || m[3] == "i" && m[2] == "" {
+		return 0, false, false
+	}
+	value, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, false, false
+	}
+	base := 1000.0
+	if m[3] == "i" {
+		base = 1024
+	}
+	value *= math.Pow(base, float64(unitPowers[m[2]]))
+	// Mbps is bits, MBps bytes
+	bits = strings.HasPrefix(m[4], "bit") || m[4] == "bps" && strings.Contains(s, "bps")
+	return value, bits, true
+}
+
+// Parse parses a size such as "500MB" or "2GiB" into bytes. KB/MB/GB are
+// decimal, KiB/MiB/GiB binary, a bare number is bytes. "" returns 0.
+func Parse(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	value, bits, ok := parse(s)
+	if !ok || bits || strings.HasSuffix(strings.ToLower(s), "ps") {
+		return 0, fmt.Errorf("invalid size %q (want e.g. 500MB)", s)
+	}
+	return int64(value), nil
+}
+
+// ParseRate parses a bandwidth such as "10MiB/s", "500KB/s" or "100Mbit/s"
+// into bytes per second, with the units of Parse; bit units (Mbit/s, Mbps)
+// are divided by eight. "", "0" and "unlimited" return 0 (no limit).
+func ParseRate(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "unlimited") {
+		return 0, nil
+	}
+	value, bits, ok := parse(strings.TrimSuffix(s, "/s"))
+	if !ok {
+		return 0, fmt.Errorf("invalid bandwidth %q (want e.g. 10MiB/s)", s)
+	}
+	if bits {
+		value /= 8
+	}
+	rate := int64(value)
+	if value > 0 && rate == 0 {
+		rate = 1
+	}
+	return rate, nil
+}
+
+// Format renders bytes with binary units, e.g. "1.5 GiB"
+func Format(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	v := float64(n) / 1024
+	unit := "KiB"
+	for _, next := range []string{"MiB", "GiB", "TiB"} {
+		if v < 1024 {
+			break
+		}
+		v /= 1024
+		unit = next
+	}
+	return fmt.Sprintf("%.1f %s", v, unit)
+}
+
+// FormatRate renders bytes per second like Format, e.g. "10.0 MiB/s"
+func FormatRate(rate int64) string {
+	if rate <= 0 {
+		return "unlimited"
+	}
+	return Format(rate) + "/s"
+}
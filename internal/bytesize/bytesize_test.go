@@ -0,0 +1,79 @@
+// internal/bytesize/bytesize_test.go
+package bytesize
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	for in, want := range map[string]int64{
+		"":       0,
+		"512":    512,
+		"500MB":  500e6,
+		"1.5 kb": 1500,
+		"2GiB":   2 << 30,
+		"1t":     1e12,
+		"10MiB":  10 << 20,
+		"3bytes": 3,
+	} {
+		if got, err := Parse(in); err != nil || got != want {
+			t.Errorf("Parse(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"lots", "5ib", "-1MB", "100Mbit", "2MBps", "10MiB/s"} {
+		if _, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) expected error", in)
+		}
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"unlimited", 0, false},
+		{"0", 0, false},
+		{"500", 500, false},
+		{"10MiB/s", 10 << 20, false},
+		{"10 MiB/s", 10 << 20, false},
+		{"1.5MiB", 3 << 19, false},
+		{"500KB/s", 500_000, false},
+		{"500kbps", 62_500, false},
+		{"2MBps", 2_000_000, false},
+		{"100Mbit/s", 12_500_000, false},
+		{"2GiB/s", 2 << 30, false},
+		{"10M", 10_000_000, false},
+		{"1bit/s", 1, false},
+		{"fast", 0, true},
+		{"10 iB/s", 0, true},
+		{"-1MiB/s", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRate(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRate(%q) = %d, %v; want %d (err %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := map[int64]string{
+		512:      "512 B",
+		1536:     "1.5 KiB",
+		10 << 20: "10.0 MiB",
+		3 << 30:  "3.0 GiB",
+		5 << 40:  "5.0 TiB",
+	}
+	for in, want := range tests {
+		if got := Format(in); got != want {
+			t.Errorf("Format(%d) = %q, want %q", in, got, want)
+		}
+	}
+	if got := FormatRate(0); got != "unlimited" {
+		t.Errorf("FormatRate(0) = %q", got)
+	}
+	if got := FormatRate(10 << 20); got != "10.0 MiB/s" {
+		t.Errorf("FormatRate = %q, want 10.0 MiB/s", got)
+	}
+}
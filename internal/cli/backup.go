@@ -2,22 +2,35 @@
 package cli
 
 import (
-    "context"
-    "fmt"
-    "sync"
-    "time"
-
-    "github.com/devtheops/gsbt/internal/backup"
-    "github.com/devtheops/gsbt/internal/config"
-    "github.com/devtheops/gsbt/internal/connector"
-    "github.com/devtheops/gsbt/internal/log"
-    "github.com/devtheops/gsbt/internal/progress"
-    "github.com/spf13/cobra"
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devtheops/gsbt/internal/backup"
+	"github.com/devtheops/gsbt/internal/bytesize"
+	"github.com/devtheops/gsbt/internal/config"
+	"github.com/devtheops/gsbt/internal/connector"
+	"github.com/devtheops/gsbt/internal/hooks"
+	"github.com/devtheops/gsbt/internal/log"
+	"github.com/devtheops/gsbt/internal/metrics"
+	"github.com/devtheops/gsbt/internal/notify"
+	"github.com/devtheops/gsbt/internal/progress"
+	"github.com/devtheops/gsbt/internal/rcon"
+	"github.com/devtheops/gsbt/internal/throttle"
+	"github.com/spf13/cobra"
 )
 
 var (
 	backupServer     string
 	backupSequential bool
+	backupWait       bool
+	backupNoWait     bool
 )
 
 // allow tests to inject mocks
@@ -28,20 +41,29 @@ var backupCmd = &cobra.Command{
 	Short: "Backup gameserver files",
 	Long:  `Download and archive files from configured gameservers.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
-		return runBackup(ctx, cmd)
+		return runBackup(cmd.Context(), cmd)
 	},
 }
 
 func init() {
 	backupCmd.Flags().StringVar(&backupServer, "server", "", "backup specific server only")
 	backupCmd.Flags().BoolVar(&backupSequential, "sequential", false, "run backups sequentially")
+	lockFlags(backupCmd, &backupWait, &backupNoWait, false)
 	rootCmd.AddCommand(backupCmd)
 }
 
-func runBackup(ctx context.Context, cmd *cobra.Command) error {
+func runBackup(ctx context.Context, cmd *cobra.Command) (err error) {
+	// On a terminal, progress is shown as live bars that log lines go above
+	out, errOut := cmd.OutOrStdout(), cmd.ErrOrStderr()
+	var display *progress.Display
+	if GetOutputFormat() != "json" && !IsQuiet() && progress.IsTerminal(out) {
+		display = progress.NewDisplay(out)
+		defer display.Stop()
+		out, errOut = display.Writer(out), display.Writer(errOut)
+	}
+
 	// Setup logger
-	logger := log.NewWithWriters(cmd.OutOrStdout(), cmd.ErrOrStderr())
+	logger := log.NewWithWriters(out, errOut)
 	logger.SetOutputFormat(GetOutputFormat())
 	logger.SetQuiet(IsQuiet())
 	logger.SetVerbose(IsVerbose())
@@ -56,142 +78,383 @@ func runBackup(ctx context.Context, cmd *cobra.Command) error {
 		return err
 	}
 
-	servers := cfg.Servers
-	if backupServer != "" {
+	servers, err := selectServers(cfg.Servers, backupServer)
+	if err != nil {
+		return err
+	}
+
+	globalBucket, err := newGlobalBucket(cfg)
+	if err != nil {
+		return err
+	}
+	targets, err := toNotifiers(cfg.Notifications)
+	if err != nil {
+		return err
+	}
+	wait := shouldWait(backupWait, backupNoWait, false)
+
+	check, logger := startHealthcheck(ctx, cfg.Defaults.HealthcheckURL, logger)
+	defer func() { check.finish(ctx, err) }()
+
+	type result struct {
+		name    string
+		success bool
+		err     error
+		events  []notify.Event
+		run     metrics.Run
+	}
+
+	historyFile := historyPath(cfgPath, cfg)
+	runOne := func(srv config.Server) result {
+		start := time.Now()
+		archivePath, stats, err := runServerBackup(ctx, cfg, srv, logger, globalBucket, display, wait)
+		recordHistory(historyFile, backupRecord(ctx, srv.Name, start, archivePath, stats, err), logger)
+		return result{
+			success: err == nil,
+			err:     err,
+			events:  backupEvents(srv.Name, archivePath, stats, err),
+			run:     backupRun(srv.Name, start, stats, err),
+		}
+	}
+
+	var results []result
+	if backupSequential || len(servers) == 1 {
+		for _, srv := range servers {
+			if ctx.Err() != nil {
+				break
+			}
+			res := runOne(srv)
+			res.name = srv.Name
+			results = append(results, res)
+		}
+	} else {
+		var wg sync.WaitGroup
+		ch := make(chan result, len(servers))
+
+		for _, srv := range servers {
+			srv := srv
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				res := runOne(srv)
+				res.name = srv.Name
+				ch <- res
+			}()
+		}
+
+		wg.Wait()
+		close(ch)
+		for res := range ch {
+			results = append(results, res)
+		}
+	}
+
+	// One summary for the whole run, in config order
+	summary := notify.Summary{Command: "backup"}
+	for _, srv := range servers {
+		for _, res := range results {
+			if res.name == srv.Name {
+				summary.Events = append(summary.Events, res.events...)
+			}
+		}
+	}
+	sendNotifications(ctx, targets, summary, logger)
+
+	var runs []metrics.Run
+	for _, res := range results {
+		// A cancelled backup says nothing about the server's health
+		if res.err != nil && ctx.Err() != nil {
+			continue
+		}
+		runs = append(runs, res.run)
+	}
+	recordMetrics(ctx, metrics.New(), cfg.Metrics.Textfile, runs, logger)
+
+	successes := 0
+	failures := 0
+	var completed []string
+	for _, res := range results {
+		if res.success {
+			successes++
+			completed = append(completed, res.name)
+		} else {
+			failures++
+		}
+	}
+
+	if ctx.Err() != nil {
+		var incomplete []string
+		for _, srv := range servers {
+			if !slices.Contains(completed, srv.Name) {
+				incomplete = append(incomplete, srv.Name)
+			}
+		}
+		logger.Warn(fmt.Sprintf("[yellow]backup interrupted:[/yellow] %d of %d servers completed, not completed: %s",
+			successes, len(servers), strings.Join(incomplete, ", ")),
+			log.Meta{"completed": completed, "incomplete": incomplete})
+		return fmt.Errorf("%w: %d of %d servers completed", ErrInterrupted, successes, len(servers))
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("backup complete with failures: %d success, %d failed", successes, failures)
+	}
+
+	logger.Info(fmt.Sprintf("[bold][green]backup complete[/green][/bold] (%d success)", successes))
+
+	return nil
+}
+
+// newGlobalBucket returns the bucket enforcing global_bandwidth_limit, one
+// bucket shared by every server's transfers
+func newGlobalBucket(cfg *config.Config) (*throttle.Bucket, error) {
+	schedule, err := toSchedule(cfg.Defaults.GlobalBandwidthLimit, cfg.Defaults.BandwidthSchedule,
+		func(w config.BandwidthWindow) string { return w.GlobalLimit })
+	if err != nil {
+		return nil, fmt.Errorf("global_bandwidth_limit: %w", err)
+	}
+	return throttle.NewBucket(schedule), nil
+}
+
+// runServerBackup backs up one server, logging its progress and outcome
+// and pinging its health check, and returns the archive written.
+// display, if set, shows the download progress as a live bar.
+// wait decides what happens when another run holds the server's lock.
+func runServerBackup(ctx context.Context, cfg *config.Config, srv config.Server, logger *log.Logger, globalBucket *throttle.Bucket, display *progress.Display, wait bool) (string, backup.Stats, error) {
+	check, serverLogger := startHealthcheck(ctx, srv.HealthcheckURL, withServer(logger, srv.Name))
+	archivePath, stats, err := backupServerArchive(ctx, cfg, srv, serverLogger, globalBucket, display, wait)
+	check.finish(ctx, err)
+	return archivePath, stats, err
+}
+
+// backupServerArchive does the work of runServerBackup; logger already
+// carries the server's prefix
+func backupServerArchive(ctx context.Context, cfg *config.Config, srv config.Server, serverLogger *log.Logger, globalBucket *throttle.Bucket, display *progress.Display, wait bool) (string, backup.Stats, error) {
+	serverLogger.Info("[yellow]starting backup[/yellow]")
+
+	connCfg, err := toConnectorConfig(srv, cfg.Defaults)
+	if err != nil {
+		serverLogger.Error(fmt.Sprintf("[red]config error:[/red] %v", err))
+		return "", backup.Stats{}, err
+	}
+	connCfg.Log = func(line string) { serverLogger.Warn(line) }
+
+	limit, windows := srv.GetBandwidth(cfg.Defaults)
+	schedule, err := toSchedule(limit, windows, func(w config.BandwidthWindow) string { return w.Limit })
+	if err != nil {
+		err = fmt.Errorf("bandwidth_limit: %w", err)
+		serverLogger.Error(fmt.Sprintf("[red]config error:[/red] %v", err))
+		return "", backup.Stats{}, err
+	}
+	bandwidth := throttle.NewLimiter(throttle.NewBucket(schedule), globalBucket)
+	connCfg.Bandwidth = bandwidth
+	timeouts := srv.GetTimeouts(cfg.Defaults)
+	connCfg.ConnectTimeout = seconds(timeouts.Connect)
+	if len(bandwidth) > 0 {
+		serverLogger.Debug(fmt.Sprintf("bandwidth limit %s", bytesize.FormatRate(bandwidth.Rate())))
+	}
+
+	consistency, err := backup.ParseConsistency(srv.GetConsistency(cfg.Defaults))
+	if err != nil {
+		serverLogger.Error(fmt.Sprintf("[red]config error:[/red] %v", err))
+		return "", backup.Stats{}, err
+	}
+	guards, err := toGuards(srv.GetGuards(cfg.Defaults))
+	if err != nil {
+		serverLogger.Error(fmt.Sprintf("[red]config error:[/red] %v", err))
+		return "", backup.Stats{}, err
+	}
+	maxStorage, err := bytesize.Parse(srv.GetMaxStorage(cfg.Defaults))
+	if err != nil {
+		err = fmt.Errorf("max_storage: %w", err)
+		serverLogger.Error(fmt.Sprintf("[red]config error:[/red] %v", err))
+		return "", backup.Stats{}, err
+	}
+	hookCfg := srv.GetHooks(cfg.Defaults)
+	preFailure, err := hooks.ParsePreFailure(hookCfg.PreFailure)
+	if err != nil {
+		err = fmt.Errorf("hooks.pre_failure: %w", err)
+		serverLogger.Error(fmt.Sprintf("[red]config error:[/red] %v", err))
+		return "", backup.Stats{}, err
+	}
+	runner := hookRunner{hooks: hookCfg, logger: serverLogger}
+
+	if dir := srv.GetBackupLocation(cfg.Defaults); dir != "" {
+		l, err := lockServer(ctx, dir, "backup", wait, serverLogger)
+		if err != nil && ctx.Err() != nil {
+			serverLogger.Warn("[yellow]backup cancelled[/yellow]")
+			return "", backup.Stats{}, err
+		}
+		if err != nil {
+			serverLogger.Error(fmt.Sprintf("[red]lock error:[/red] %v", err))
+			return "", backup.Stats{}, err
+		}
+		defer l.Release()
+	}
+
+	conn, err := newConnector(connCfg)
+	if err != nil {
+		serverLogger.Error(fmt.Sprintf("[red]init error:[/red] %v", err))
+		return "", backup.Stats{}, err
+	}
+
+	// Staging copies are reused between runs (rsync deltas), so servers
+	// sharing defaults.temp_dir each get their own subdirectory
+	tempDir := cfg.Defaults.TempDir
+	if tempDir != "" {
+		tempDir = filepath.Join(tempDir, srv.Name)
+	}
+
+	reporter := progress.NewWithOptions(serverLogger, GetOutputFormat(), progress.Options{
+		Server:   srv.Name,
+		Interval: seconds(cfg.Defaults.ProgressInterval),
+		Display:  display,
+	})
+	// A failed download leaves the bar behind
+	defer display.Remove(srv.Name)
+	mgr := backup.Manager{
+		BackupLocation: srv.GetBackupLocation(cfg.Defaults),
+		TempDir:        tempDir,
+		Progress:       reporter,
+		RetryAttempts:  cfg.Defaults.RetryAttempts,
+		RetryDelay:     seconds(cfg.Defaults.RetryDelay),
+		RetryBackoff:   cfg.Defaults.RetryBackoff,
+		Bandwidth:      bandwidth,
+
+		Consistency:        consistency,
+		ConsistencyRetries: srv.GetConsistencyRetries(cfg.Defaults),
+		Guards:             guards,
+		MaxStorage:         maxStorage,
+		KeepGood:           srv.GetPruneKeepGood(cfg.Defaults),
+		ConnectTimeout:     seconds(timeouts.Connect),
+		ListTimeout:        seconds(timeouts.List),
+		IdleTimeout:        seconds(timeouts.IdleTransfer),
+		ServerTimeout:      seconds(timeouts.Server),
+	}
+
+	start := time.Now()
+	env := hooks.Env{Server: srv.Name, BackupLocation: mgr.BackupLocation}
+	var (
+		archivePath string
+		stats       backup.Stats
+	)
+	err = runner.run(ctx, hooks.PreBackup, env)
+	if err != nil && preFailure == hooks.Continue && ctx.Err() == nil {
+		serverLogger.Warn(fmt.Sprintf("[yellow]hook failed, backing up anyway:[/yellow] %v", err))
+		err = nil
+	}
+	if err == nil {
+		err = withRCON(ctx, srv, serverLogger, func() error {
+			var err error
+			archivePath, stats, err = mgr.Backup(ctx, conn)
+			return err
+		})
+	}
+	for _, path := range stats.Pruned {
+		serverLogger.Info(fmt.Sprintf("pruned %s to stay within max_storage", filepath.Base(path)),
+			log.Meta{"archive_path": path})
+	}
+
+	// Post and failure hooks run even when gsbt is being stopped, like RCON
+	// post commands
+	hookCtx := context.WithoutCancel(ctx)
+	switch {
+	case err == nil:
+		if len(stats.Changed) > 0 {
+			serverLogger.Warn(fmt.Sprintf("[yellow]files changed during download:[/yellow] %s",
+				strings.Join(stats.Changed, ", ")), log.Meta{"changed": stats.Changed})
+		}
+		if len(stats.Suspicious) > 0 {
+			serverLogger.Warn(fmt.Sprintf("[yellow]backup marked suspicious:[/yellow] %s",
+				strings.Join(stats.Suspicious, "; ")), log.Meta{"suspicious": stats.Suspicious})
+		}
+
+		serverLogger.Info(fmt.Sprintf("[green]saved[/green] %s (%d files, %.1f MB, %.1fs)",
+			archivePath, stats.Files, float64(stats.Bytes)/1e6, time.Since(start).Seconds()),
+			log.Meta{
+				"archive_path": archivePath,
+				"files":        stats.Files,
+				"bytes":        stats.Bytes,
+				"duration_sec": time.Since(start).Seconds(),
+			})
+
+		env.Status = hooks.StatusSuccess
+		env.ArchivePath, env.Files, env.Bytes = archivePath, stats.Files, stats.Bytes
+		env.Duration = time.Since(start)
+		// The archive is written; a failing post hook doesn't undo that
+		if hookErr := runner.run(hookCtx, hooks.PostBackup, env); hookErr != nil {
+			serverLogger.Warn(fmt.Sprintf("[yellow]hook failed:[/yellow] %v", hookErr))
+		}
+	case ctx.Err() != nil:
+		serverLogger.Warn("[yellow]backup cancelled[/yellow]")
+	default:
+		serverLogger.Error(fmt.Sprintf("[red]backup failed:[/red] %v", err))
+	}
+
+	if err != nil {
+		env.Status = hooks.StatusFailed
+		if ctx.Err() != nil {
+			env.Status = hooks.StatusCancelled
+		}
+		env.Error = err.Error()
+		env.Duration = time.Since(start)
+		if hookErr := runner.run(hookCtx, hooks.OnFailure, env); hookErr != nil {
+			serverLogger.Error(fmt.Sprintf("[red]hook failed:[/red] %v", hookErr))
+		}
+		return archivePath, stats, err
+	}
+	return archivePath, stats, nil
+}
+
+// selectServers returns the server called name, or all servers if name is empty
+func selectServers(servers []config.Server, name string) ([]config.Server, error) {
+	if name != "" {
 		filtered := make([]config.Server, 0, 1)
 		for _, s := range servers {
-			if s.Name == backupServer {
+			if s.Name == name {
 				filtered = append(filtered, s)
 			}
 		}
 		if len(filtered) == 0 {
-			return fmt.Errorf("server %q not found in config", backupServer)
+			return nil, fmt.Errorf("server %q not found in config", name)
 		}
 		servers = filtered
 	}
 
 	if len(servers) == 0 {
-		return fmt.Errorf("no servers configured")
-	}
-
-    successes := 0
-    failures := 0
-
-    type result struct {
-        success bool
-        err     error
-    }
-
-    runOne := func(srv config.Server) result {
-        serverLogger := logger.WithPrefix(fmt.Sprintf("[bold][cyan]%s[/cyan][/bold]", srv.Name))
-        serverLogger.Info("[yellow]starting backup[/yellow]")
-
-        connCfg, err := toConnectorConfig(srv, cfg.Defaults)
-        if err != nil {
-            serverLogger.Error(fmt.Sprintf("[red]config error:[/red] %v", err))
-            return result{err: err}
-        }
-
-        conn, err := newConnector(connCfg)
-        if err != nil {
-            serverLogger.Error(fmt.Sprintf("[red]init error:[/red] %v", err))
-            return result{err: err}
-        }
-
-        mgr := backup.Manager{
-            BackupLocation: srv.GetBackupLocation(cfg.Defaults),
-            TempDir:        cfg.Defaults.TempDir,
-            Progress:       progress.New(serverLogger, GetOutputFormat()),
-        }
-
-        start := time.Now()
-        archivePath, stats, err := mgr.Backup(ctx, conn)
-        if err != nil {
-            serverLogger.Error(fmt.Sprintf("[red]backup failed:[/red] %v", err))
-            return result{err: err}
-        }
-
-        serverLogger.Info(fmt.Sprintf("[green]saved[/green] %s (%d files, %.1f MB, %.1fs)",
-            archivePath, stats.Files, float64(stats.Bytes)/1e6, time.Since(start).Seconds()),
-            log.Meta{
-                "archive_path": archivePath,
-                "files":        stats.Files,
-                "bytes":        stats.Bytes,
-                "duration_sec": time.Since(start).Seconds(),
-            })
-
-        return result{success: true}
-    }
-
-    if backupSequential || len(servers) == 1 {
-        for _, srv := range servers {
-            res := runOne(srv)
-            if res.success {
-                successes++
-            } else {
-                failures++
-            }
-        }
-    } else {
-        var wg sync.WaitGroup
-        results := make(chan result, len(servers))
-
-        for _, srv := range servers {
-            srv := srv
-            wg.Add(1)
-            go func() {
-                defer wg.Done()
-                results <- runOne(srv)
-            }()
-        }
-
-        wg.Wait()
-        close(results)
-        for res := range results {
-            if res.success {
-                successes++
-            } else {
-                failures++
-            }
-        }
-    }
-
-    if failures > 0 {
-        return fmt.Errorf("backup complete with failures: %d success, %d failed", successes, failures)
-    }
-
-    logger.Info(fmt.Sprintf("[bold][green]backup complete[/green][/bold] (%d success)", successes))
-
-    return nil
+		return nil, fmt.Errorf("no servers configured")
+	}
+	return servers, nil
+}
+
+// withServer returns logger prefixed with a server's name
+func withServer(logger *log.Logger, name string) *log.Logger {
+	return logger.WithPrefix(fmt.Sprintf("[bold][cyan]%s[/cyan][/bold]", name))
 }
 
 func toConnectorConfig(s config.Server, defaults config.Defaults) (connector.Config, error) {
 	conn := s.Connection
 
-	// Populate default include/exclude
-	include := conn.GetInclude()
-	exclude := conn.Exclude
+	reg, ok := connector.Lookup(conn.Type)
+	if !ok {
+		return connector.Config{}, fmt.Errorf("unsupported connector type: %s", conn.Type)
+	}
+
+	opts := reg.NewOptions()
+	if err := conn.DecodeOptions(opts); err != nil {
+		return connector.Config{}, fmt.Errorf("invalid %s connection: %w", conn.Type, err)
+	}
 
 	// Default API key for nitrado
-	apiKey := conn.APIKey
-	if apiKey == "" {
-		apiKey = defaults.NitradoAPIKey
+	if nitrado, ok := opts.(*connector.NitradoOptions); ok && nitrado.APIKey == "" {
+		nitrado.APIKey = defaults.NitradoAPIKey
 	}
 
 	cfg := connector.Config{
 		Type:          conn.Type,
-		Host:          conn.Host,
-		Port:          conn.Port,
-		Username:      conn.Username,
-		Password:      conn.Password,
-		KeyFile:       conn.KeyFile,
-		Passive:       conn.IsPassive(),
-		TLS:           conn.TLS,
-		APIKey:        apiKey,
-		ServiceID:     conn.ServiceID,
 		RemotePath:    conn.RemotePath,
-		Include:       include,
-		Exclude:       exclude,
+		Include:       conn.GetInclude(),
+		Exclude:       conn.Exclude,
+		Options:       opts,
 		RetryAttempts: defaults.RetryAttempts,
 		RetryDelay:    defaults.RetryDelay,
 		RetryBackoff:  defaults.RetryBackoff,
@@ -201,5 +464,118 @@ func toConnectorConfig(s config.Server, defaults config.Defaults) (connector.Con
 		return cfg, fmt.Errorf("connection.remote_path is required")
 	}
 
+	if err := opts.Validate(); err != nil {
+		return cfg, err
+	}
+
 	return cfg, nil
 }
+
+// withRCON runs fn wrapped in the server's RCON pre/post commands, if configured.
+func withRCON(ctx context.Context, srv config.Server, logger *log.Logger, fn func() error) error {
+	if srv.RCON == nil {
+		return fn()
+	}
+
+	rconCfg, err := toRCONConfig(srv)
+	if err != nil {
+		return err
+	}
+	rconCfg.OnResponse = func(command, response string) {
+		logger.Debug(fmt.Sprintf("rcon %s: %s", command, response))
+	}
+
+	logger.Debug(fmt.Sprintf("rcon %s (%s)", rconCfg.Address, rconCfg.Protocol))
+	return rcon.Around(ctx, rconCfg, fn)
+}
+
+func toRCONConfig(s config.Server) (rcon.Config, error) {
+	rc := s.RCON
+
+	protocol, err := rcon.ParseProtocol(rc.Protocol)
+	if err != nil {
+		return rcon.Config{}, err
+	}
+
+	host := rc.Host
+	if host == "" {
+		// Fall back to the connection host for connectors that have one
+		var conn struct {
+			Host string `yaml:"host"`
+		}
+		if err := s.Connection.Decode(&conn); err != nil {
+			return rcon.Config{}, fmt.Errorf("connection: %w", err)
+		}
+		host = conn.Host
+	}
+	if host == "" {
+		return rcon.Config{}, fmt.Errorf("rcon.host is required")
+	}
+
+	port := rc.Port
+	if port == 0 {
+		port = protocol.DefaultPort()
+	}
+
+	return rcon.Config{
+		Address:      net.JoinHostPort(host, strconv.Itoa(port)),
+		Password:     rc.Password,
+		Protocol:     protocol,
+		PreCommands:  rc.PreCommands,
+		PostCommands: rc.PostCommands,
+		WaitAfter:    time.Duration(rc.WaitAfter) * time.Second,
+	}, nil
+}
+
+// toSchedule parses a bandwidth limit plus the windows that override it;
+// windowLimit selects which of a window's limits applies
+func toSchedule(limit string, windows []config.BandwidthWindow, windowLimit func(config.BandwidthWindow) string) (throttle.Schedule, error) {
+	rate, err := bytesize.ParseRate(limit)
+	if err != nil {
+		return throttle.Schedule{}, err
+	}
+	s := throttle.Schedule{Rate: rate}
+
+	for _, w := range windows {
+		start, end, err := throttle.ParseHours(w.Hours)
+		if err != nil {
+			return throttle.Schedule{}, err
+		}
+		value := windowLimit(w)
+		if value == "" {
+			continue
+		}
+		rate, err := bytesize.ParseRate(value)
+		if err != nil {
+			return throttle.Schedule{}, err
+		}
+		s.Windows = append(s.Windows, throttle.Window{Start: start, End: end, Rate: rate})
+	}
+	return s, nil
+}
+
+// toGuards parses the size and action of a server's sanity guards
+func toGuards(g config.Guards) (backup.Guards, error) {
+	minBytes, err := bytesize.Parse(g.MinBytes)
+	if err != nil {
+		return backup.Guards{}, fmt.Errorf("min_bytes: %w", err)
+	}
+	policy, err := backup.ParseGuardPolicy(g.Action)
+	if err != nil {
+		return backup.Guards{}, fmt.Errorf("guard_action: %w", err)
+	}
+	if g.MaxShrinkPercent < 0 || g.MaxShrinkPercent > 100 {
+		return backup.Guards{}, fmt.Errorf("max_shrink_percent must be between 0 and 100")
+	}
+	return backup.Guards{
+		MinFiles:         g.MinFiles,
+		MinBytes:         minBytes,
+		MaxShrinkPercent: g.MaxShrinkPercent,
+		Policy:           policy,
+	}, nil
+}
+
+// seconds converts a config value in seconds
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
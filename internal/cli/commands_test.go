@@ -4,16 +4,29 @@ package cli
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/devtheops/gsbt/internal/backup"
 	"github.com/devtheops/gsbt/internal/config"
 	"github.com/devtheops/gsbt/internal/connector"
+	"github.com/devtheops/gsbt/internal/healthcheck"
+	"github.com/devtheops/gsbt/internal/history"
+	"github.com/devtheops/gsbt/internal/lock"
+	"github.com/devtheops/gsbt/internal/log"
+	"github.com/devtheops/gsbt/internal/metrics"
+	"gopkg.in/yaml.v3"
 )
 
 // TestBackupCommandMetadata tests backup command structure
@@ -131,6 +144,121 @@ func TestToConnectorConfigRequiresRemotePath(t *testing.T) {
 	}
 }
 
+func TestToConnectorConfigDecodesOptions(t *testing.T) {
+	var server config.Server
+	serverYAML := `
+name: ark
+connection:
+  type: nitrado
+  service_id: "18341077"
+  remote_path: /games/ark
+`
+	if err := yaml.Unmarshal([]byte(serverYAML), &server); err != nil {
+		t.Fatalf("parse server: %v", err)
+	}
+
+	cfg, err := toConnectorConfig(server, config.Defaults{NitradoAPIKey: "default-key"})
+	if err != nil {
+		t.Fatalf("toConnectorConfig error: %v", err)
+	}
+
+	opts, ok := cfg.Options.(*connector.NitradoOptions)
+	if !ok {
+		t.Fatalf("options type = %T, want *connector.NitradoOptions", cfg.Options)
+	}
+	if opts.ServiceID != "18341077" {
+		t.Errorf("service_id = %q, want 18341077", opts.ServiceID)
+	}
+	if opts.APIKey != "default-key" {
+		t.Errorf("api_key = %q, want default from defaults.nitrado_api_key", opts.APIKey)
+	}
+
+	// Validation errors from the connector options are surfaced
+	if _, err := toConnectorConfig(server, config.Defaults{}); err == nil {
+		t.Fatal("expected error for missing api_key")
+	}
+
+	server.Connection.Type = "gopher"
+	if _, err := toConnectorConfig(server, config.Defaults{}); err == nil {
+		t.Fatal("expected error for unknown connector type")
+	}
+}
+
+func TestToRCONConfigDefaults(t *testing.T) {
+	var conn config.Connection
+	if err := yaml.Unmarshal([]byte("type: ftp\nhost: game.example.com\n"), &conn); err != nil {
+		t.Fatalf("parse connection: %v", err)
+	}
+
+	server := config.Server{
+		Connection: conn,
+		RCON: &config.RCON{
+			Password:     "secret",
+			Protocol:     "minecraft",
+			PreCommands:  []string{"save-off", "save-all flush"},
+			PostCommands: []string{"save-on"},
+			WaitAfter:    5,
+		},
+	}
+
+	cfg, err := toRCONConfig(server)
+	if err != nil {
+		t.Fatalf("toRCONConfig error: %v", err)
+	}
+
+	if cfg.Address != "game.example.com:25575" {
+		t.Errorf("address = %s, want game.example.com:25575", cfg.Address)
+	}
+	if cfg.WaitAfter != 5*time.Second {
+		t.Errorf("wait_after = %v, want 5s", cfg.WaitAfter)
+	}
+
+	server.RCON.Protocol = "telnet"
+	if _, err := toRCONConfig(server); err == nil {
+		t.Fatal("expected error for unsupported protocol")
+	}
+
+	server.RCON.Protocol = "minecraft"
+	if err := yaml.Unmarshal([]byte("type: ftp\nhost: [a, b]\n"), &server.Connection); err != nil {
+		t.Fatalf("parse connection: %v", err)
+	}
+	if _, err := toRCONConfig(server); err == nil || !strings.Contains(err.Error(), "connection:") {
+		t.Errorf("err = %v, want the connection block's decode error", err)
+	}
+}
+
+func TestToSchedule(t *testing.T) {
+	windows := []config.BandwidthWindow{
+		{Hours: "18:00-23:00", Limit: "1MiB/s", GlobalLimit: "4MiB/s"},
+		{Hours: "01:00-07:00", Limit: "unlimited"},
+	}
+
+	s, err := toSchedule("10MiB/s", windows, func(w config.BandwidthWindow) string { return w.Limit })
+	if err != nil {
+		t.Fatalf("toSchedule error: %v", err)
+	}
+	if s.Rate != 10<<20 || len(s.Windows) != 2 || s.Windows[0].Rate != 1<<20 || s.Windows[1].Rate != 0 {
+		t.Errorf("server schedule = %+v", s)
+	}
+
+	// Windows without a global limit keep the regular global cap
+	s, err = toSchedule("", windows, func(w config.BandwidthWindow) string { return w.GlobalLimit })
+	if err != nil {
+		t.Fatalf("toSchedule error: %v", err)
+	}
+	if s.Rate != 0 || len(s.Windows) != 1 || s.Windows[0].Start != 18*time.Hour || s.Windows[0].Rate != 4<<20 {
+		t.Errorf("global schedule = %+v", s)
+	}
+
+	if _, err := toSchedule("fast", nil, nil); err == nil {
+		t.Error("expected error for invalid limit")
+	}
+	bad := []config.BandwidthWindow{{Hours: "evenings", Limit: "1MiB/s"}}
+	if _, err := toSchedule("", bad, func(w config.BandwidthWindow) string { return w.Limit }); err == nil {
+		t.Error("expected error for invalid hours")
+	}
+}
+
 func TestRunBackupSuccess(t *testing.T) {
 	resetRootCmd()
 	resetFlags()
@@ -268,7 +396,7 @@ type mockSuccessConnector struct{}
 
 func (m *mockSuccessConnector) Connect(ctx context.Context) error { return nil }
 func (m *mockSuccessConnector) List(ctx context.Context) ([]connector.FileInfo, error) {
-	return []connector.FileInfo{{Path: "file.txt"}}, nil
+	return []connector.FileInfo{{Path: "file.txt", Size: 4}}, nil
 }
 func (m *mockSuccessConnector) Download(ctx context.Context, remotePath string, w io.Writer) error {
 	_, _ = w.Write([]byte("data"))
@@ -285,7 +413,7 @@ type sleepConnector struct{ sleep time.Duration }
 
 func (s *sleepConnector) Connect(ctx context.Context) error { return nil }
 func (s *sleepConnector) List(ctx context.Context) ([]connector.FileInfo, error) {
-	return []connector.FileInfo{{Path: "file.txt"}}, nil
+	return []connector.FileInfo{{Path: "file.txt", Size: 4}}, nil
 }
 func (s *sleepConnector) Download(ctx context.Context, remotePath string, w io.Writer) error {
 	select {
@@ -300,6 +428,489 @@ func (s *sleepConnector) Upload(ctx context.Context, r io.Reader, remotePath str
 func (s *sleepConnector) Close() error { return nil }
 func (s *sleepConnector) Name() string { return "sleep" }
 
+// TestRunBackupInterrupted tests cancellation stops the run and reports
+// which servers completed
+func TestRunBackupInterrupted(t *testing.T) {
+	resetRootCmd()
+	resetFlags()
+	rootCmd.AddCommand(backupCmd)
+	backupSequential = true
+	defer func() { backupSequential = false }()
+
+	cfgYAML := `
+defaults:
+  backup_location: %s
+servers:
+  - name: fast
+    connection: { type: ftp, host: example.com, remote_path: /fast }
+  - name: slow
+    connection: { type: ftp, host: example.com, remote_path: /slow }
+  - name: never
+    connection: { type: ftp, host: example.com, remote_path: /never }
+`
+	tmp := t.TempDir()
+	cfgPath := filepath.Join(tmp, "config.yml")
+	os.WriteFile(cfgPath, []byte(fmt.Sprintf(cfgYAML, filepath.Join(tmp, "backups"))), 0o644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	origNewConnector := newConnector
+	newConnector = func(cfg connector.Config) (connector.Connector, error) {
+		if cfg.RemotePath == "/fast" {
+			return &mockSuccessConnector{}, nil
+		}
+		cancel()
+		return &sleepConnector{sleep: time.Minute}, nil
+	}
+	defer func() { newConnector = origNewConnector }()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"backup", "--config", cfgPath})
+
+	// cobra keeps the context of an earlier Execute on the subcommand
+	backupCmd.SetContext(ctx)
+	defer backupCmd.SetContext(context.Background())
+	err := rootCmd.ExecuteContext(ctx)
+	if !errors.Is(err, ErrInterrupted) || exitCode(err) != ExitInterrupted {
+		t.Fatalf("err = %v, want ErrInterrupted", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "1 of 3 servers completed, not completed: slow, never") {
+		t.Errorf("missing summary in output:\n%s", out)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(tmp, "backups", "slow", "*.tar.gz*")); len(leftovers) != 0 {
+		t.Errorf("archives left behind: %v", leftovers)
+	}
+}
+
+// TestRunLocked tests backup, prune and restore refuse a server another
+// run holds
+func TestRunLocked(t *testing.T) {
+	resetRootCmd()
+	resetFlags()
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(pruneCmd)
+	rootCmd.AddCommand(restoreCmd)
+	defer func() { pruneNoWait, restoreNoWait, restoreServer = false, false, "" }()
+
+	tmp := t.TempDir()
+	cfgPath := filepath.Join(tmp, "config.yml")
+	os.WriteFile(cfgPath, []byte(fmt.Sprintf(`
+defaults:
+  backup_location: %s
+servers:
+  - name: test
+    connection: { type: ftp, host: example.com, remote_path: /data }
+`, filepath.Join(tmp, "backups"))), 0o644)
+
+	origNewConnector := newConnector
+	newConnector = func(cfg connector.Config) (connector.Connector, error) {
+		t.Error("connected to a locked server")
+		return &mockSuccessConnector{}, nil
+	}
+	defer func() { newConnector = origNewConnector }()
+
+	held, err := lock.AcquireDir(context.Background(), filepath.Join(tmp, "backups", "test"), "backup", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer held.Release()
+
+	for _, args := range [][]string{{"backup"}, {"prune", "--no-wait"}, {"restore", "backup.tar.gz", "--server", "test", "--no-wait"}} {
+		buf := new(bytes.Buffer)
+		rootCmd.SetOut(buf)
+		rootCmd.SetErr(buf)
+		rootCmd.SetArgs(append(args, "--config", cfgPath))
+		if err := rootCmd.Execute(); err == nil {
+			t.Errorf("%s: expected error for locked server", args[0])
+		}
+		if want := fmt.Sprintf("locked by pid %d", os.Getpid()); !strings.Contains(buf.String(), want) {
+			t.Errorf("%s: output missing %q:\n%s", args[0], want, buf.String())
+		}
+	}
+}
+
+// failListConnector fails to list the remote files
+type failListConnector struct{ mockSuccessConnector }
+
+func (f *failListConnector) List(ctx context.Context) ([]connector.FileInfo, error) {
+	return nil, errors.New("listing refused")
+}
+
+// TestRunBackupHooks tests hooks run around backups with the GSBT_* environment
+func TestRunBackupHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks use sh")
+	}
+	resetRootCmd()
+	resetFlags()
+	rootCmd.AddCommand(backupCmd)
+	backupSequential = true
+	defer func() { backupSequential = false }()
+
+	tmp := t.TempDir()
+	events := filepath.Join(tmp, "events")
+	cfgPath := filepath.Join(tmp, "config.yml")
+	os.WriteFile(cfgPath, []byte(fmt.Sprintf(`
+defaults:
+  backup_location: %s
+  hooks:
+    pre_backup: echo "pre $GSBT_SERVER" >> %[2]s
+    post_backup:
+      - echo "post $GSBT_SERVER $GSBT_STATUS $GSBT_FILES $GSBT_BYTES $(basename $GSBT_ARCHIVE_PATH)" >> %[2]s
+      - echo copied
+    on_failure: echo "failure $GSBT_SERVER $GSBT_STATUS $GSBT_ERROR" >> %[2]s
+servers:
+  - name: good
+    connection: { type: ftp, host: example.com, remote_path: /good }
+  - name: postfail
+    hooks:
+      post_backup: "exit 3"
+    connection: { type: ftp, host: example.com, remote_path: /good }
+  - name: broken
+    connection: { type: ftp, host: example.com, remote_path: /broken }
+  - name: blocked
+    hooks:
+      pre_backup: "exit 1"
+    connection: { type: ftp, host: example.com, remote_path: /blocked }
+`, filepath.Join(tmp, "backups"), events)), 0o644)
+
+	origNewConnector := newConnector
+	newConnector = func(cfg connector.Config) (connector.Connector, error) {
+		switch cfg.RemotePath {
+		case "/broken":
+			return &failListConnector{}, nil
+		case "/blocked":
+			return &sleepConnector{sleep: time.Minute}, nil
+		}
+		return &mockSuccessConnector{}, nil
+	}
+	defer func() { newConnector = origNewConnector }()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"backup", "--config", cfgPath})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatal("expected failures")
+	}
+
+	data, _ := os.ReadFile(events)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	archives, _ := filepath.Glob(filepath.Join(tmp, "backups", "good", "*.tar.gz"))
+	if len(archives) != 1 {
+		t.Fatalf("archives = %v, want one for good", archives)
+	}
+	want := []string{
+		"pre good",
+		"post good success 1 4 " + filepath.Base(archives[0]),
+		"pre postfail",
+		"pre broken",
+		"failure broken failed list: listing refused",
+		`failure blocked failed pre_backup hook "exit 1": exit status 1`,
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("hook events:\n%s\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+	if !strings.Contains(buf.String(), "post_backup: copied") {
+		t.Errorf("hook output not logged:\n%s", buf.String())
+	}
+	// A failing post_backup hook is only a warning: the archive stays and
+	// on_failure doesn't run
+	if !strings.Contains(buf.String(), `hook failed: post_backup hook "exit 3"`) {
+		t.Errorf("post hook failure not logged:\n%s", buf.String())
+	}
+	if archives, _ := filepath.Glob(filepath.Join(tmp, "backups", "postfail", "*.tar.gz")); len(archives) != 1 {
+		t.Errorf("postfail archives = %v, want one", archives)
+	}
+}
+
+// TestRunBackupNotifications tests one summary is posted for the whole run
+func TestRunBackupNotifications(t *testing.T) {
+	resetRootCmd()
+	resetFlags()
+	rootCmd.AddCommand(backupCmd)
+
+	var bodies []map[string]any
+	var mu sync.Mutex
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		bodies = append(bodies, body)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	tmp := t.TempDir()
+	cfgPath := filepath.Join(tmp, "config.yml")
+	os.WriteFile(cfgPath, []byte(fmt.Sprintf(`
+defaults:
+  backup_location: %s
+notifications:
+  - type: webhook
+    url: %s
+    events: [success, failure]
+    template: "{{.Server}} {{.Type}} {{.Stats.Files}}"
+servers:
+  - name: good
+    connection: { type: ftp, host: example.com, remote_path: /good }
+  - name: broken
+    connection: { type: ftp, host: example.com, remote_path: /broken }
+`, filepath.Join(tmp, "backups"), receiver.URL)), 0o644)
+
+	origNewConnector := newConnector
+	newConnector = func(cfg connector.Config) (connector.Connector, error) {
+		if cfg.RemotePath == "/broken" {
+			return &failListConnector{}, nil
+		}
+		return &mockSuccessConnector{}, nil
+	}
+	defer func() { newConnector = origNewConnector }()
+
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"backup", "--config", cfgPath})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatal("expected failure for broken server")
+	}
+
+	if len(bodies) != 1 {
+		t.Fatalf("got %d notifications, want one summary", len(bodies))
+	}
+	if bodies[0]["title"] != "gsbt backup: 1 failed, 1 succeeded" || bodies[0]["text"] != "good success 1\nbroken failure 0" {
+		t.Errorf("notification = %v", bodies[0])
+	}
+}
+
+func TestRunBackupHealthcheck(t *testing.T) {
+	resetRootCmd()
+	resetFlags()
+	rootCmd.AddCommand(backupCmd)
+
+	var pings []string
+	bodies := map[string]string{}
+	var mu sync.Mutex
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		pings = append(pings, r.URL.Path)
+		bodies[r.URL.Path] = string(body)
+		mu.Unlock()
+		// a monitoring outage must not fail the backup
+		if strings.HasPrefix(r.URL.Path, "/good") {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	tmp := t.TempDir()
+	cfgPath := filepath.Join(tmp, "config.yml")
+	os.WriteFile(cfgPath, []byte(fmt.Sprintf(`
+defaults:
+  backup_location: %[1]s
+  healthcheck_url: %[2]s/global
+servers:
+  - name: good
+    healthcheck_url: %[2]s/good
+    connection: { type: ftp, host: example.com, remote_path: /good }
+  - name: broken
+    healthcheck_url: %[2]s/broken
+    connection: { type: ftp, host: example.com, remote_path: /broken }
+`, filepath.Join(tmp, "backups"), receiver.URL)), 0o644)
+
+	origNewConnector, origNewHealthcheck := newConnector, newHealthcheck
+	newConnector = func(cfg connector.Config) (connector.Connector, error) {
+		if cfg.RemotePath == "/broken" {
+			return &failListConnector{}, nil
+		}
+		return &mockSuccessConnector{}, nil
+	}
+	newHealthcheck = func(url string) *healthcheck.Check {
+		c := healthcheck.New(url)
+		c.RetryDelay = time.Millisecond
+		return c
+	}
+	defer func() { newConnector, newHealthcheck = origNewConnector, origNewHealthcheck }()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"backup", "--config", cfgPath, "--sequential"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatal("expected failure for broken server")
+	}
+
+	want := []string{
+		"/global/start",
+		"/good/start", "/good/start", "/good/start",
+		"/good", "/good", "/good",
+		"/broken/start", "/broken/fail",
+		"/global/fail",
+	}
+	if strings.Join(pings, " ") != strings.Join(want, " ") {
+		t.Errorf("pings = %v, want %v", pings, want)
+	}
+	if !strings.Contains(bodies["/broken/fail"], "backup failed: list: listing refused") ||
+		strings.Contains(bodies["/broken/fail"], "good") {
+		t.Errorf("broken's failure ping = %q, want its own log", bodies["/broken/fail"])
+	}
+	if !strings.Contains(bodies["/global/fail"], "good saved") || !strings.Contains(bodies["/global/fail"], "broken backup failed") {
+		t.Errorf("global failure ping = %q, want the whole run's log", bodies["/global/fail"])
+	}
+	if !strings.Contains(buf.String(), "good saved") || !strings.Contains(buf.String(), "healthcheck failed: ping") {
+		t.Errorf("output = %s, want a saved archive and a healthcheck warning", buf.String())
+	}
+}
+
+func TestRunBackupMetricsFile(t *testing.T) {
+	resetRootCmd()
+	resetFlags()
+	rootCmd.AddCommand(backupCmd)
+
+	tmp := t.TempDir()
+	cfgPath := filepath.Join(tmp, "config.yml")
+	textfile := filepath.Join(tmp, "gsbt.prom")
+	os.WriteFile(cfgPath, []byte(fmt.Sprintf(`
+defaults:
+  backup_location: %s
+metrics:
+  textfile: %s
+servers:
+  - name: good
+    connection: { type: ftp, host: example.com, remote_path: /good }
+  - name: broken
+    connection: { type: ftp, host: example.com, remote_path: /broken }
+`, filepath.Join(tmp, "backups"), textfile)), 0o644)
+
+	origNewConnector := newConnector
+	newConnector = func(cfg connector.Config) (connector.Connector, error) {
+		if cfg.RemotePath == "/broken" {
+			return &failListConnector{}, nil
+		}
+		return &mockSuccessConnector{}, nil
+	}
+	defer func() { newConnector = origNewConnector }()
+
+	// a second run only backs up broken: good's metrics are kept
+	defer func() { backupServer = "" }()
+	for _, args := range [][]string{{}, {"--server", "broken"}} {
+		resetFlags()
+		rootCmd.SetOut(new(bytes.Buffer))
+		rootCmd.SetErr(new(bytes.Buffer))
+		rootCmd.SetArgs(append([]string{"backup", "--config", cfgPath}, args...))
+		if err := rootCmd.Execute(); err == nil {
+			t.Fatal("expected failure for broken server")
+		}
+	}
+
+	data, err := os.ReadFile(textfile)
+	if err != nil {
+		t.Fatalf("metrics textfile not written: %v", err)
+	}
+	for _, want := range []string{
+		`gsbt_backup_success{server="good"} 1`,
+		`gsbt_backup_files{server="good"} 1`,
+		`gsbt_backup_success{server="broken"} 0`,
+		`gsbt_backup_failures_total{server="broken"} 2`,
+		`gsbt_backup_failures_total{server="good"} 0`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("textfile missing %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestRecordMetricsLocked(t *testing.T) {
+	textfile := filepath.Join(t.TempDir(), "gsbt.prom")
+	logger := log.NewWithWriters(new(bytes.Buffer), new(bytes.Buffer))
+	// the daemon's registry only knows its own server
+	daemon := metrics.New()
+	recordMetrics(context.Background(), daemon, textfile, []metrics.Run{{Server: "ark", End: time.Now()}}, logger)
+
+	// a cron run holds the textfile while it writes another server
+	l, err := lock.Acquire(context.Background(), textfile+".lock", "test", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		recordMetrics(context.Background(), daemon, textfile, []metrics.Run{{Server: "ark", End: time.Now()}}, logger)
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("recordMetrics wrote the textfile while it was locked")
+	case <-time.After(50 * time.Millisecond):
+	}
+	cron := metrics.New()
+	cron.Load(textfile)
+	cron.Record(metrics.Run{Server: "valheim", End: time.Now()})
+	cron.WriteFile(textfile)
+	l.Release()
+	<-done
+
+	data, _ := os.ReadFile(textfile)
+	for _, server := range []string{"ark", "valheim"} {
+		if !strings.Contains(string(data), fmt.Sprintf(`gsbt_backup_success{server=%q} 1`, server)) {
+			t.Errorf("textfile lost %s:\n%s", server, data)
+		}
+	}
+}
+
+func TestRunBackupJSONProgress(t *testing.T) {
+	resetRootCmd()
+	resetFlags()
+	rootCmd.AddCommand(backupCmd)
+
+	tmp := t.TempDir()
+	cfgPath := filepath.Join(tmp, "config.yml")
+	os.WriteFile(cfgPath, []byte(fmt.Sprintf(`
+defaults:
+  backup_location: %s
+servers:
+  - name: alpha
+    connection: { type: ftp, host: example.com, remote_path: /alpha }
+  - name: beta
+    connection: { type: ftp, host: example.com, remote_path: /beta }
+`, filepath.Join(tmp, "backups"))), 0o644)
+
+	origNewConnector := newConnector
+	newConnector = func(cfg connector.Config) (connector.Connector, error) {
+		return &mockSuccessConnector{}, nil
+	}
+	defer func() { newConnector = origNewConnector }()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"backup", "--config", cfgPath, "--output", "json"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("backup failed: %v", err)
+	}
+
+	// parallel servers' events and log lines never break each other up
+	events := map[string][]string{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry struct {
+			Event  string `json:"event"`
+			Server string `json:"server"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		if entry.Event != "" {
+			events[entry.Server] = append(events[entry.Server], entry.Event)
+		}
+	}
+	for _, server := range []string{"alpha", "beta"} {
+		if got := strings.Join(events[server], " "); got != "start file_start file_progress file_done complete" {
+			t.Errorf("%s events = %q", server, got)
+		}
+	}
+}
+
 // TestBackupCommandHelp tests backup command help
 func TestBackupCommandHelp(t *testing.T) {
 	resetRootCmd()
@@ -371,26 +982,69 @@ func TestPruneCommandFlags(t *testing.T) {
 	}
 }
 
-// TestPruneCommandStub tests prune command stub output
-func TestPruneCommandStub(t *testing.T) {
+// TestRunPrune tests prune deletes old archives but keeps the last good ones
+func TestRunPrune(t *testing.T) {
 	resetRootCmd()
 	resetFlags()
 	rootCmd.AddCommand(pruneCmd)
 
+	cfgYAML := `
+defaults:
+  backup_location: %s
+  prune_age: 7
+  prune_keep_good: 1
+servers:
+  - name: test
+    connection:
+      type: ftp
+      host: example.com
+      remote_path: /data
+`
+	tmp := t.TempDir()
+	cfgPath := filepath.Join(tmp, "config.yml")
+	os.WriteFile(cfgPath, []byte(fmt.Sprintf(cfgYAML, filepath.Join(tmp, "backups"))), 0o644)
+
+	dir := filepath.Join(tmp, "backups", "test")
+	os.MkdirAll(dir, 0o755)
+	archive := func(age time.Duration, status string) string {
+		path := filepath.Join(dir, time.Now().Add(-age).UTC().Format("2006-01-02_150405")+".tar.gz")
+		os.WriteFile(path, nil, 0o644)
+		backup.WriteManifest(path, backup.Manifest{Status: status})
+		return path
+	}
+	day := 24 * time.Hour
+	oldest := archive(30*day, backup.StatusOK)
+	lastGood := archive(20*day, backup.StatusOK)
+	archive(10*day, backup.StatusSuspicious)
+	archive(day, backup.StatusSuspicious)
+
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
-	rootCmd.SetArgs([]string{"prune"})
-
-	err := rootCmd.Execute()
-	if err != nil {
-		t.Fatalf("prune command failed: %v", err)
+	rootCmd.SetArgs([]string{"prune", "--config", cfgPath, "--dry-run"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("prune --dry-run failed: %v", err)
+	}
+	if _, err := os.Stat(oldest); err != nil {
+		t.Fatalf("dry run deleted %s", oldest)
+	}
+	if !strings.Contains(buf.String(), "would delete 2 archives") {
+		t.Errorf("dry run output:\n%s", buf.String())
 	}
 
-	output := buf.String()
-	expected := "prune command - not yet implemented"
-	if !strings.Contains(output, expected) {
-		t.Errorf("prune output missing %q\nGot: %s", expected, output)
+	rootCmd.SetArgs([]string{"prune", "--config", cfgPath})
+	pruneDryRun = false
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	if _, err := os.Stat(oldest); !os.IsNotExist(err) {
+		t.Errorf("%s not deleted", oldest)
+	}
+	if _, err := os.Stat(lastGood); err != nil {
+		t.Errorf("last good archive deleted: %v", err)
+	}
+	if archives, _ := backup.ListArchives(dir); len(archives) != 2 {
+		t.Errorf("%d archives left, want 2", len(archives))
 	}
 }
 
@@ -455,26 +1109,53 @@ func TestListCommandFlags(t *testing.T) {
 	}
 }
 
-// TestListCommandStub tests list command stub output
-func TestListCommandStub(t *testing.T) {
+// TestListCommand tests the status list built from the run history
+func TestListCommand(t *testing.T) {
 	resetRootCmd()
 	resetFlags()
 	rootCmd.AddCommand(listCmd)
 
+	tmp := t.TempDir()
+	cfgPath := filepath.Join(tmp, "config.yml")
+	os.WriteFile(cfgPath, []byte(`
+servers:
+  - name: valheim
+    connection: { type: ftp, host: example.com, remote_path: /valheim }
+  - name: ark
+    connection: { type: ftp, host: example.com, remote_path: /ark }
+  - name: rust
+    connection: { type: ftp, host: example.com, remote_path: /rust }
+`), 0o644)
+
+	at := time.Date(2026, 3, 1, 4, 0, 0, 0, time.Local)
+	history.Append(filepath.Join(tmp, history.FileName),
+		history.Record{Command: "backup", Server: "ark", Start: at, End: at, Outcome: history.Success},
+		history.Record{Command: "backup", Server: "ark", Start: at.Add(time.Hour), End: at.Add(time.Hour), Outcome: history.Failed, Error: "list: listing refused"},
+		history.Record{Command: "backup", Server: "valheim", Start: at, End: at, Outcome: history.Success},
+	)
+
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
-	rootCmd.SetArgs([]string{"list"})
-
-	err := rootCmd.Execute()
-	if err != nil {
+	rootCmd.SetArgs([]string{"list", "--config", cfgPath})
+	if err := rootCmd.Execute(); err != nil {
 		t.Fatalf("list command failed: %v", err)
 	}
 
-	output := buf.String()
-	expected := "list command - not yet implemented"
-	if !strings.Contains(output, expected) {
-		t.Errorf("list output missing %q\nGot: %s", expected, output)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want a header and 3 servers:\n%s", len(lines), buf.String())
+	}
+	for i, want := range [][]string{
+		{"valheim", "2026-03-01 04:00", "success"},
+		{"ark", "2026-03-01 05:00", "failed", "2026-03-01 05:00: list: listing refused"},
+		{"rust", "never", "-"},
+	} {
+		for _, w := range want {
+			if !strings.Contains(lines[i+1], w) {
+				t.Errorf("line %q missing %q", lines[i+1], w)
+			}
+		}
 	}
 }
 
@@ -587,6 +1268,38 @@ func TestRestoreCommandStub(t *testing.T) {
 	}
 }
 
+// TestRunRestoreHooksWarn tests restore hooks are accepted and warned
+// about until restore runs them
+func TestRunRestoreHooksWarn(t *testing.T) {
+	resetRootCmd()
+	resetFlags()
+	rootCmd.AddCommand(restoreCmd)
+	defer func() { restoreServer = "" }()
+
+	tmp := t.TempDir()
+	cfgPath := filepath.Join(tmp, "config.yml")
+	os.WriteFile(cfgPath, []byte(fmt.Sprintf(`
+defaults:
+  backup_location: %s
+  hooks:
+    pre_restore: echo stopping
+servers:
+  - name: test
+    connection: { type: ftp, host: example.com, remote_path: /data }
+`, filepath.Join(tmp, "backups"))), 0o644)
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"restore", "backup.tar.gz", "--server", "test", "--config", cfgPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "hooks are not run yet") {
+		t.Errorf("output missing hook warning:\n%s", buf.String())
+	}
+}
+
 // TestRestoreCommandRequiresArg tests restore command requires exactly one argument
 func TestRestoreCommandRequiresArg(t *testing.T) {
 	tests := []struct {
@@ -682,8 +1395,10 @@ func TestAllCommandsRegistered(t *testing.T) {
 	rootCmd.AddCommand(pruneCmd)
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(restoreCmd)
+	rootCmd.AddCommand(daemonCmd)
+	rootCmd.AddCommand(historyCmd)
 
-	expectedCommands := []string{"version", "backup", "prune", "list", "restore"}
+	expectedCommands := []string{"version", "backup", "prune", "list", "restore", "daemon", "history"}
 
 	for _, cmdName := range expectedCommands {
 		found := false
@@ -710,6 +1425,8 @@ func TestRootHelpShowsAllCommands(t *testing.T) {
 	rootCmd.AddCommand(pruneCmd)
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(restoreCmd)
+	rootCmd.AddCommand(daemonCmd)
+	rootCmd.AddCommand(historyCmd)
 
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
@@ -722,7 +1439,7 @@ func TestRootHelpShowsAllCommands(t *testing.T) {
 	}
 
 	output := buf.String()
-	expectedCommands := []string{"version", "backup", "prune", "list", "restore"}
+	expectedCommands := []string{"version", "backup", "prune", "list", "restore", "daemon", "history"}
 
 	for _, cmdName := range expectedCommands {
 		if !strings.Contains(output, cmdName) {
@@ -0,0 +1,181 @@
+// internal/cli/daemon.go
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/devtheops/gsbt/internal/config"
+	"github.com/devtheops/gsbt/internal/log"
+	"github.com/devtheops/gsbt/internal/metrics"
+	"github.com/devtheops/gsbt/internal/notify"
+	"github.com/devtheops/gsbt/internal/scheduler"
+	"github.com/spf13/cobra"
+)
+
+// allow tests to drive the daemon
+var (
+	daemonClock   scheduler.Clock
+	reloadSignals = func() (<-chan os.Signal, func()) {
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, syscall.SIGHUP)
+		return ch, func() { signal.Stop(ch) }
+	}
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run scheduled backups",
+	Long: `Run backups on each server's schedule until stopped, pruning a server's
+old archives after each successful backup. Servers without a schedule are
+skipped. SIGHUP reloads the config file. With metrics.listen set, Prometheus
+metrics are served at /metrics.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDaemon(cmd.Context(), cmd)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+}
+
+func runDaemon(ctx context.Context, cmd *cobra.Command) error {
+	logger := log.NewWithWriters(cmd.OutOrStdout(), cmd.ErrOrStderr())
+	logger.SetOutputFormat(GetOutputFormat())
+	logger.SetQuiet(IsQuiet())
+	logger.SetVerbose(IsVerbose())
+
+	cfgPath, err := config.FindConfigFile(GetConfigFile())
+	if err != nil {
+		return err
+	}
+	reg := metrics.New()
+	cfg, jobs, err := daemonJobs(ctx, cfgPath, logger, reg)
+	if err != nil {
+		return err
+	}
+	// Counters carry on from the textfile of earlier runs
+	if path := cfg.Metrics.Textfile; path != "" {
+		if err := reg.Load(path); err != nil {
+			logger.Warn(fmt.Sprintf("[yellow]previous metrics not loaded:[/yellow] %v", err))
+		}
+	}
+	listen := cfg.Metrics.Listen
+	if listen != "" {
+		if err := serveMetrics(ctx, listen, reg, logger); err != nil {
+			return err
+		}
+	}
+
+	sched := scheduler.New(daemonClock)
+	sched.OnNext = func(job string, at time.Time) {
+		withServer(logger, job).Info(fmt.Sprintf("next backup at %s", at.Format(time.RFC3339)),
+			log.Meta{"next_run": at.Format(time.RFC3339)})
+	}
+	sched.OnSkip = func(job string) {
+		withServer(logger, job).Warn("[yellow]skipping scheduled backup,[/yellow] the previous one is still running")
+	}
+
+	reload, stop := reloadSignals()
+	defer stop()
+
+	logger.Info(fmt.Sprintf("[green]daemon started[/green] (%d scheduled servers)", len(jobs)),
+		log.Meta{"config": cfgPath})
+	for {
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			sched.Run(runCtx, jobs)
+			close(done)
+		}()
+
+		select {
+		case <-ctx.Done():
+			cancel()
+			<-done
+			logger.Info("stopping, waiting for running backups")
+			sched.Wait()
+			logger.Info("daemon stopped")
+			return nil
+
+		case <-reload:
+			cancel()
+			<-done
+			// Backups already running finish with the config they started with
+			newCfg, newJobs, err := daemonJobs(ctx, cfgPath, logger, reg)
+			if err != nil {
+				logger.Error(fmt.Sprintf("[red]reload failed:[/red] %v, keeping the previous config", err))
+				continue
+			}
+			if newCfg.Metrics.Listen != listen {
+				logger.Warn("[yellow]metrics.listen changed,[/yellow] restart the daemon to apply it")
+			}
+			jobs = newJobs
+			logger.Info(fmt.Sprintf("[green]config reloaded[/green] (%d scheduled servers)", len(jobs)))
+		}
+	}
+}
+
+// daemonJobs loads the config and returns it with a job for each server
+// with a schedule; jobs record their backups in reg
+func daemonJobs(ctx context.Context, cfgPath string, logger *log.Logger, reg *metrics.Registry) (*config.Config, []scheduler.Job, error) {
+	cfg, err := config.LoadConfig(cfgPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	globalBucket, err := newGlobalBucket(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	targets, err := toNotifiers(cfg.Notifications)
+	if err != nil {
+		return nil, nil, err
+	}
+	historyFile := historyPath(cfgPath, cfg)
+
+	var jobs []scheduler.Job
+	for _, srv := range cfg.Servers {
+		s := srv.GetSchedule(cfg.Defaults)
+		if s.Cron == "" {
+			withServer(logger, srv.Name).Debug("no schedule, skipped")
+			continue
+		}
+		schedule, err := scheduler.Parse(s.Cron, s.Timezone)
+		if err != nil {
+			return nil, nil, fmt.Errorf("server %s: %w", srv.Name, err)
+		}
+
+		jobs = append(jobs, scheduler.Job{
+			Name:     srv.Name,
+			Schedule: schedule,
+			Jitter:   seconds(s.Jitter),
+			Run: func() {
+				// A server locked by a manual run is skipped until next time
+				start := time.Now()
+				archivePath, stats, err := runServerBackup(ctx, cfg, srv, logger, globalBucket, nil, false)
+				recordHistory(historyFile, backupRecord(ctx, srv.Name, start, archivePath, stats, err), logger)
+				if err == nil || ctx.Err() == nil {
+					recordMetrics(ctx, reg, cfg.Metrics.Textfile, []metrics.Run{backupRun(srv.Name, start, stats, err)}, logger)
+				}
+				summary := notify.Summary{Command: "backup", Events: backupEvents(srv.Name, archivePath, stats, err)}
+				// Pruning after failed backups could age out every archive
+				if err == nil && ctx.Err() == nil {
+					start := time.Now()
+					res, err := pruneServerArchives(ctx, cfg, srv, logger, false, true)
+					recordHistory(historyFile, pruneRecord(ctx, srv.Name, start, res, err), logger)
+					summary.Events = append(summary.Events, pruneEvents(srv.Name, res, err)...)
+				}
+				sendNotifications(ctx, targets, summary, logger)
+			},
+		})
+	}
+
+	if len(jobs) == 0 {
+		return nil, nil, fmt.Errorf("no servers have a schedule")
+	}
+	return cfg, jobs, nil
+}
@@ -0,0 +1,217 @@
+// internal/cli/daemon_test.go
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devtheops/gsbt/internal/connector"
+)
+
+// tickClock fires the scheduler's pending timer when the test calls tick
+type tickClock struct {
+	mu   sync.Mutex
+	now  time.Time
+	wait time.Duration
+	ch   chan time.Time
+}
+
+func (c *tickClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *tickClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.wait, c.ch = d, make(chan time.Time, 1)
+	return c.ch
+}
+
+func (c *tickClock) tick(t *testing.T) {
+	t.Helper()
+	waitFor(t, "scheduler waiting", func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.ch != nil
+	})
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(c.wait)
+	c.ch <- c.now
+	c.ch = nil
+}
+
+// syncBuffer is a bytes.Buffer safe for the daemon's concurrent logging
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRunDaemon(t *testing.T) {
+	resetRootCmd()
+	resetFlags()
+	rootCmd.AddCommand(daemonCmd)
+
+	cfgYAML := `
+defaults:
+  backup_location: %s
+  schedule_timezone: UTC
+metrics:
+  listen: 127.0.0.1:0
+  textfile: %s
+servers:
+  - name: alpha
+    schedule: "@hourly"
+    connection: { type: ftp, host: example.com, remote_path: /alpha }
+  - name: beta
+    schedule: "%s"
+    connection: { type: ftp, host: example.com, remote_path: /beta }
+`
+	tmp := t.TempDir()
+	backups := filepath.Join(tmp, "backups")
+	cfgPath := filepath.Join(tmp, "config.yml")
+	textfile := filepath.Join(tmp, "gsbt.prom")
+	writeConfig := func(betaSchedule string) {
+		os.WriteFile(cfgPath, []byte(fmt.Sprintf(cfgYAML, backups, textfile, betaSchedule)), 0o644)
+	}
+	writeConfig("")
+
+	clock := &tickClock{now: time.Date(2026, 3, 1, 10, 30, 0, 0, time.UTC)}
+	reload := make(chan os.Signal, 1)
+	origClock, origSignals, origNewConnector := daemonClock, reloadSignals, newConnector
+	daemonClock = clock
+	reloadSignals = func() (<-chan os.Signal, func()) { return reload, func() {} }
+	newConnector = func(cfg connector.Config) (connector.Connector, error) {
+		return &mockSuccessConnector{}, nil
+	}
+	defer func() { daemonClock, reloadSignals, newConnector = origClock, origSignals, origNewConnector }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := &syncBuffer{}
+	rootCmd.SetOut(out)
+	rootCmd.SetErr(out)
+	rootCmd.SetArgs([]string{"daemon", "--config", cfgPath})
+	daemonCmd.SetContext(ctx)
+	defer daemonCmd.SetContext(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- rootCmd.ExecuteContext(ctx) }()
+
+	archives := func(server string) int {
+		matches, _ := filepath.Glob(filepath.Join(backups, server, "*.tar.gz"))
+		return len(matches)
+	}
+
+	clock.tick(t)
+	waitFor(t, "alpha backup", func() bool { return archives("alpha") == 1 })
+	if archives("beta") != 0 {
+		t.Error("beta has no schedule but was backed up")
+	}
+
+	const alphaFiles = `gsbt_backup_files{server="alpha"} 1`
+	waitFor(t, "metrics textfile", func() bool {
+		data, _ := os.ReadFile(textfile)
+		return strings.Contains(string(data), alphaFiles)
+	})
+	addr := regexp.MustCompile(`serving metrics on (\S+)`).FindStringSubmatch(out.String())
+	if addr == nil {
+		t.Fatalf("metrics address not logged:\n%s", out.String())
+	}
+	resp, err := http.Get(addr[1])
+	if err != nil {
+		t.Fatalf("scrape: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), alphaFiles) {
+		t.Errorf("scrape = %s, want alpha's metrics", body)
+	}
+
+	writeConfig("@hourly")
+	reload <- os.Interrupt
+	waitFor(t, "reload", func() bool { return strings.Contains(out.String(), "config reloaded (2 scheduled servers)") })
+
+	clock.tick(t)
+	waitFor(t, "beta backup", func() bool { return archives("beta") == 1 })
+
+	// a broken config is rejected and the previous one kept
+	writeConfig("not a schedule")
+	reload <- os.Interrupt
+	waitFor(t, "failed reload", func() bool { return strings.Contains(out.String(), "reload failed") })
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("daemon returned %v", err)
+	}
+
+	output := out.String()
+	for _, want := range []string{
+		"daemon started (1 scheduled servers)",
+		"next backup at 2026-03-01T11:00:00Z",
+		"next backup at 2026-03-01T12:00:00Z",
+		"daemon stopped",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestRunDaemonInvalidSchedule(t *testing.T) {
+	resetRootCmd()
+	resetFlags()
+	rootCmd.AddCommand(daemonCmd)
+
+	tmp := t.TempDir()
+	cfgPath := filepath.Join(tmp, "config.yml")
+	os.WriteFile(cfgPath, []byte(`
+servers:
+  - name: alpha
+    schedule: "every tuesday"
+    connection: { type: ftp, host: example.com, remote_path: /alpha }
+`), 0o644)
+
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"daemon", "--config", cfgPath})
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "server alpha: invalid schedule") {
+		t.Errorf("err = %v, want invalid schedule", err)
+	}
+}
@@ -0,0 +1,56 @@
+// internal/cli/healthcheck.go
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/devtheops/gsbt/internal/healthcheck"
+	"github.com/devtheops/gsbt/internal/log"
+)
+
+// allow tests to shorten the pings' retries
+var newHealthcheck = healthcheck.New
+
+// pinger reports a run to a health check, keeping the run's log so a
+// failure ping can explain itself
+type pinger struct {
+	check  *healthcheck.Check
+	tail   *healthcheck.Tail
+	logger *log.Logger
+}
+
+// startHealthcheck pings url's /start and returns the pinger plus logger
+// teed into the log tail. Without a url it returns a nil pinger and logger
+// itself. Ping failures are only logged: monitoring never fails a backup.
+func startHealthcheck(ctx context.Context, url string, logger *log.Logger) (*pinger, *log.Logger) {
+	if url == "" {
+		return nil, logger
+	}
+	p := &pinger{
+		check:  newHealthcheck(url),
+		tail:   healthcheck.NewTail(healthcheck.DefaultTailSize),
+		logger: logger,
+	}
+	if err := p.check.Start(ctx); err != nil {
+		logger.Warn(fmt.Sprintf("[yellow]healthcheck failed:[/yellow] %v", err))
+	}
+	return p, logger.WithTee(p.tail)
+}
+
+// finish pings success, or failure with the log tail when err is set,
+// even when gsbt is being stopped
+func (p *pinger) finish(ctx context.Context, err error) {
+	if p == nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	if err == nil {
+		err = p.check.Success(ctx)
+	} else {
+		err = p.check.Fail(ctx, p.tail.Bytes())
+	}
+	if err != nil {
+		p.logger.Warn(fmt.Sprintf("[yellow]healthcheck failed:[/yellow] %v", err))
+	}
+}
@@ -0,0 +1,200 @@
+// internal/cli/history.go
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/devtheops/gsbt/internal/backup"
+	"github.com/devtheops/gsbt/internal/bytesize"
+	"github.com/devtheops/gsbt/internal/config"
+	"github.com/devtheops/gsbt/internal/history"
+	"github.com/devtheops/gsbt/internal/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyServer string
+	historySince  string
+	historyFailed bool
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show past runs",
+	Long: `Show past backup and prune runs, oldest first, one line per server.
+--since takes a duration such as 24h or 7d, or a date such as 2026-03-01.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runHistory(cmd)
+	},
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&historyServer, "server", "", "show runs of a specific server only")
+	historyCmd.Flags().StringVar(&historySince, "since", "", "show runs started since a duration ago or a date")
+	historyCmd.Flags().BoolVar(&historyFailed, "failed", false, "show failed and cancelled runs only")
+	rootCmd.AddCommand(historyCmd)
+}
+
+func runHistory(cmd *cobra.Command) error {
+	cfgPath, err := config.FindConfigFile(GetConfigFile())
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadConfig(cfgPath)
+	if err != nil {
+		return err
+	}
+
+	filter := history.Filter{Server: historyServer, Failed: historyFailed}
+	if historySince != "" {
+		if filter.Since, err = parseSince(historySince, time.Now()); err != nil {
+			return err
+		}
+	}
+	records, err := history.Read(historyPath(cfgPath, cfg), filter)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if GetOutputFormat() == "json" {
+		enc := json.NewEncoder(out)
+		for _, r := range records {
+			enc.Encode(r)
+		}
+		return nil
+	}
+	if len(records) == 0 {
+		fmt.Fprintln(out, "no runs recorded")
+		return nil
+	}
+	writeHistory(out, records)
+	return nil
+}
+
+// writeHistory prints records as a table
+func writeHistory(w io.Writer, records []history.Record) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "START\tCOMMAND\tSERVER\tOUTCOME\tDURATION\tFILES\tSIZE\tDETAILS")
+	for _, r := range records {
+		files, size := "-", "-"
+		if r.Archive != "" {
+			files, size = strconv.Itoa(r.Files), bytesize.Format(r.Bytes)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Start.Local().Format("2006-01-02 15:04:05"), r.Command, r.Server, r.Outcome,
+			r.Duration().Round(time.Second), files, size, historyDetails(r))
+	}
+	tw.Flush()
+}
+
+// historyDetails is the error of a failed run, otherwise what it produced
+func historyDetails(r history.Record) string {
+	switch {
+	case r.Error != "":
+		return r.Error
+	case len(r.Suspicious) > 0:
+		return "suspicious: " + strings.Join(r.Suspicious, "; ")
+	case r.Archive != "":
+		return filepath.Base(r.Archive)
+	case r.Command == "prune":
+		return fmt.Sprintf("%d archives deleted", len(r.Pruned))
+	}
+	return ""
+}
+
+// parseSince parses --since: a duration before now (with d for days) or a
+// date in local time
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use a duration such as 24h or 7d, or a date such as 2006-01-02", s)
+}
+
+// historyPath returns the history file: history_file, or history.jsonl
+// next to the config file
+func historyPath(cfgPath string, cfg *config.Config) string {
+	if cfg.Defaults.HistoryFile != "" {
+		return cfg.Defaults.HistoryFile
+	}
+	return filepath.Join(filepath.Dir(cfgPath), history.FileName)
+}
+
+// recordHistory appends r to the history file, only warning on failure:
+// history never fails a run
+func recordHistory(path string, r history.Record, logger *log.Logger) {
+	r.Version = version
+	if err := history.Append(path, r); err != nil {
+		logger.Warn(fmt.Sprintf("[yellow]history not recorded:[/yellow] %v", err))
+	}
+}
+
+// outcome returns the history outcome of a run that ended with err
+func outcome(ctx context.Context, err error) string {
+	switch {
+	case err == nil:
+		return history.Success
+	case ctx.Err() != nil:
+		return history.Cancelled
+	}
+	return history.Failed
+}
+
+// backupRecord is the history record of a server's backup
+func backupRecord(ctx context.Context, server string, start time.Time, archivePath string, stats backup.Stats, err error) history.Record {
+	r := history.Record{
+		Command:    "backup",
+		Server:     server,
+		Start:      start,
+		End:        time.Now(),
+		Outcome:    outcome(ctx, err),
+		Files:      stats.Files,
+		Bytes:      stats.Bytes,
+		Changed:    stats.Changed,
+		Suspicious: stats.Suspicious,
+		Pruned:     stats.Pruned,
+	}
+	if err != nil {
+		r.Error = err.Error()
+	} else {
+		r.Archive = archivePath
+	}
+	return r
+}
+
+// pruneRecord is the history record of a server's prune
+func pruneRecord(ctx context.Context, server string, start time.Time, res backup.PruneResult, err error) history.Record {
+	r := history.Record{
+		Command: "prune",
+		Server:  server,
+		Start:   start,
+		End:     time.Now(),
+		Outcome: outcome(ctx, err),
+	}
+	for _, a := range res.Deleted {
+		r.Pruned = append(r.Pruned, a.Path)
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
@@ -0,0 +1,139 @@
+// internal/cli/history_test.go
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devtheops/gsbt/internal/connector"
+	"github.com/devtheops/gsbt/internal/history"
+	"github.com/spf13/cobra"
+)
+
+func TestRunHistory(t *testing.T) {
+	resetRootCmd()
+	resetFlags()
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(pruneCmd)
+	rootCmd.AddCommand(historyCmd)
+	resetHistoryFlags := func() { historyServer, historySince, historyFailed = "", "", false }
+	resetHistoryFlags()
+	defer resetHistoryFlags()
+	// --help sticks to commands from the help tests
+	for _, cmd := range []*cobra.Command{backupCmd, pruneCmd, historyCmd} {
+		cmd.Flags().Set("help", "false")
+	}
+
+	tmp := t.TempDir()
+	cfgPath := filepath.Join(tmp, "config.yml")
+	historyFile := filepath.Join(tmp, "state", "runs.jsonl")
+	os.WriteFile(cfgPath, []byte(`
+defaults:
+  backup_location: `+filepath.Join(tmp, "backups")+`
+  history_file: `+historyFile+`
+servers:
+  - name: good
+    connection: { type: ftp, host: example.com, remote_path: /good }
+  - name: broken
+    connection: { type: ftp, host: example.com, remote_path: /broken }
+`), 0o644)
+
+	origNewConnector := newConnector
+	newConnector = func(cfg connector.Config) (connector.Connector, error) {
+		if cfg.RemotePath == "/broken" {
+			return &failListConnector{}, nil
+		}
+		return &mockSuccessConnector{}, nil
+	}
+	defer func() { newConnector = origNewConnector }()
+
+	run := func(args ...string) (string, error) {
+		buf := new(bytes.Buffer)
+		rootCmd.SetOut(buf)
+		rootCmd.SetErr(buf)
+		rootCmd.SetArgs(append(args, "--config", cfgPath))
+		err := rootCmd.Execute()
+		return buf.String(), err
+	}
+
+	backupServer = ""
+	if _, err := run("backup", "--sequential"); err == nil {
+		t.Fatal("expected failure for broken server")
+	}
+	pruneServer = "good"
+	defer func() { pruneServer = "" }()
+	if _, err := run("prune"); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+
+	records, err := history.Read(historyFile, history.Filter{})
+	if err != nil || len(records) != 3 {
+		t.Fatalf("history = %+v, %v, want 3 records", records, err)
+	}
+	good := records[0]
+	if good.Command != "backup" || good.Outcome != history.Success || good.Files != 1 || good.Archive == "" || good.Version != version {
+		t.Errorf("good backup = %+v", good)
+	}
+	if broken := records[1]; broken.Outcome != history.Failed || broken.Error != "list: listing refused" {
+		t.Errorf("broken backup = %+v", broken)
+	}
+	if records[2].Command != "prune" || records[2].Server != "good" {
+		t.Errorf("prune = %+v", records[2])
+	}
+
+	out, err := run("history", "--failed")
+	if err != nil {
+		t.Fatalf("history failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], "broken") || !strings.Contains(lines[1], "list: listing refused") {
+		t.Errorf("history --failed =\n%s", out)
+	}
+
+	resetHistoryFlags()
+	out, err = run("history", "--server", "good", "--since", "1h", "--output", "json")
+	if err != nil {
+		t.Fatalf("history failed: %v", err)
+	}
+	var commands []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var r history.Record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("invalid json line %q: %v", line, err)
+		}
+		commands = append(commands, r.Command)
+	}
+	if strings.Join(commands, ",") != "backup,prune" {
+		t.Errorf("history --server good = %v, want its backup and prune", commands)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.Local)
+	for _, tc := range []struct {
+		in   string
+		want time.Time
+	}{
+		{"24h", now.Add(-24 * time.Hour)},
+		{"90m", now.Add(-90 * time.Minute)},
+		{"7d", time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)},
+		{"2026-03-01", time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)},
+		{"2026-03-01 18:30", time.Date(2026, 3, 1, 18, 30, 0, 0, time.Local)},
+		{"2026-03-01T18:30:00Z", time.Date(2026, 3, 1, 18, 30, 0, 0, time.UTC)},
+	} {
+		got, err := parseSince(tc.in, now)
+		if err != nil || !got.Equal(tc.want) {
+			t.Errorf("parseSince(%q) = %v, %v, want %v", tc.in, got, err, tc.want)
+		}
+	}
+	for _, in := range []string{"yesterday", "-1h", "7w"} {
+		if _, err := parseSince(in, now); err == nil {
+			t.Errorf("parseSince(%q) expected error", in)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// internal/cli/hooks.go
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/devtheops/gsbt/internal/config"
+	"github.com/devtheops/gsbt/internal/hooks"
+	"github.com/devtheops/gsbt/internal/log"
+)
+
+// hookRunner runs a server's hooks, logging their output with the
+// server's prefix
+type hookRunner struct {
+	hooks  config.Hooks
+	logger *log.Logger
+}
+
+func (h hookRunner) run(ctx context.Context, kind hooks.Kind, env hooks.Env) error {
+	commands := h.commands(kind)
+	if len(commands) == 0 {
+		return nil
+	}
+	h.logger.Debug(fmt.Sprintf("running %s hooks", kind))
+	return hooks.Run(ctx, kind, commands, env, seconds(h.hooks.GetTimeout()), func(line string, stderr bool) {
+		msg := fmt.Sprintf("%s: %s", kind, line)
+		if stderr {
+			h.logger.Warn(msg)
+		} else {
+			h.logger.Info(msg)
+		}
+	})
+}
+
+func (h hookRunner) commands(kind hooks.Kind) []string {
+	switch kind {
+	case hooks.PreBackup:
+		return h.hooks.PreBackup
+	case hooks.PostBackup:
+		return h.hooks.PostBackup
+	case hooks.OnFailure:
+		return h.hooks.OnFailure
+	case hooks.PreRestore:
+		return h.hooks.PreRestore
+	case hooks.PostRestore:
+		return h.hooks.PostRestore
+	}
+	return nil
+}
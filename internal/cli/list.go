@@ -2,8 +2,14 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
 
+	"github.com/devtheops/gsbt/internal/config"
+	"github.com/devtheops/gsbt/internal/history"
 	"github.com/spf13/cobra"
 )
 
@@ -13,8 +19,8 @@ var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List configured servers",
 	Long:  `Show configured servers and their backup status.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Fprintln(cmd.OutOrStdout(), "list command - not yet implemented")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runList(cmd)
 	},
 }
 
@@ -22,3 +28,85 @@ func init() {
 	listCmd.Flags().StringVar(&listServer, "server", "", "show specific server details")
 	rootCmd.AddCommand(listCmd)
 }
+
+// serverStatus is a server's line in `gsbt list --output json`
+type serverStatus struct {
+	Server      string     `json:"server"`
+	Description string     `json:"description,omitempty"`
+	LastRun     *time.Time `json:"last_run,omitempty"`
+	Outcome     string     `json:"outcome,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastFailure *time.Time `json:"last_failure,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+func runList(cmd *cobra.Command) error {
+	cfgPath, err := config.FindConfigFile(GetConfigFile())
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadConfig(cfgPath)
+	if err != nil {
+		return err
+	}
+	servers, err := selectServers(cfg.Servers, listServer)
+	if err != nil {
+		return err
+	}
+	records, err := history.Read(historyPath(cfgPath, cfg), history.Filter{Server: listServer})
+	if err != nil {
+		return err
+	}
+
+	statuses := history.Statuses(records)
+	list := make([]serverStatus, 0, len(servers))
+	for _, srv := range servers {
+		s := statuses[srv.Name]
+		status := serverStatus{Server: srv.Name, Description: srv.Description}
+		if s.LastRun != nil {
+			status.LastRun, status.Outcome = &s.LastRun.End, s.LastRun.Outcome
+		}
+		if s.LastSuccess != nil {
+			status.LastSuccess = &s.LastSuccess.End
+		}
+		if s.LastFailure != nil {
+			status.LastFailure, status.LastError = &s.LastFailure.End, s.LastFailure.Error
+		}
+		list = append(list, status)
+	}
+
+	out := cmd.OutOrStdout()
+	if GetOutputFormat() == "json" {
+		enc := json.NewEncoder(out)
+		for _, s := range list {
+			enc.Encode(s)
+		}
+		return nil
+	}
+	writeServerList(out, list)
+	return nil
+}
+
+// writeServerList prints servers as a table
+func writeServerList(w io.Writer, list []serverStatus) {
+	when := func(t *time.Time) string {
+		if t == nil {
+			return "never"
+		}
+		return t.Local().Format("2006-01-02 15:04")
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERVER\tLAST BACKUP\tOUTCOME\tLAST SUCCESS\tLAST FAILURE")
+	for _, s := range list {
+		outcome, failure := "-", "-"
+		if s.Outcome != "" {
+			outcome = s.Outcome
+		}
+		if s.LastFailure != nil {
+			failure = fmt.Sprintf("%s: %s", when(s.LastFailure), s.LastError)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", s.Server, when(s.LastRun), outcome, when(s.LastSuccess), failure)
+	}
+	tw.Flush()
+}
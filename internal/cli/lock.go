@@ -0,0 +1,56 @@
+// internal/cli/lock.go
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/devtheops/gsbt/internal/lock"
+	"github.com/devtheops/gsbt/internal/log"
+	"github.com/spf13/cobra"
+)
+
+// lockFlags registers --wait and --no-wait, which choose whether cmd waits
+// for a server locked by another gsbt run or fails right away
+func lockFlags(cmd *cobra.Command, wait, noWait *bool, waitByDefault bool) {
+	waitUsage, noWaitUsage := "wait for other gsbt runs on the same server to finish", "fail if another gsbt run holds the server's lock"
+	if waitByDefault {
+		waitUsage += " (default)"
+	} else {
+		noWaitUsage += " (default)"
+	}
+	cmd.Flags().BoolVar(wait, "wait", false, waitUsage)
+	cmd.Flags().BoolVar(noWait, "no-wait", false, noWaitUsage)
+	cmd.MarkFlagsMutuallyExclusive("wait", "no-wait")
+}
+
+// shouldWait resolves the --wait/--no-wait flags
+func shouldWait(wait, noWait, waitByDefault bool) bool {
+	switch {
+	case wait:
+		return true
+	case noWait:
+		return false
+	}
+	return waitByDefault
+}
+
+// lockServer takes the lock in a server's backup location, so backups,
+// prunes and restores of the same server never run at once. A lock left
+// behind by a run that was killed is taken over.
+func lockServer(ctx context.Context, dir, command string, wait bool, logger *log.Logger) (*lock.Lock, error) {
+	l, err := lock.AcquireDir(ctx, dir, command, false)
+	var locked *lock.LockedError
+	if wait && errors.As(err, &locked) {
+		logger.Info(fmt.Sprintf("%v, waiting", err))
+		l, err = lock.AcquireDir(ctx, dir, command, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if l.Stale != nil {
+		logger.Warn(fmt.Sprintf("[yellow]taking over stale lock[/yellow] of %s", l.Stale))
+	}
+	return l, nil
+}
@@ -0,0 +1,91 @@
+// internal/cli/metrics.go
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/devtheops/gsbt/internal/backup"
+	"github.com/devtheops/gsbt/internal/lock"
+	"github.com/devtheops/gsbt/internal/log"
+	"github.com/devtheops/gsbt/internal/metrics"
+)
+
+// backupRun returns the metrics of a server's backup that started at start
+// and has just ended
+func backupRun(server string, start time.Time, stats backup.Stats, err error) metrics.Run {
+	return metrics.Run{
+		Server:   server,
+		End:      time.Now(),
+		Duration: time.Since(start),
+		Stats:    stats,
+		Err:      err,
+	}
+}
+
+// metricsLockTimeout bounds the wait for another gsbt process writing the
+// textfile
+const metricsLockTimeout = 10 * time.Second
+
+// recordMetrics adds runs to reg and, with a textfile path, rewrites the
+// textfile. The file is locked while it is read and rewritten, and what
+// other gsbt processes wrote to it is loaded first, so a daemon and a cron
+// run keep each other's servers. Failures are logged rather than returned:
+// metrics never fail a backup.
+func recordMetrics(ctx context.Context, reg *metrics.Registry, path string, runs []metrics.Run, logger *log.Logger) {
+	if path != "" {
+		// An interrupted run still records the servers it completed
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), metricsLockTimeout)
+		defer cancel()
+		if l, err := lock.Acquire(ctx, path+".lock", "metrics", true); err != nil {
+			logger.Warn(fmt.Sprintf("[yellow]metrics not written:[/yellow] %v", err))
+			path = ""
+		} else {
+			defer l.Release()
+			if err := reg.Load(path); err != nil {
+				logger.Warn(fmt.Sprintf("[yellow]previous metrics not loaded:[/yellow] %v", err))
+			}
+		}
+	}
+	for _, run := range runs {
+		reg.Record(run)
+	}
+	if path == "" {
+		return
+	}
+	if err := reg.WriteFile(path); err != nil {
+		logger.Warn(fmt.Sprintf("[yellow]metrics not written:[/yellow] %v", err))
+		return
+	}
+	logger.Debug(fmt.Sprintf("metrics written to %s", path))
+}
+
+// serveMetrics serves reg on addr at /metrics until ctx is done
+func serveMetrics(ctx context.Context, addr string, reg *metrics.Registry, logger *log.Logger) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("metrics.listen: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", reg)
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error(fmt.Sprintf("[red]metrics server failed:[/red] %v", err))
+		}
+	}()
+
+	logger.Info(fmt.Sprintf("serving metrics on http://%s/metrics", l.Addr()))
+	return nil
+}
@@ -0,0 +1,91 @@
+// internal/cli/notify.go
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/devtheops/gsbt/internal/backup"
+	"github.com/devtheops/gsbt/internal/config"
+	"github.com/devtheops/gsbt/internal/log"
+	"github.com/devtheops/gsbt/internal/notify"
+)
+
+// toNotifiers builds the configured notification targets
+func toNotifiers(notifications []config.Notification) ([]*notify.Target, error) {
+	var targets []*notify.Target
+	for i, n := range notifications {
+		t, err := notify.New(notify.Options{
+			Type:     n.Type,
+			URL:      n.URL,
+			Headers:  n.Headers,
+			Events:   n.Events,
+			Template: n.Template,
+			SMTP: notify.SMTPOptions{
+				Host:     n.Host,
+				Port:     n.Port,
+				TLS:      n.TLS,
+				Username: n.Username,
+				Password: n.Password,
+				From:     n.From,
+				To:       n.To,
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("notifications[%d]: %w", i, err)
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// backupEvents describes a server's backup, and the archives it pruned
+// for max_storage, for notifications
+func backupEvents(server, archivePath string, stats backup.Stats, err error) []notify.Event {
+	now := time.Now()
+	e := notify.Event{Type: notify.EventSuccess, Server: server, Time: now, Archive: archivePath, Stats: stats}
+	switch {
+	case errors.Is(err, context.Canceled):
+		e.Type, e.Error = notify.EventFailure, "cancelled"
+	case err != nil:
+		e.Type, e.Error = notify.EventFailure, err.Error()
+	case len(stats.Suspicious) > 0:
+		e.Type = notify.EventSuspicious
+	}
+	events := []notify.Event{e}
+	if len(stats.Pruned) > 0 {
+		events = append(events, notify.Event{Type: notify.EventPrune, Server: server, Time: now, Pruned: stats.Pruned})
+	}
+	return events
+}
+
+// pruneEvents describes a server's prune for notifications; nothing is
+// reported when no archive was deleted
+func pruneEvents(server string, res backup.PruneResult, err error) []notify.Event {
+	now := time.Now()
+	if err != nil {
+		return []notify.Event{{Type: notify.EventFailure, Server: server, Time: now, Error: "prune: " + err.Error()}}
+	}
+	if len(res.Deleted) == 0 {
+		return nil
+	}
+	pruned := make([]string, 0, len(res.Deleted))
+	for _, a := range res.Deleted {
+		pruned = append(pruned, a.Path)
+	}
+	return []notify.Event{{Type: notify.EventPrune, Server: server, Time: now, Pruned: pruned}}
+}
+
+// sendNotifications delivers summary to every target. Failures are only
+// logged; they don't change the outcome of the run.
+func sendNotifications(ctx context.Context, targets []*notify.Target, summary notify.Summary, logger *log.Logger) {
+	if len(targets) == 0 || len(summary.Events) == 0 {
+		return
+	}
+	// Interrupted runs are still reported
+	if err := notify.NotifyAll(context.WithoutCancel(ctx), targets, summary); err != nil {
+		logger.Warn(fmt.Sprintf("[yellow]notification failed:[/yellow] %v", err))
+	}
+}
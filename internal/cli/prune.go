@@ -2,27 +2,130 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"path/filepath"
+	"time"
 
+	"github.com/devtheops/gsbt/internal/backup"
+	"github.com/devtheops/gsbt/internal/config"
+	"github.com/devtheops/gsbt/internal/log"
+	"github.com/devtheops/gsbt/internal/notify"
 	"github.com/spf13/cobra"
 )
 
 var (
 	pruneServer string
 	pruneDryRun bool
+	pruneWait   bool
+	pruneNoWait bool
 )
 
 var pruneCmd = &cobra.Command{
 	Use:   "prune",
 	Short: "Remove old backups",
 	Long:  `Delete backups older than the configured prune_age.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Fprintln(cmd.OutOrStdout(), "prune command - not yet implemented")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPrune(cmd.Context(), cmd)
 	},
 }
 
 func init() {
 	pruneCmd.Flags().StringVar(&pruneServer, "server", "", "prune specific server only")
 	pruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "show what would be deleted")
+	lockFlags(pruneCmd, &pruneWait, &pruneNoWait, true)
 	rootCmd.AddCommand(pruneCmd)
 }
+
+func runPrune(ctx context.Context, cmd *cobra.Command) error {
+	logger := log.NewWithWriters(cmd.OutOrStdout(), cmd.ErrOrStderr())
+	logger.SetOutputFormat(GetOutputFormat())
+	logger.SetQuiet(IsQuiet())
+	logger.SetVerbose(IsVerbose())
+
+	cfgPath, err := config.FindConfigFile(GetConfigFile())
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(cfgPath)
+	if err != nil {
+		return err
+	}
+
+	servers, err := selectServers(cfg.Servers, pruneServer)
+	if err != nil {
+		return err
+	}
+
+	targets, err := toNotifiers(cfg.Notifications)
+	if err != nil {
+		return err
+	}
+
+	wait := shouldWait(pruneWait, pruneNoWait, true)
+	failures := 0
+	summary := notify.Summary{Command: "prune"}
+	historyFile := historyPath(cfgPath, cfg)
+	for _, srv := range servers {
+		start := time.Now()
+		res, err := pruneServerArchives(ctx, cfg, srv, logger, pruneDryRun, wait)
+		if err != nil {
+			failures++
+		}
+		if !pruneDryRun {
+			summary.Events = append(summary.Events, pruneEvents(srv.Name, res, err)...)
+			recordHistory(historyFile, pruneRecord(ctx, srv.Name, start, res, err), logger)
+		}
+	}
+	sendNotifications(ctx, targets, summary, logger)
+
+	if failures > 0 {
+		return fmt.Errorf("prune failed for %d servers", failures)
+	}
+	return nil
+}
+
+// pruneServerArchives prunes one server's archives, logging what was (or
+// with dryRun would be) deleted, and returns the result. wait decides what happens when another run
+// holds the server's lock.
+func pruneServerArchives(ctx context.Context, cfg *config.Config, srv config.Server, logger *log.Logger, dryRun, wait bool) (backup.PruneResult, error) {
+	serverLogger := withServer(logger, srv.Name)
+
+	dir := srv.GetBackupLocation(cfg.Defaults)
+	if dir == "" {
+		serverLogger.Error("[red]config error:[/red] no backup_location")
+		return backup.PruneResult{}, fmt.Errorf("no backup_location")
+	}
+	maxAge := time.Duration(srv.GetPruneAge(cfg.Defaults)) * 24 * time.Hour
+
+	// An archive being written is never pruned: the backup holds the lock
+	l, err := lockServer(ctx, dir, "prune", wait, serverLogger)
+	if err != nil {
+		serverLogger.Error(fmt.Sprintf("[red]lock error:[/red] %v", err))
+		return backup.PruneResult{}, err
+	}
+	defer l.Release()
+
+	verb := "deleted"
+	if dryRun {
+		verb = "would delete"
+	}
+
+	res, err := backup.Prune(dir, maxAge, srv.GetPruneKeepGood(cfg.Defaults), time.Now(), dryRun)
+	for _, a := range res.Deleted {
+		serverLogger.Info(fmt.Sprintf("%s %s", verb, filepath.Base(a.Path)),
+			log.Meta{"archive_path": a.Path, "dry_run": dryRun})
+	}
+	if len(res.Protected) > 0 {
+		serverLogger.Warn(fmt.Sprintf("[yellow]newest backups are suspicious,[/yellow] keeping %d old good archives",
+			len(res.Protected)))
+	}
+	if err != nil {
+		serverLogger.Error(fmt.Sprintf("[red]prune failed:[/red] %v", err))
+		return res, err
+	}
+	serverLogger.Info(fmt.Sprintf("[green]%s[/green] %d archives older than %d days",
+		verb, len(res.Deleted), srv.GetPruneAge(cfg.Defaults)))
+	return res, nil
+}
@@ -2,8 +2,11 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/devtheops/gsbt/internal/config"
+	"github.com/devtheops/gsbt/internal/log"
 	"github.com/spf13/cobra"
 )
 
@@ -12,6 +15,8 @@ var (
 	restoreLocal  string
 	restoreDryRun bool
 	restoreForce  bool
+	restoreWait   bool
+	restoreNoWait bool
 )
 
 var restoreCmd = &cobra.Command{
@@ -19,8 +24,8 @@ var restoreCmd = &cobra.Command{
 	Short: "Restore a backup",
 	Long:  `Restore a backup to a server or extract locally.`,
 	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Fprintf(cmd.OutOrStdout(), "restore command - not yet implemented (file: %s)\n", args[0])
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRestore(cmd.Context(), cmd, args[0])
 	},
 }
 
@@ -29,5 +34,45 @@ func init() {
 	restoreCmd.Flags().StringVar(&restoreLocal, "local", "", "extract to local path")
 	restoreCmd.Flags().BoolVar(&restoreDryRun, "dry-run", false, "show what would be restored")
 	restoreCmd.Flags().BoolVar(&restoreForce, "force", false, "skip confirmation prompt")
+	lockFlags(restoreCmd, &restoreWait, &restoreNoWait, true)
 	rootCmd.AddCommand(restoreCmd)
 }
+
+func runRestore(ctx context.Context, cmd *cobra.Command, file string) error {
+	if restoreServer != "" {
+		logger := log.NewWithWriters(cmd.OutOrStdout(), cmd.ErrOrStderr())
+		logger.SetOutputFormat(GetOutputFormat())
+		logger.SetQuiet(IsQuiet())
+		logger.SetVerbose(IsVerbose())
+
+		cfgPath, err := config.FindConfigFile(GetConfigFile())
+		if err != nil {
+			return err
+		}
+		cfg, err := config.LoadConfig(cfgPath)
+		if err != nil {
+			return err
+		}
+		servers, err := selectServers(cfg.Servers, restoreServer)
+		if err != nil {
+			return err
+		}
+
+		// Hold the server's lock so neither a backup nor prune touches its
+		// archives while one is restored
+		if dir := servers[0].GetBackupLocation(cfg.Defaults); dir != "" {
+			l, err := lockServer(ctx, dir, "restore", shouldWait(restoreWait, restoreNoWait, true), withServer(logger, restoreServer))
+			if err != nil {
+				return err
+			}
+			defer l.Release()
+		}
+
+		if h := servers[0].GetHooks(cfg.Defaults); len(h.PreRestore) > 0 || len(h.PostRestore) > 0 {
+			withServer(logger, restoreServer).Warn("pre_restore/post_restore hooks are not run yet: restore is not implemented")
+		}
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "restore command - not yet implemented (file: %s)\n", file)
+	return nil
+}
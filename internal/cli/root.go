@@ -2,12 +2,27 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
+	"github.com/devtheops/gsbt/internal/connector"
 	"github.com/spf13/cobra"
 )
 
+// Exit codes. ExitInterrupted follows the shell convention for SIGINT.
+const (
+	ExitFailure     = 1
+	ExitInterrupted = 130
+)
+
+// ErrInterrupted is returned by commands stopped by SIGINT or SIGTERM
+var ErrInterrupted = errors.New("interrupted")
+
 var (
 	cfgFile   string
 	outputFmt string
@@ -18,7 +33,7 @@ var (
 var rootCmd = &cobra.Command{
 	Use:   "gsbt",
 	Short: "Gameserver Backup Tool",
-	Long:  `A modular backup tool for game servers supporting FTP, SFTP, and Nitrado.`,
+	Long:  rootLong(),
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		if verbose && quiet {
 			return fmt.Errorf("--verbose and --quiet flags cannot be used together")
@@ -31,11 +46,30 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+// rootLong describes gsbt with the registered connector types
+func rootLong() string {
+	return "A modular backup tool for game servers with " + strings.Join(connector.Types(), ", ") + " connectors."
+}
+
 func Execute() {
-	err := rootCmd.Execute()
+	// The first signal cancels the command so it can clean up; after that
+	// the default handling applies and a second one kills gsbt right away
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
+
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
 	if err != nil {
-		os.Exit(1)
+		os.Exit(exitCode(err))
+	}
+}
+
+// exitCode maps a command error to the process exit code
+func exitCode(err error) int {
+	if errors.Is(err, ErrInterrupted) || errors.Is(err, context.Canceled) {
+		return ExitInterrupted
 	}
+	return ExitFailure
 }
 
 func init() {
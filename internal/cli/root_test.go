@@ -3,6 +3,8 @@ package cli
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -23,7 +25,7 @@ func resetRootCmd() {
 	rootCmd = &cobra.Command{
 		Use:   "gsbt",
 		Short: "Gameserver Backup Tool",
-		Long:  `A modular backup tool for game servers supporting FTP, SFTP, and Nitrado.`,
+		Long:  rootLong(),
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			if verbose && quiet {
 				return fmt.Errorf("--verbose and --quiet flags cannot be used together")
@@ -316,6 +318,11 @@ func TestRootCommandMetadata(t *testing.T) {
 	if !strings.Contains(rootCmd.Long, "modular backup tool") {
 		t.Errorf("rootCmd.Long doesn't contain expected text, got %q", rootCmd.Long)
 	}
+	for _, typ := range []string{"exec", "ftp", "nitrado", "sftp", "smb", "webdav"} {
+		if !strings.Contains(rootCmd.Long, typ) {
+			t.Errorf("rootCmd.Long doesn't list the %s connector, got %q", typ, rootCmd.Long)
+		}
+	}
 }
 
 func TestPersistentFlags(t *testing.T) {
@@ -368,3 +375,19 @@ func TestMutuallyExclusiveFlags(t *testing.T) {
 		t.Errorf("Expected error message %q, got %q", expectedErr, err.Error())
 	}
 }
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{errors.New("boom"), ExitFailure},
+		{fmt.Errorf("%w: 1 of 2 servers completed", ErrInterrupted), ExitInterrupted},
+		{fmt.Errorf("list: %w", context.Canceled), ExitInterrupted},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+// internal/cli/rsync_pipe.go
+package cli
+
+import (
+	"github.com/devtheops/gsbt/internal/connector"
+	"github.com/spf13/cobra"
+)
+
+// rsyncPipeCmd is run by rsync as its remote shell during SFTP delta transfers
+var rsyncPipeCmd = &cobra.Command{
+	Use:                connector.RsyncPipeCommand + " <socket> <host> <command...>",
+	Hidden:             true,
+	DisableFlagParsing: true,
+	SilenceUsage:       true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return connector.RsyncPipe(args, cmd.InOrStdin(), cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rsyncPipeCmd)
+}
@@ -4,6 +4,8 @@ package config
 import (
 	"os"
 	"regexp"
+
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -66,6 +68,22 @@ func ExpandEnvVarsInConfig(cfg *Config) {
 	cfg.Defaults.TempDir = ExpandEnvVars(cfg.Defaults.TempDir)
 	cfg.Defaults.EnvFile = ExpandEnvVars(cfg.Defaults.EnvFile)
 	cfg.Defaults.NitradoAPIKey = ExpandEnvVars(cfg.Defaults.NitradoAPIKey)
+	cfg.Defaults.HealthcheckURL = ExpandEnvVars(cfg.Defaults.HealthcheckURL)
+	cfg.Defaults.HistoryFile = ExpandEnvVars(cfg.Defaults.HistoryFile)
+
+	cfg.Metrics.Textfile = ExpandEnvVars(cfg.Metrics.Textfile)
+	cfg.Metrics.Listen = ExpandEnvVars(cfg.Metrics.Listen)
+
+	for i := range cfg.Notifications {
+		n := &cfg.Notifications[i]
+		n.URL = ExpandEnvVars(n.URL)
+		n.Host = ExpandEnvVars(n.Host)
+		n.Username = ExpandEnvVars(n.Username)
+		n.Password = ExpandEnvVars(n.Password)
+		for k, v := range n.Headers {
+			n.Headers[k] = ExpandEnvVars(v)
+		}
+	}
 
 	// Expand each server
 	for i := range cfg.Servers {
@@ -73,17 +91,13 @@ func ExpandEnvVarsInConfig(cfg *Config) {
 		server.Name = ExpandEnvVars(server.Name)
 		server.Description = ExpandEnvVars(server.Description)
 		server.BackupLocation = ExpandEnvVars(server.BackupLocation)
+		server.HealthcheckURL = ExpandEnvVars(server.HealthcheckURL)
 
 		// Expand connection fields
 		conn := &server.Connection
 		conn.Type = ExpandEnvVars(conn.Type)
-		conn.Host = ExpandEnvVars(conn.Host)
-		conn.Username = ExpandEnvVars(conn.Username)
-		conn.Password = ExpandEnvVars(conn.Password)
-		conn.KeyFile = ExpandEnvVars(conn.KeyFile)
-		conn.APIKey = ExpandEnvVars(conn.APIKey)
-		conn.ServiceID = ExpandEnvVars(conn.ServiceID)
 		conn.RemotePath = ExpandEnvVars(conn.RemotePath)
+		expandEnvVarsInNode(&conn.raw)
 
 		// Expand include/exclude patterns
 		for j := range conn.Include {
@@ -92,5 +106,42 @@ func ExpandEnvVarsInConfig(cfg *Config) {
 		for j := range conn.Exclude {
 			conn.Exclude[j] = ExpandEnvVars(conn.Exclude[j])
 		}
+
+		// Expand rcon fields
+		if rc := server.RCON; rc != nil {
+			rc.Host = ExpandEnvVars(rc.Host)
+			rc.Password = ExpandEnvVars(rc.Password)
+			for j := range rc.PreCommands {
+				rc.PreCommands[j] = ExpandEnvVars(rc.PreCommands[j])
+			}
+			for j := range rc.PostCommands {
+				rc.PostCommands[j] = ExpandEnvVars(rc.PostCommands[j])
+			}
+		}
+	}
+}
+
+// expandEnvVarsInNode expands every scalar in a YAML node tree in place, so
+// connector options decoded later see expanded values. Plain (unquoted)
+// scalars have their tag reset so e.g. `port: ${FTP_PORT}` decodes as an int.
+func expandEnvVarsInNode(n *yaml.Node) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		expanded := ExpandEnvVars(n.Value)
+		if expanded != n.Value {
+			n.Value = expanded
+			if n.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+				n.Tag = ""
+			}
+		}
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range n.Content {
+			expandEnvVarsInNode(child)
+		}
+	case yaml.MappingNode:
+		// Content alternates key, value; only values are expanded
+		for i := 1; i < len(n.Content); i += 2 {
+			expandEnvVarsInNode(n.Content[i])
+		}
 	}
 }
@@ -4,6 +4,8 @@ package config
 import (
 	"os"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestExpandEnvVars(t *testing.T) {
@@ -90,10 +92,25 @@ func TestExpandEnvVarsInConfig(t *testing.T) {
 	os.Setenv("TEST_BACKUP_LOCATION", "/srv/backups")
 	os.Setenv("TEST_FTP_PASSWORD", "ftp_pass123")
 	os.Setenv("TEST_NITRADO_KEY", "nitrado_key_456")
+	os.Setenv("TEST_FTP_PORT", "2121")
+	defer os.Unsetenv("TEST_FTP_PORT")
 	defer os.Unsetenv("TEST_BACKUP_LOCATION")
 	defer os.Unsetenv("TEST_FTP_PASSWORD")
 	defer os.Unsetenv("TEST_NITRADO_KEY")
 
+	var conn Connection
+	connYAML := `
+type: ftp
+host: localhost
+port: ${TEST_FTP_PORT}
+username: user
+password: ${TEST_FTP_PASSWORD}
+remote_path: ${TEST_BACKUP_LOCATION:-/x}/saves
+`
+	if err := yaml.Unmarshal([]byte(connYAML), &conn); err != nil {
+		t.Fatalf("failed to parse connection: %v", err)
+	}
+
 	cfg := &Config{
 		Defaults: Defaults{
 			BackupLocation: "${TEST_BACKUP_LOCATION}",
@@ -105,12 +122,7 @@ func TestExpandEnvVarsInConfig(t *testing.T) {
 			{
 				Name:           "test-server",
 				BackupLocation: "${TEST_BACKUP_LOCATION}/test",
-				Connection: Connection{
-					Type:     "ftp",
-					Host:     "localhost",
-					Username: "user",
-					Password: "${TEST_FTP_PASSWORD}",
-				},
+				Connection:     conn,
 			},
 		},
 	}
@@ -132,7 +144,22 @@ func TestExpandEnvVarsInConfig(t *testing.T) {
 	if cfg.Servers[0].BackupLocation != "/srv/backups/test" {
 		t.Errorf("expected server BackupLocation=/srv/backups/test, got %s", cfg.Servers[0].BackupLocation)
 	}
-	if cfg.Servers[0].Connection.Password != "ftp_pass123" {
-		t.Errorf("expected server Password=ftp_pass123, got %s", cfg.Servers[0].Connection.Password)
+	if cfg.Servers[0].Connection.RemotePath != "/srv/backups/saves" {
+		t.Errorf("expected server RemotePath=/srv/backups/saves, got %s", cfg.Servers[0].Connection.RemotePath)
+	}
+
+	// Connector-specific options are expanded in the raw block
+	var opts struct {
+		Port     int    `yaml:"port"`
+		Password string `yaml:"password"`
+	}
+	if err := cfg.Servers[0].Connection.Decode(&opts); err != nil {
+		t.Fatalf("failed to decode connection options: %v", err)
+	}
+	if opts.Password != "ftp_pass123" {
+		t.Errorf("expected server Password=ftp_pass123, got %s", opts.Password)
+	}
+	if opts.Port != 2121 {
+		t.Errorf("expected server Port=2121, got %d", opts.Port)
 	}
 }
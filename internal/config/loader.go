@@ -89,4 +89,14 @@ func applyDefaults(cfg *Config) {
 	if cfg.Defaults.PruneAge == 0 {
 		cfg.Defaults.PruneAge = 30
 	}
+	setDefault(&cfg.Defaults.ConsistencyRetries, 2)
+	setDefault(&cfg.Defaults.PruneKeepGood, 3)
+	setDefault(&cfg.Defaults.Hooks.Timeout, 300)
+}
+
+// setDefault sets an unset setting to v, keeping an explicit 0
+func setDefault(p **int, v int) {
+	if *p == nil {
+		*p = &v
+	}
 }
@@ -127,6 +127,51 @@ servers:
 	if cfg.Defaults.PruneAge != 30 {
 		t.Errorf("expected default prune_age 30, got %d", cfg.Defaults.PruneAge)
 	}
+	if cfg.Defaults.GetConsistencyRetries() != 2 {
+		t.Errorf("expected default consistency_retries 2, got %d", cfg.Defaults.GetConsistencyRetries())
+	}
+	if cfg.Defaults.Hooks.GetTimeout() != 300 {
+		t.Errorf("expected default hooks.timeout 300, got %d", cfg.Defaults.Hooks.GetTimeout())
+	}
+}
+
+func TestLoadConfigExplicitZero(t *testing.T) {
+	testConfig := filepath.Join(t.TempDir(), "config.yml")
+	os.WriteFile(testConfig, []byte(`
+defaults:
+  backup_location: /srv/backups/
+  consistency_retries: 0
+  prune_keep_good: 0
+  hooks:
+    timeout: 0
+servers:
+  - name: test
+    connection: { type: ftp, host: localhost }
+  - name: keeps-one
+    prune_keep_good: 1
+    consistency_retries: 3
+    connection: { type: ftp, host: localhost }
+`), 0644)
+
+	cfg, err := LoadConfig(testConfig)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if got := cfg.Defaults.GetConsistencyRetries(); got != 0 {
+		t.Errorf("consistency_retries = %d, want the explicit 0", got)
+	}
+	if got := cfg.Servers[0].GetPruneKeepGood(cfg.Defaults); got != 0 {
+		t.Errorf("prune_keep_good = %d, want the explicit 0", got)
+	}
+	if got := cfg.Servers[1].GetPruneKeepGood(cfg.Defaults); got != 1 {
+		t.Errorf("server prune_keep_good = %d, want 1", got)
+	}
+	if got := cfg.Servers[1].GetConsistencyRetries(cfg.Defaults); got != 3 {
+		t.Errorf("server consistency_retries = %d, want 3", got)
+	}
+	if got := cfg.Servers[0].GetHooks(cfg.Defaults).GetTimeout(); got != 0 {
+		t.Errorf("hooks.timeout = %d, want the explicit 0", got)
+	}
 }
 
 func TestLoadConfigWithEnvVars(t *testing.T) {
@@ -164,7 +209,13 @@ servers:
 		t.Errorf("expected backup_location /test/backups, got %s", cfg.Defaults.BackupLocation)
 	}
 
-	if cfg.Servers[0].Connection.Host != "test.example.com" {
-		t.Errorf("expected host test.example.com, got %s", cfg.Servers[0].Connection.Host)
+	var opts struct {
+		Host string `yaml:"host"`
+	}
+	if err := cfg.Servers[0].Connection.Decode(&opts); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if opts.Host != "test.example.com" {
+		t.Errorf("expected host test.example.com, got %s", opts.Host)
 	}
 }
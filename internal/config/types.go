@@ -1,14 +1,52 @@
 // internal/config/types.go
 package config
 
-import "path/filepath"
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
 
 //go:generate go run ../../cmd/schema-gen/main.go ../../gsbt.schema.json
 
 // Config is the root configuration structure
 type Config struct {
-	Defaults Defaults `yaml:"defaults,omitempty"`
-	Servers  []Server `yaml:"servers"`
+	Defaults      Defaults       `yaml:"defaults,omitempty"`
+	Servers       []Server       `yaml:"servers"`
+	Notifications []Notification `yaml:"notifications,omitempty"`
+	Metrics       Metrics        `yaml:"metrics,omitempty"`
+}
+
+// Metrics configures the Prometheus metrics of backups
+type Metrics struct {
+	// Textfile is rewritten after each run for node_exporter's textfile collector
+	Textfile string `yaml:"textfile,omitempty"`
+	// Listen is the address `gsbt daemon` serves /metrics on, e.g. ":9110"
+	Listen string `yaml:"listen,omitempty"`
+}
+
+// Notification is a destination told about the outcome of each run
+type Notification struct {
+	// Type is webhook, discord, slack or email
+	Type    string            `yaml:"type"`
+	URL     string            `yaml:"url,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// Events to notify: success, failure, suspicious, prune (default failure and suspicious)
+	Events []string `yaml:"events,omitempty"`
+	// Template renders one line per event (Go text/template)
+	Template string `yaml:"template,omitempty"`
+
+	// SMTP settings for email; tls is starttls (default), implicit or none
+	Host     string   `yaml:"host,omitempty"`
+	Port     int      `yaml:"port,omitempty"`
+	TLS      string   `yaml:"tls,omitempty"`
+	Username string   `yaml:"username,omitempty"`
+	Password string   `yaml:"password,omitempty"`
+	From     string   `yaml:"from,omitempty"`
+	To       []string `yaml:"to,omitempty"`
 }
 
 // Defaults holds default values for all servers
@@ -21,6 +59,57 @@ type Defaults struct {
 	RetryBackoff   bool   `yaml:"retry_backoff,omitempty"`
 	EnvFile        string `yaml:"env_file,omitempty"`
 	NitradoAPIKey  string `yaml:"nitrado_api_key,omitempty"`
+
+	// Bandwidth limits per server (overridable) and shared by all servers
+	BandwidthLimit       string            `yaml:"bandwidth_limit,omitempty"`
+	BandwidthSchedule    []BandwidthWindow `yaml:"bandwidth_schedule,omitempty"`
+	GlobalBandwidthLimit string            `yaml:"global_bandwidth_limit,omitempty"`
+
+	// Files that change during download are fetched again up to
+	// consistency_retries times (default 2, 0 for none), then handled per
+	// consistency (warn, fail, mark)
+	Consistency        string `yaml:"consistency,omitempty"`
+	ConsistencyRetries *int   `yaml:"consistency_retries,omitempty"`
+
+	// Sanity guards (overridable per server) and the good archives prune
+	// keeps while the newest backups fail them
+	MinFiles         int    `yaml:"min_files,omitempty"`
+	MinBytes         string `yaml:"min_bytes,omitempty"`
+	MaxShrinkPercent int    `yaml:"max_shrink_percent,omitempty"`
+	GuardAction      string `yaml:"guard_action,omitempty"`
+	PruneKeepGood    *int   `yaml:"prune_keep_good,omitempty"`
+
+	// MaxStorage caps each server's archives, e.g. "50GB"; the oldest are
+	// pruned to make room for a new backup
+	MaxStorage string `yaml:"max_storage,omitempty"`
+
+	// Timeouts in seconds (overridable per server), 0 for none.
+	// idle_transfer_timeout aborts a download that receives nothing for
+	// that long; server_timeout bounds a server's whole backup.
+	ConnectTimeout      int `yaml:"connect_timeout,omitempty"`
+	ListTimeout         int `yaml:"list_timeout,omitempty"`
+	IdleTransferTimeout int `yaml:"idle_transfer_timeout,omitempty"`
+	ServerTimeout       int `yaml:"server_timeout,omitempty"`
+
+	// Schedule for `gsbt daemon` (overridable per server): a cron expression
+	// or shorthand such as "@hourly", its timezone (local time when empty)
+	// and up to schedule_jitter seconds of random delay per run
+	Schedule         string `yaml:"schedule,omitempty"`
+	ScheduleTimezone string `yaml:"schedule_timezone,omitempty"`
+	ScheduleJitter   int    `yaml:"schedule_jitter,omitempty"`
+
+	Hooks Hooks `yaml:"hooks,omitempty"`
+
+	// ProgressInterval is how often, in seconds, --output json reports
+	// progress within a file (default 1)
+	ProgressInterval int `yaml:"progress_interval,omitempty"`
+
+	// HealthcheckURL is a healthchecks.io-style check pinged around each
+	// `gsbt backup` run as a whole; servers are not pinged on it
+	HealthcheckURL string `yaml:"healthcheck_url,omitempty"`
+
+	// HistoryFile records every run (default history.jsonl next to the config file)
+	HistoryFile string `yaml:"history_file,omitempty"`
 }
 
 // Server represents a single gameserver configuration
@@ -30,23 +119,161 @@ type Server struct {
 	BackupLocation string     `yaml:"backup_location,omitempty"`
 	PruneAge       int        `yaml:"prune_age,omitempty"`
 	Connection     Connection `yaml:"connection"`
+	RCON           *RCON      `yaml:"rcon,omitempty"`
+
+	BandwidthLimit     string            `yaml:"bandwidth_limit,omitempty"`
+	BandwidthSchedule  []BandwidthWindow `yaml:"bandwidth_schedule,omitempty"`
+	Consistency        string            `yaml:"consistency,omitempty"`
+	ConsistencyRetries *int              `yaml:"consistency_retries,omitempty"`
+
+	MinFiles         *int   `yaml:"min_files,omitempty"`
+	MinBytes         string `yaml:"min_bytes,omitempty"`
+	MaxShrinkPercent *int   `yaml:"max_shrink_percent,omitempty"`
+	GuardAction      string `yaml:"guard_action,omitempty"`
+	PruneKeepGood    *int   `yaml:"prune_keep_good,omitempty"`
+	MaxStorage       string `yaml:"max_storage,omitempty"`
+
+	ConnectTimeout      *int `yaml:"connect_timeout,omitempty"`
+	ListTimeout         *int `yaml:"list_timeout,omitempty"`
+	IdleTransferTimeout *int `yaml:"idle_transfer_timeout,omitempty"`
+	ServerTimeout       *int `yaml:"server_timeout,omitempty"`
+
+	Schedule         string  `yaml:"schedule,omitempty"`
+	ScheduleTimezone *string `yaml:"schedule_timezone,omitempty"`
+	ScheduleJitter   *int    `yaml:"schedule_jitter,omitempty"`
+
+	Hooks Hooks `yaml:"hooks,omitempty"`
+
+	// HealthcheckURL is pinged around this server's backups
+	HealthcheckURL string `yaml:"healthcheck_url,omitempty"`
+}
+
+// Hooks are shell commands run around backups and restores. A server's
+// hooks replace the default ones of the same kind.
+type Hooks struct {
+	PreBackup   Commands `yaml:"pre_backup,omitempty"`
+	PostBackup  Commands `yaml:"post_backup,omitempty"`
+	OnFailure   Commands `yaml:"on_failure,omitempty"`
+	PreRestore  Commands `yaml:"pre_restore,omitempty"`
+	PostRestore Commands `yaml:"post_restore,omitempty"`
+
+	// Timeout per command in seconds (default 300, 0 for none)
+	Timeout *int `yaml:"timeout,omitempty"`
+	// PreFailure is abort (default) or continue
+	PreFailure string `yaml:"pre_failure,omitempty"`
+}
+
+// Commands is a list of shell commands; a single string is also accepted
+type Commands []string
+
+// UnmarshalYAML accepts a string or a list of strings
+func (c *Commands) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*c = Commands{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*c = list
+	return nil
+}
+
+// Schedule is a server's daemon schedule merged with the defaults
+type Schedule struct {
+	Cron     string
+	Timezone string
+	Jitter   int
+}
+
+// Timeouts are a server's timeouts in seconds merged with the defaults
+type Timeouts struct {
+	Connect      int
+	List         int
+	IdleTransfer int
+	Server       int
+}
+
+// Guards are a server's sanity checks merged with the defaults
+type Guards struct {
+	MinFiles         int
+	MinBytes         string
+	MaxShrinkPercent int
+	Action           string
 }
 
-// Connection holds connector-specific configuration
+// BandwidthWindow overrides bandwidth limits during a daily time range such
+// as "08:00-18:00" (local time, may wrap past midnight). Empty limits keep
+// the regular value; "unlimited" lifts it.
+type BandwidthWindow struct {
+	Hours       string `yaml:"hours"`
+	Limit       string `yaml:"limit,omitempty"`
+	GlobalLimit string `yaml:"global_limit,omitempty"`
+}
+
+// Connection holds connector configuration. Only the settings shared by all
+// connectors are decoded here; the full block is kept so connector-specific
+// options can be decoded by type (see Decode).
 type Connection struct {
 	Type       string   `yaml:"type"`
-	Host       string   `yaml:"host,omitempty"`
-	Port       int      `yaml:"port,omitempty"`
-	Username   string   `yaml:"username,omitempty"`
-	Password   string   `yaml:"password,omitempty"`
-	KeyFile    string   `yaml:"key_file,omitempty"`
-	Passive    *bool    `yaml:"passive,omitempty"`
-	TLS        bool     `yaml:"tls,omitempty"`
-	APIKey     string   `yaml:"api_key,omitempty"`
-	ServiceID  string   `yaml:"service_id,omitempty"`
 	RemotePath string   `yaml:"remote_path,omitempty"`
 	Include    []string `yaml:"include,omitempty"`
 	Exclude    []string `yaml:"exclude,omitempty"`
+
+	raw yaml.Node
+}
+
+// UnmarshalYAML decodes the common fields and retains the raw block
+func (c *Connection) UnmarshalYAML(node *yaml.Node) error {
+	type plain Connection
+	var p plain
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+	*c = Connection(p)
+	c.raw = *node
+	return nil
+}
+
+// Decode decodes the full connection block into v, typically a connector's options struct
+func (c *Connection) Decode(v interface{}) error {
+	if c.raw.Kind == 0 {
+		return nil
+	}
+	return c.raw.Decode(v)
+}
+
+// DecodeOptions decodes the connection block into v, a pointer to a
+// connector's options struct, like Decode, but reports settings that are
+// neither shared nor fields of v, so a typo isn't silently ignored
+func (c *Connection) DecodeOptions(v interface{}) error {
+	known := map[string]bool{"type": true, "remote_path": true, "include": true, "exclude": true}
+	t := reflect.TypeOf(v).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name == "" {
+			name = strings.ToLower(t.Field(i).Name)
+		}
+		known[name] = true
+	}
+	for i := 0; i+1 < len(c.raw.Content); i += 2 {
+		if key := c.raw.Content[i]; !known[key.Value] {
+			return fmt.Errorf("line %d: unknown setting %q", key.Line, key.Value)
+		}
+	}
+	return c.Decode(v)
+}
+
+// RCON holds remote console settings used to flush the world around a backup
+type RCON struct {
+	Host         string   `yaml:"host,omitempty"`
+	Port         int      `yaml:"port,omitempty"`
+	Password     string   `yaml:"password"`
+	Protocol     string   `yaml:"protocol,omitempty"`
+	PreCommands  []string `yaml:"pre_commands,omitempty"`
+	PostCommands []string `yaml:"post_commands,omitempty"`
+	WaitAfter    int      `yaml:"wait_after,omitempty"`
 }
 
 // GetBackupLocation returns server-specific location, or the default with the server name appended
@@ -71,6 +298,133 @@ func (s *Server) GetPruneAge(defaults Defaults) int {
 	return defaults.PruneAge
 }
 
+// GetBandwidth returns the server's bandwidth limit and schedule, each
+// falling back to the defaults when not set on the server
+func (s *Server) GetBandwidth(defaults Defaults) (string, []BandwidthWindow) {
+	limit, schedule := s.BandwidthLimit, s.BandwidthSchedule
+	if limit == "" {
+		limit = defaults.BandwidthLimit
+	}
+	if schedule == nil {
+		schedule = defaults.BandwidthSchedule
+	}
+	return limit, schedule
+}
+
+// GetConsistency returns server-specific or default consistency policy
+func (s *Server) GetConsistency(defaults Defaults) string {
+	if s.Consistency != "" {
+		return s.Consistency
+	}
+	return defaults.Consistency
+}
+
+// GetConsistencyRetries returns consistency_retries, 0 when unset
+func (d Defaults) GetConsistencyRetries() int {
+	return intValue(d.ConsistencyRetries)
+}
+
+// GetConsistencyRetries returns server-specific or default consistency_retries
+func (s *Server) GetConsistencyRetries(defaults Defaults) int {
+	return intOr(s.ConsistencyRetries, defaults.GetConsistencyRetries())
+}
+
+// GetGuards returns the server's sanity guards, each falling back to the
+// defaults when not set on the server, so a server can turn one off with 0
+func (s *Server) GetGuards(defaults Defaults) Guards {
+	g := Guards{
+		MinFiles:         intOr(s.MinFiles, defaults.MinFiles),
+		MinBytes:         defaults.MinBytes,
+		MaxShrinkPercent: intOr(s.MaxShrinkPercent, defaults.MaxShrinkPercent),
+		Action:           defaults.GuardAction,
+	}
+	if s.MinBytes != "" {
+		g.MinBytes = s.MinBytes
+	}
+	if s.GuardAction != "" {
+		g.Action = s.GuardAction
+	}
+	return g
+}
+
+// GetTimeout returns the per-command timeout in seconds, 0 when unset
+func (h Hooks) GetTimeout() int {
+	return intValue(h.Timeout)
+}
+
+// GetPruneKeepGood returns server-specific or default number of good archives prune keeps
+func (s *Server) GetPruneKeepGood(defaults Defaults) int {
+	if s.PruneKeepGood != nil {
+		return *s.PruneKeepGood
+	}
+	return intValue(defaults.PruneKeepGood)
+}
+
+// GetMaxStorage returns server-specific or default storage quota
+func (s *Server) GetMaxStorage(defaults Defaults) string {
+	if s.MaxStorage != "" {
+		return s.MaxStorage
+	}
+	return defaults.MaxStorage
+}
+
+// GetTimeouts returns the server's timeouts, each falling back to the
+// defaults when not set on the server, so a server can also set 0
+func (s *Server) GetTimeouts(defaults Defaults) Timeouts {
+	return Timeouts{
+		Connect:      intOr(s.ConnectTimeout, defaults.ConnectTimeout),
+		List:         intOr(s.ListTimeout, defaults.ListTimeout),
+		IdleTransfer: intOr(s.IdleTransferTimeout, defaults.IdleTransferTimeout),
+		Server:       intOr(s.ServerTimeout, defaults.ServerTimeout),
+	}
+}
+
+// GetSchedule returns the server's daemon schedule, each setting falling
+// back to the defaults when not set on the server, so a server can set
+// local time ("") or no jitter (0)
+func (s *Server) GetSchedule(defaults Defaults) Schedule {
+	sched := Schedule{
+		Cron:     defaults.Schedule,
+		Timezone: defaults.ScheduleTimezone,
+		Jitter:   intOr(s.ScheduleJitter, defaults.ScheduleJitter),
+	}
+	if s.Schedule != "" {
+		sched.Cron = s.Schedule
+	}
+	if s.ScheduleTimezone != nil {
+		sched.Timezone = *s.ScheduleTimezone
+	}
+	return sched
+}
+
+// GetHooks returns the server's hooks, each kind and setting falling back
+// to the defaults when not set on the server
+func (s *Server) GetHooks(defaults Defaults) Hooks {
+	h, d := s.Hooks, defaults.Hooks
+	if h.PreBackup == nil {
+		h.PreBackup = d.PreBackup
+	}
+	if h.PostBackup == nil {
+		h.PostBackup = d.PostBackup
+	}
+	if h.OnFailure == nil {
+		h.OnFailure = d.OnFailure
+	}
+	if h.PreRestore == nil {
+		h.PreRestore = d.PreRestore
+	}
+	if h.PostRestore == nil {
+		h.PostRestore = d.PostRestore
+	}
+	if h.Timeout == nil {
+		h.Timeout = d.Timeout
+	}
+	if h.PreFailure == "" {
+		h.PreFailure = d.PreFailure
+	}
+	return h
+}
+
 // GetInclude returns include patterns or default ["*"]
 func (c *Connection) GetInclude() []string {
 	if len(c.Include) > 0 {
@@ -79,10 +433,19 @@ func (c *Connection) GetInclude() []string {
 	return []string{"*"}
 }
 
-// IsPassive returns passive mode setting (default true)
-func (c *Connection) IsPassive() bool {
-	if c.Passive != nil {
-		return *c.Passive
+// intValue returns a setting that distinguishes an explicit 0 from unset,
+// 0 when unset
+func intValue(p *int) int {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+// intOr returns *p, or def when p is nil
+func intOr(p *int, def int) int {
+	if p == nil {
+		return def
 	}
-	return true
-}
\ No newline at end of file
+	return *p
+}
@@ -2,11 +2,120 @@
 package config
 
 import (
+	"strings"
 	"testing"
 
 	"gopkg.in/yaml.v3"
 )
 
+func TestGetBandwidth(t *testing.T) {
+	defaults := Defaults{
+		BandwidthLimit:    "10MiB/s",
+		BandwidthSchedule: []BandwidthWindow{{Hours: "18:00-23:00", Limit: "2MiB/s"}},
+	}
+
+	s := Server{}
+	limit, schedule := s.GetBandwidth(defaults)
+	if limit != "10MiB/s" || len(schedule) != 1 {
+		t.Errorf("inherited = %q, %v", limit, schedule)
+	}
+
+	s = Server{BandwidthLimit: "unlimited", BandwidthSchedule: []BandwidthWindow{}}
+	limit, schedule = s.GetBandwidth(defaults)
+	if limit != "unlimited" || len(schedule) != 0 {
+		t.Errorf("overridden = %q, %v", limit, schedule)
+	}
+}
+
+func TestGetGuards(t *testing.T) {
+	defaults := Defaults{MinFiles: 1, MaxShrinkPercent: 50, GuardAction: "mark"}
+	minFiles, none := 10, 0
+	s := Server{MinFiles: &minFiles, MinBytes: "1GB"}
+	want := Guards{MinFiles: 10, MinBytes: "1GB", MaxShrinkPercent: 50, Action: "mark"}
+	if got := s.GetGuards(defaults); got != want {
+		t.Errorf("GetGuards = %+v, want %+v", got, want)
+	}
+
+	// an explicit 0 turns a default guard off for the server
+	s = Server{MinFiles: &none, MaxShrinkPercent: &none}
+	want = Guards{Action: "mark"}
+	if got := s.GetGuards(defaults); got != want {
+		t.Errorf("GetGuards = %+v, want %+v", got, want)
+	}
+}
+
+func TestGetTimeouts(t *testing.T) {
+	defaults := Defaults{ConnectTimeout: 10, IdleTransferTimeout: 120}
+	idle, server, none := 600, 3600, 0
+	s := Server{IdleTransferTimeout: &idle, ServerTimeout: &server}
+	want := Timeouts{Connect: 10, IdleTransfer: 600, Server: 3600}
+	if got := s.GetTimeouts(defaults); got != want {
+		t.Errorf("GetTimeouts = %+v, want %+v", got, want)
+	}
+
+	// an explicit 0 overrides the default
+	s = Server{ConnectTimeout: &none}
+	want = Timeouts{IdleTransfer: 120}
+	if got := s.GetTimeouts(defaults); got != want {
+		t.Errorf("GetTimeouts = %+v, want %+v", got, want)
+	}
+}
+
+func TestGetSchedule(t *testing.T) {
+	defaults := Defaults{Schedule: "@daily", ScheduleTimezone: "Europe/Berlin", ScheduleJitter: 300}
+	s := Server{Schedule: "0 */6 * * *"}
+	want := Schedule{Cron: "0 */6 * * *", Timezone: "Europe/Berlin", Jitter: 300}
+	if got := s.GetSchedule(defaults); got != want {
+		t.Errorf("GetSchedule = %+v, want %+v", got, want)
+	}
+
+	// explicit local time and no jitter override the defaults
+	var cfg Config
+	err := yaml.Unmarshal([]byte(`
+servers:
+  - name: ark
+    schedule_timezone: ""
+    schedule_jitter: 0
+`), &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = Schedule{Cron: "@daily"}
+	if got := cfg.Servers[0].GetSchedule(defaults); got != want {
+		t.Errorf("GetSchedule = %+v, want %+v", got, want)
+	}
+}
+
+func TestGetHooks(t *testing.T) {
+	var cfg Config
+	err := yaml.Unmarshal([]byte(`
+defaults:
+  hooks:
+    pre_backup: ./announce.sh
+    on_failure: [./page.sh, ./log.sh]
+    timeout: 60
+servers:
+  - name: ark
+    hooks:
+      pre_backup: []
+      post_backup: ./copy.sh
+`), &cfg)
+	if err != nil {
+		t.Fatalf("failed to parse yaml: %v", err)
+	}
+
+	h := cfg.Servers[0].GetHooks(cfg.Defaults)
+	if len(h.PreBackup) != 0 {
+		t.Errorf("PreBackup = %q, want the server's empty list", h.PreBackup)
+	}
+	if len(h.PostBackup) != 1 || h.PostBackup[0] != "./copy.sh" {
+		t.Errorf("PostBackup = %q, want [./copy.sh]", h.PostBackup)
+	}
+	if len(h.OnFailure) != 2 || h.GetTimeout() != 60 {
+		t.Errorf("hooks = %+v, want default on_failure and timeout", h)
+	}
+}
+
 func TestConfigParsing(t *testing.T) {
 	yamlData := `
 defaults:
@@ -51,3 +160,28 @@ servers:
 		t.Errorf("expected connection type ftp, got %s", cfg.Servers[0].Connection.Type)
 	}
 }
+
+func TestConnectionDecodeOptions(t *testing.T) {
+	var conn Connection
+	err := yaml.Unmarshal([]byte(`type: ftp
+host: game.example.com
+remote_path: /saves
+passwrd: secret
+`), &conn)
+	if err != nil {
+		t.Fatalf("failed to parse yaml: %v", err)
+	}
+
+	var opts struct {
+		Host     string `yaml:"host"`
+		Password string `yaml:"password,omitempty"`
+	}
+	err = conn.DecodeOptions(&opts)
+	if err == nil || !strings.Contains(err.Error(), `line 4: unknown setting "passwrd"`) {
+		t.Fatalf("err = %v, want the typo reported", err)
+	}
+	// the lenient Decode still picks out what it needs
+	if err := conn.Decode(&opts); err != nil || opts.Host != "game.example.com" {
+		t.Errorf("Decode = %v, host %q", err, opts.Host)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"io"
 	"time"
+
+	"github.com/devtheops/gsbt/internal/throttle"
 )
 
 // FileInfo represents a remote file
@@ -36,24 +38,92 @@ type Connector interface {
 	Name() string
 }
 
-// Config holds common connector configuration
+// ResumableDownloader is implemented by connectors that can start a
+// download part way into a file, so an interrupted transfer continues from
+// the bytes already received
+type ResumableDownloader interface {
+	// DownloadFrom writes remotePath to w, skipping the first offset bytes
+	DownloadFrom(ctx context.Context, remotePath string, offset int64, w io.Writer) error
+}
+
+// DeltaSyncer is implemented by connectors that can update a local copy of
+// the remote files in place, transferring only what changed
+type DeltaSyncer interface {
+	// SyncTo brings localDir in line with files. It returns false without
+	// touching localDir if delta transfer is unavailable, in which case
+	// the caller downloads the files instead.
+	SyncTo(ctx context.Context, files []FileInfo, localDir string) (bool, error)
+}
+
+// skipWriter drops the first n bytes written to it, for resuming from
+// sources that can only be read from the start
+type skipWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (s *skipWriter) Write(p []byte) (int, error) {
+	total := len(p)
+	if s.n > 0 {
+		skip := min(s.n, int64(len(p)))
+		s.n -= skip
+		p = p[skip:]
+	}
+	if len(p) > 0 {
+		if _, err := s.w.Write(p); err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
+
+// Config holds common connector configuration. Connector-specific settings
+// live in Options, whose concrete type is chosen by the registration for Type.
 type Config struct {
 	Type       string
-	Host       string
-	Port       int
-	Username   string
-	Password   string
-	KeyFile    string
-	Passive    bool
-	TLS        bool
-	APIKey     string
-	ServiceID  string
 	RemotePath string
 	Include    []string
 	Exclude    []string
+	Options    Options
 
 	// Retry settings
 	RetryAttempts int
 	RetryDelay    int
 	RetryBackoff  bool
+
+	// Bandwidth throttles uploads and transfers the connector runs outside
+	// of Download, such as rsync; empty means unlimited
+	Bandwidth throttle.Limiter
+
+	// ConnectTimeout bounds dialing (DefaultConnectTimeout if zero)
+	ConnectTimeout time.Duration
+
+	// Log receives diagnostic output, such as an exec plugin's stderr, one
+	// line at a time (discarded if nil)
+	Log func(line string)
+}
+
+// DefaultConnectTimeout is used when Config.ConnectTimeout is not set
+const DefaultConnectTimeout = 30 * time.Second
+
+func (c Config) connectTimeout() time.Duration {
+	if c.ConnectTimeout > 0 {
+		return c.ConnectTimeout
+	}
+	return DefaultConnectTimeout
+}
+
+func (c Config) log(line string) {
+	if c.Log != nil {
+		c.Log(line)
+	}
+}
+
+// contextErr reports the context's error for an operation that failed
+// because ctx aborted it, rather than the I/O error that followed
+func contextErr(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
 }
@@ -0,0 +1,428 @@
+// internal/connector/exec.go
+package connector
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devtheops/gsbt/internal/log"
+)
+
+// execPluginPrefix is prepended to the plugin name to find the executable on $PATH
+const execPluginPrefix = "gsbt-connector-"
+
+// execProtocolVersion is sent with connect so plugins can reject incompatible hosts
+const execProtocolVersion = 1
+
+// execChunkSize is the amount of raw data carried per upload message
+const execChunkSize = 64 * 1024
+
+func init() {
+	Register(Registration{
+		Type:       "exec",
+		NewOptions: func() Options { return &ExecOptions{} },
+		New: func(cfg Config) (Connector, error) {
+			return NewExecConnector(cfg), nil
+		},
+	})
+}
+
+// ExecOptions holds settings for an external connector plugin
+type ExecOptions struct {
+	Plugin   string            `yaml:"plugin"`
+	Host     string            `yaml:"host,omitempty"`
+	Port     int               `yaml:"port,omitempty"`
+	Username string            `yaml:"username,omitempty"`
+	Password string            `yaml:"password,omitempty"`
+	Options  map[string]string `yaml:"options,omitempty"`
+}
+
+// Validate checks required exec settings
+func (o *ExecOptions) Validate() error {
+	if o.Plugin == "" {
+		return fmt.Errorf("connection.plugin is required")
+	}
+	return nil
+}
+
+// ExecConnector implements Connector by delegating to an external executable
+// that speaks line-delimited JSON over stdin/stdout (see docs/exec-connectors.md).
+type ExecConnector struct {
+	config Config
+	opts   *ExecOptions
+
+	cmd    *exec.Cmd
+	stderr *log.LineWriter
+	stdin  io.WriteCloser
+	enc    *json.Encoder
+	dec    *json.Decoder
+	nextID int
+	mu     sync.Mutex
+
+	// resume is set when the plugin accepts a download offset
+	resume bool
+}
+
+// execRequest is a message sent from gsbt to the plugin
+type execRequest struct {
+	ID     int         `json:"id"`
+	Method string      `json:"method,omitempty"`
+	Params interface{} `json:"params,omitempty"`
+	Data   []byte      `json:"data,omitempty"`
+	EOF    bool        `json:"eof,omitempty"`
+}
+
+// execResponse is a message sent from the plugin to gsbt
+type execResponse struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	Data   []byte          `json:"data,omitempty"`
+}
+
+type execConnectParams struct {
+	Version    int               `json:"version"`
+	Host       string            `json:"host,omitempty"`
+	Port       int               `json:"port,omitempty"`
+	Username   string            `json:"username,omitempty"`
+	Password   string            `json:"password,omitempty"`
+	RemotePath string            `json:"remote_path"`
+	Options    map[string]string `json:"options,omitempty"`
+}
+
+type execConnectResult struct {
+	Resume bool `json:"resume"`
+}
+
+type execPathParams struct {
+	Path string `json:"path"`
+}
+
+type execDownloadParams struct {
+	Path   string `json:"path"`
+	Offset int64  `json:"offset,omitempty"`
+}
+
+type execFileInfo struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	IsDir   bool      `json:"is_dir"`
+}
+
+type execListResult struct {
+	Files []execFileInfo `json:"files"`
+}
+
+// NewExecConnector creates a new exec connector
+func NewExecConnector(cfg Config) *ExecConnector {
+	return &ExecConnector{config: cfg, opts: optionsAs[ExecOptions](cfg)}
+}
+
+// Name returns the connector name for logging
+func (e *ExecConnector) Name() string {
+	if e.opts.Host != "" {
+		return fmt.Sprintf("exec://%s/%s", e.opts.Plugin, e.opts.Host)
+	}
+	return fmt.Sprintf("exec://%s", e.opts.Plugin)
+}
+
+// pluginPath resolves the plugin executable. Names containing a path
+// separator are used as-is, anything else is looked up as gsbt-connector-<name>.
+func (e *ExecConnector) pluginPath() (string, error) {
+	if e.opts.Plugin == "" {
+		return "", fmt.Errorf("plugin is required for exec connector")
+	}
+	if strings.ContainsRune(e.opts.Plugin, os.PathSeparator) || strings.Contains(e.opts.Plugin, "/") {
+		return e.opts.Plugin, nil
+	}
+	p, err := exec.LookPath(execPluginPrefix + e.opts.Plugin)
+	if err != nil {
+		return "", fmt.Errorf("connector plugin %s%s not found: %w", execPluginPrefix, e.opts.Plugin, err)
+	}
+	return p, nil
+}
+
+// Connect starts the plugin process and sends the connect request
+func (e *ExecConnector) Connect(ctx context.Context) error {
+	bin, err := e.pluginPath()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(bin)
+	stderr := log.NewLineWriter(e.config.log)
+	cmd.Stderr = stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to start plugin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to start plugin: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start plugin: %w", err)
+	}
+
+	e.cmd = cmd
+	e.stderr = stderr
+	e.stdin = stdin
+	e.enc = json.NewEncoder(stdin)
+	e.dec = json.NewDecoder(bufio.NewReader(stdout))
+
+	params := execConnectParams{
+		Version:    execProtocolVersion,
+		Host:       e.opts.Host,
+		Port:       e.opts.Port,
+		Username:   e.opts.Username,
+		Password:   e.opts.Password,
+		RemotePath: e.config.RemotePath,
+		Options:    e.opts.Options,
+	}
+	var result execConnectResult
+	if err := e.call(ctx, "connect", params, &result); err != nil {
+		e.kill()
+		e.wait()
+		e.cmd = nil
+		return fmt.Errorf("plugin connect failed: %w", err)
+	}
+	e.resume = result.Resume
+
+	return nil
+}
+
+// List returns files at remote_path matching include/exclude patterns
+func (e *ExecConnector) List(ctx context.Context) ([]FileInfo, error) {
+	if e.cmd == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	var result execListResult
+	if err := e.call(ctx, "list", nil, &result); err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", e.config.RemotePath, err)
+	}
+
+	var filtered []FileInfo
+	for _, f := range result.Files {
+		if f.IsDir {
+			continue
+		}
+		if MatchesPatterns(f.Path, e.config.Include, e.config.Exclude) {
+			filtered = append(filtered, FileInfo{
+				Path:    f.Path,
+				Size:    f.Size,
+				ModTime: f.ModTime,
+			})
+		}
+	}
+
+	return filtered, nil
+}
+
+// Download streams a file from the plugin as a sequence of data messages
+func (e *ExecConnector) Download(ctx context.Context, remotePath string, w io.Writer) error {
+	return e.DownloadFrom(ctx, remotePath, 0, w)
+}
+
+// DownloadFrom streams a file from offset on. Plugins that did not announce
+// resume support send the whole file and the first offset bytes are skipped.
+func (e *ExecConnector) DownloadFrom(ctx context.Context, remotePath string, offset int64, w io.Writer) error {
+	if e.cmd == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	defer e.watch(ctx)()
+
+	params := execDownloadParams{Path: remotePath}
+	if e.resume {
+		params.Offset = offset
+	} else if offset > 0 {
+		w = &skipWriter{w: w, n: offset}
+	}
+	id, err := e.send("download", params)
+	if err != nil {
+		return e.wrapErr(ctx, err)
+	}
+
+	for {
+		resp, err := e.recv(id)
+		if err != nil {
+			return e.wrapErr(ctx, err)
+		}
+		if resp.Error != "" {
+			return fmt.Errorf("failed to download %s: %s", remotePath, resp.Error)
+		}
+		if resp.Data != nil {
+			if _, err := w.Write(resp.Data); err != nil {
+				// The plugin keeps streaming; the process can't be reused
+				e.kill()
+				return err
+			}
+			continue
+		}
+		if resp.Result != nil {
+			return nil
+		}
+	}
+}
+
+// Upload streams r to the plugin as data messages terminated by an eof message
+func (e *ExecConnector) Upload(ctx context.Context, r io.Reader, remotePath string) error {
+	if e.cmd == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	defer e.watch(ctx)()
+
+	r = e.config.Bandwidth.Reader(ctx, r)
+	id, err := e.send("upload", execPathParams{Path: remotePath})
+	if err != nil {
+		return e.wrapErr(ctx, err)
+	}
+
+	buf := make([]byte, execChunkSize)
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			if err := e.enc.Encode(execRequest{ID: id, Data: buf[:n]}); err != nil {
+				return e.wrapErr(ctx, err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			e.kill()
+			return readErr
+		}
+	}
+
+	if err := e.enc.Encode(execRequest{ID: id, EOF: true}); err != nil {
+		return e.wrapErr(ctx, err)
+	}
+
+	resp, err := e.recv(id)
+	if err != nil {
+		return e.wrapErr(ctx, err)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("failed to upload %s: %s", remotePath, resp.Error)
+	}
+	return nil
+}
+
+// Close asks the plugin to disconnect and waits for it to exit
+func (e *ExecConnector) Close() error {
+	if e.cmd == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := e.call(ctx, "close", nil, nil)
+	e.stdin.Close()
+
+	done := make(chan struct{})
+	go func() {
+		e.wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		e.kill()
+		<-done
+	}
+
+	e.cmd = nil
+	return err
+}
+
+// wait reaps the plugin process and logs the rest of its stderr
+func (e *ExecConnector) wait() {
+	e.cmd.Wait()
+	e.stderr.Flush()
+}
+
+// call sends a request and decodes the final result into out (if non-nil)
+func (e *ExecConnector) call(ctx context.Context, method string, params interface{}, out interface{}) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	defer e.watch(ctx)()
+
+	id, err := e.send(method, params)
+	if err != nil {
+		return e.wrapErr(ctx, err)
+	}
+
+	resp, err := e.recv(id)
+	if err != nil {
+		return e.wrapErr(ctx, err)
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	if out != nil && resp.Result != nil {
+		if err := json.Unmarshal(resp.Result, out); err != nil {
+			return fmt.Errorf("invalid %s result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+func (e *ExecConnector) send(method string, params interface{}) (int, error) {
+	e.nextID++
+	id := e.nextID
+	return id, e.enc.Encode(execRequest{ID: id, Method: method, Params: params})
+}
+
+// recv reads the next message, which must belong to request id
+func (e *ExecConnector) recv(id int) (execResponse, error) {
+	var resp execResponse
+	if err := e.dec.Decode(&resp); err != nil {
+		if errors.Is(err, io.EOF) {
+			return resp, fmt.Errorf("plugin exited unexpectedly")
+		}
+		return resp, fmt.Errorf("invalid plugin message: %w", err)
+	}
+	if resp.ID != id {
+		return resp, fmt.Errorf("plugin replied to request %d, expected %d", resp.ID, id)
+	}
+	return resp, nil
+}
+
+// watch kills the plugin if ctx is cancelled while a request is in flight,
+// which unblocks any pending read. The returned func stops the watcher.
+func (e *ExecConnector) watch(ctx context.Context) func() {
+	stop := context.AfterFunc(ctx, e.kill)
+	return func() { stop() }
+}
+
+func (e *ExecConnector) wrapErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func (e *ExecConnector) kill() {
+	if e.cmd != nil && e.cmd.Process != nil {
+		e.cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,268 @@
+// internal/connector/exec_test.go
+package connector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestExecHelperProcess is not a real test: it is re-executed as a fake
+// gsbt-connector-* plugin by installFakePlugin.
+func TestExecHelperProcess(t *testing.T) {
+	if os.Getenv("GSBT_EXEC_HELPER") != "1" {
+		return
+	}
+	runFakePlugin()
+	os.Exit(0)
+}
+
+// runFakePlugin implements the exec protocol over an in-memory file tree.
+func runFakePlugin() {
+	files := map[string]string{
+		"world.sav":       "world-data",
+		"players/1.json":  strings.Repeat("p", 100_000),
+		"logs/server.log": "log",
+	}
+	uploads := map[string]*bytes.Buffer{}
+	connected := false
+
+	dec := json.NewDecoder(bufio.NewReader(os.Stdin))
+	enc := json.NewEncoder(os.Stdout)
+	reply := func(id int, result interface{}) { enc.Encode(map[string]interface{}{"id": id, "result": result}) }
+	fail := func(id int, msg string) { enc.Encode(map[string]interface{}{"id": id, "error": msg}) }
+
+	for {
+		var req struct {
+			ID     int             `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+			Data   []byte          `json:"data"`
+			EOF    bool            `json:"eof"`
+		}
+		if err := dec.Decode(&req); err != nil {
+			return
+		}
+
+		var params struct {
+			Version  int               `json:"version"`
+			Password string            `json:"password"`
+			Path     string            `json:"path"`
+			Offset   int               `json:"offset"`
+			Options  map[string]string `json:"options"`
+		}
+		json.Unmarshal(req.Params, &params)
+
+		switch req.Method {
+		case "connect":
+			if params.Password != "secret" || params.Version != 1 || params.Options["panel"] != "acme" {
+				fmt.Fprintf(os.Stderr, "login rejected\nfor %q", params.Password)
+				fail(req.ID, "bad credentials")
+				continue
+			}
+			connected = true
+			reply(req.ID, map[string]interface{}{"resume": params.Options["resume"] == "yes"})
+		case "list":
+			if !connected {
+				fail(req.ID, "not connected")
+				continue
+			}
+			var list []map[string]interface{}
+			list = append(list, map[string]interface{}{"path": "players", "is_dir": true})
+			for p, data := range files {
+				list = append(list, map[string]interface{}{
+					"path":     p,
+					"size":     len(data),
+					"mod_time": time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+				})
+			}
+			reply(req.ID, map[string]interface{}{"files": list})
+		case "download":
+			data, ok := files[params.Path]
+			if !ok {
+				fail(req.ID, "no such file")
+				continue
+			}
+			data = data[min(params.Offset, len(data)):]
+			for len(data) > 0 {
+				n := min(len(data), 4096)
+				enc.Encode(map[string]interface{}{"id": req.ID, "data": []byte(data[:n])})
+				data = data[n:]
+			}
+			reply(req.ID, map[string]interface{}{})
+		case "upload":
+			buf := &bytes.Buffer{}
+			uploads[params.Path] = buf
+			for {
+				var chunk struct {
+					Data []byte `json:"data"`
+					EOF  bool   `json:"eof"`
+				}
+				if err := dec.Decode(&chunk); err != nil {
+					return
+				}
+				if chunk.EOF {
+					break
+				}
+				buf.Write(chunk.Data)
+			}
+			files[params.Path] = buf.String()
+			reply(req.ID, map[string]interface{}{"bytes": buf.Len()})
+		case "close":
+			reply(req.ID, map[string]interface{}{})
+			return
+		default:
+			fail(req.ID, fmt.Sprintf("unknown method %s", req.Method))
+		}
+	}
+}
+
+// installFakePlugin puts a gsbt-connector-<name> wrapper script on $PATH that
+// re-executes the test binary as the fake plugin.
+func installFakePlugin(t *testing.T, name string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell wrapper not supported on windows")
+	}
+
+	dir := t.TempDir()
+	script := fmt.Sprintf("#!/bin/sh\nGSBT_EXEC_HELPER=1 exec %q -test.run=TestExecHelperProcess\n", os.Args[0])
+	if err := os.WriteFile(filepath.Join(dir, execPluginPrefix+name), []byte(script), 0o755); err != nil {
+		t.Fatalf("write plugin: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestExecConnector(t *testing.T) {
+	installFakePlugin(t, "fake")
+	ctx := context.Background()
+
+	conn := NewExecConnector(Config{
+		Type:       "exec",
+		RemotePath: "/saves",
+		Exclude:    []string{"logs/"},
+		Options: &ExecOptions{
+			Plugin:   "fake",
+			Password: "secret",
+			Options:  map[string]string{"panel": "acme"},
+		},
+	})
+	if conn.Name() != "exec://fake" {
+		t.Errorf("unexpected name: %s", conn.Name())
+	}
+
+	if err := conn.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+
+	files, err := conn.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files after filtering, got %d: %+v", len(files), files)
+	}
+	for _, f := range files {
+		if f.IsDir || strings.HasPrefix(f.Path, "logs/") {
+			t.Errorf("unexpected file in listing: %+v", f)
+		}
+		if !f.ModTime.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+			t.Errorf("unexpected mod time for %s: %v", f.Path, f.ModTime)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := conn.Download(ctx, "players/1.json", &buf); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if buf.Len() != 100_000 {
+		t.Errorf("downloaded %d bytes, want 100000", buf.Len())
+	}
+
+	if err := conn.Download(ctx, "missing", &buf); err == nil {
+		t.Error("expected error downloading missing file")
+	}
+
+	payload := strings.Repeat("u", execChunkSize*2+10)
+	if err := conn.Upload(ctx, strings.NewReader(payload), "restored.sav"); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+
+	buf.Reset()
+	if err := conn.Download(ctx, "restored.sav", &buf); err != nil {
+		t.Fatalf("Download uploaded file: %v", err)
+	}
+	if buf.String() != payload {
+		t.Errorf("round-tripped upload mismatch (%d bytes)", buf.Len())
+	}
+
+	if err := conn.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
+
+func TestExecConnectorConnectError(t *testing.T) {
+	installFakePlugin(t, "fake")
+
+	var lines []string
+	conn := NewExecConnector(Config{
+		Type:    "exec",
+		Options: &ExecOptions{Plugin: "fake", Password: "wrong"},
+		Log:     func(line string) { lines = append(lines, line) },
+	})
+	err := conn.Connect(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "bad credentials") {
+		t.Fatalf("expected plugin error, got %v", err)
+	}
+	// The plugin was reaped, so its stderr is logged in full
+	if conn.cmd != nil || strings.Join(lines, "|") != `login rejected|for "wrong"` {
+		t.Errorf("plugin stderr = %q, cmd = %v", lines, conn.cmd)
+	}
+}
+
+func TestExecConnectorPluginNotFound(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	conn := NewExecConnector(Config{Type: "exec", Options: &ExecOptions{Plugin: "missing"}})
+	err := conn.Connect(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "gsbt-connector-missing") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestExecConnectorDownloadFrom(t *testing.T) {
+	installFakePlugin(t, "fake")
+	want := strings.Repeat("p", 100_000)[60_000:]
+
+	for _, resume := range []string{"yes", "no"} {
+		t.Run("resume="+resume, func(t *testing.T) {
+			conn := NewExecConnector(Config{Type: "exec", Options: &ExecOptions{
+				Plugin:   "fake",
+				Password: "secret",
+				Options:  map[string]string{"panel": "acme", "resume": resume},
+			}})
+			ctx := context.Background()
+			if err := conn.Connect(ctx); err != nil {
+				t.Fatalf("Connect: %v", err)
+			}
+			defer conn.Close()
+
+			var buf bytes.Buffer
+			if err := conn.DownloadFrom(ctx, "players/1.json", 60_000, &buf); err != nil {
+				t.Fatalf("DownloadFrom: %v", err)
+			}
+			if buf.String() != want {
+				t.Errorf("got %d bytes, want %d", buf.Len(), len(want))
+			}
+		})
+	}
+}
@@ -3,16 +3,15 @@ package connector
 
 import "fmt"
 
-// NewConnector instantiates the correct connector implementation based on cfg.Type.
+// NewConnector instantiates the registered connector implementation for cfg.Type.
+// A nil cfg.Options is replaced by the connector's zero options.
 func NewConnector(cfg Config) (Connector, error) {
-	switch cfg.Type {
-	case "ftp":
-		return NewFTPConnector(cfg), nil
-	case "sftp":
-		return NewSFTPConnector(cfg), nil
-	case "nitrado":
-		return NewNitradoConnector(cfg), nil
-	default:
+	reg, ok := Lookup(cfg.Type)
+	if !ok {
 		return nil, fmt.Errorf("unsupported connector type: %s", cfg.Type)
 	}
+	if cfg.Options == nil {
+		cfg.Options = reg.NewOptions()
+	}
+	return reg.New(cfg)
 }
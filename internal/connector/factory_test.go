@@ -16,6 +16,9 @@ func TestNewConnector(t *testing.T) {
 		{"ftp", "ftp", "*connector.FTPConnector", false},
 		{"sftp", "sftp", "*connector.SFTPConnector", false},
 		{"nitrado", "nitrado", "*connector.NitradoConnector", false},
+		{"exec", "exec", "*connector.ExecConnector", false},
+		{"webdav", "webdav", "*connector.WebDAVConnector", false},
+		{"smb", "smb", "*connector.SMBConnector", false},
 		{"unknown", "unknown", "", true},
 	}
 
@@ -42,3 +45,37 @@ func TestNewConnector(t *testing.T) {
 		})
 	}
 }
+
+func TestRegistry(t *testing.T) {
+	types := Types()
+	for _, want := range []string{"exec", "ftp", "nitrado", "sftp", "smb", "webdav"} {
+		if _, ok := Lookup(want); !ok {
+			t.Errorf("connector %s not registered (have %v)", want, types)
+		}
+	}
+
+	reg, _ := Lookup("ftp")
+	if _, ok := reg.NewOptions().(*FTPOptions); !ok {
+		t.Errorf("ftp options type = %T, want *FTPOptions", reg.NewOptions())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic on duplicate registration")
+		}
+	}()
+	Register(reg)
+}
+
+func TestNewConnectorUsesOptions(t *testing.T) {
+	conn, err := NewConnector(Config{
+		Type:    "ftp",
+		Options: &FTPOptions{Host: "files.example.com", Port: 2121},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if conn.Name() != "ftp://files.example.com:2121" {
+		t.Errorf("unexpected name: %s", conn.Name())
+	}
+}
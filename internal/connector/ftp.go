@@ -3,51 +3,184 @@ package connector
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/textproto"
 	"path"
-	"time"
+	"strconv"
+	"strings"
 
-	"github.com/jlaffaye/ftp"
+	"github.com/devtheops/gsbt/internal/ftp"
 )
 
+func init() {
+	Register(Registration{
+		Type:       "ftp",
+		NewOptions: func() Options { return &FTPOptions{} },
+		New: func(cfg Config) (Connector, error) {
+			return NewFTPConnector(cfg), nil
+		},
+	})
+}
+
+// FTP TLS modes
+const (
+	FTPTLSExplicit = "explicit" // AUTH TLS on the plain control port
+	FTPTLSImplicit = "implicit" // TLS from the first byte, usually port 990
+)
+
+// FTPOptions holds FTP connection settings
+type FTPOptions struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port,omitempty"`
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	Passive  *bool  `yaml:"passive,omitempty"`
+
+	// PassiveMode forces EPSV or PASV; by default EPSV is tried first
+	PassiveMode string `yaml:"passive_mode,omitempty" jsonschema:"enum=epsv,enum=pasv"`
+
+	// Active mode (passive: false) settings: local port range such as
+	// "50000-50100" and the IP to announce when behind NAT
+	ActivePortRange string `yaml:"active_port_range,omitempty"`
+	ActiveAddress   string `yaml:"active_address,omitempty"`
+
+	// TLS enables explicit FTPS; kept for compatibility with tls_mode
+	TLS                   bool   `yaml:"tls,omitempty"`
+	TLSMode               string `yaml:"tls_mode,omitempty" jsonschema:"enum=explicit,enum=implicit"`
+	TLSCAFile             string `yaml:"tls_ca_file,omitempty"`
+	TLSServerName         string `yaml:"tls_server_name,omitempty"`
+	TLSFingerprint        string `yaml:"tls_fingerprint,omitempty"`
+	TLSInsecureSkipVerify bool   `yaml:"tls_insecure_skip_verify,omitempty"`
+	TLSCertFile           string `yaml:"tls_cert_file,omitempty"`
+	TLSKeyFile            string `yaml:"tls_key_file,omitempty"`
+}
+
+// Validate checks required FTP settings
+func (o *FTPOptions) Validate() error {
+	if o.Host == "" {
+		return fmt.Errorf("connection.host is required")
+	}
+	switch o.TLSMode {
+	case "", FTPTLSExplicit, FTPTLSImplicit:
+	default:
+		return fmt.Errorf("connection.tls_mode must be explicit or implicit, got %q", o.TLSMode)
+	}
+	switch ftp.PassiveMode(o.PassiveMode) {
+	case ftp.PassiveAuto, ftp.PassiveEPSV, ftp.PassivePASV:
+	default:
+		return fmt.Errorf("connection.passive_mode must be epsv or pasv, got %q", o.PassiveMode)
+	}
+	if _, _, err := o.activePorts(); err != nil {
+		return err
+	}
+	if o.ActiveAddress != "" && net.ParseIP(o.ActiveAddress) == nil {
+		return fmt.Errorf("connection.active_address must be an IP address, got %q", o.ActiveAddress)
+	}
+	if (o.TLSCertFile == "") != (o.TLSKeyFile == "") {
+		return fmt.Errorf("connection.tls_cert_file and connection.tls_key_file must be set together")
+	}
+	if o.TLSFingerprint != "" {
+		if _, err := parseFingerprint(o.TLSFingerprint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tlsMode returns the effective TLS mode, or "" for plain FTP
+func (o *FTPOptions) tlsMode() string {
+	if o.TLSMode != "" {
+		return o.TLSMode
+	}
+	if o.TLS {
+		return FTPTLSExplicit
+	}
+	return ""
+}
+
+// activePorts parses active_port_range ("min-max"); zero means any port
+func (o *FTPOptions) activePorts() (int, int, error) {
+	if o.ActivePortRange == "" {
+		return 0, 0, nil
+	}
+	lo, hi, _ := strings.Cut(o.ActivePortRange, "-")
+	min, err1 := strconv.Atoi(strings.TrimSpace(lo))
+	max, err2 := strconv.Atoi(strings.TrimSpace(hi))
+	if err1 != nil || err2 != nil || min < 1 || max > 65535 || min > max {
+		return 0, 0, fmt.Errorf("connection.active_port_range must look like 50000-50100, got %q", o.ActivePortRange)
+	}
+	return min, max, nil
+}
+
+// IsPassive returns passive mode setting (default true)
+func (o *FTPOptions) IsPassive() bool {
+	if o.Passive != nil {
+		return *o.Passive
+	}
+	return true
+}
+
 // FTPConnector implements Connector for FTP servers
 type FTPConnector struct {
 	config Config
-	conn   *ftp.ServerConn
+	opts   *FTPOptions
+	conn   *ftp.Conn
 }
 
 // NewFTPConnector creates a new FTP connector
 func NewFTPConnector(cfg Config) *FTPConnector {
-	if cfg.Port == 0 {
-		cfg.Port = 21
+	opts := *optionsAs[FTPOptions](cfg)
+	if opts.Port == 0 {
+		opts.Port = 21
+		if opts.tlsMode() == FTPTLSImplicit {
+			opts.Port = 990
+		}
 	}
-	return &FTPConnector{config: cfg}
+	cfg.Options = &opts
+	return &FTPConnector{config: cfg, opts: &opts}
 }
 
 // Name returns the connector name for logging
 func (f *FTPConnector) Name() string {
-	return fmt.Sprintf("ftp://%s:%d", f.config.Host, f.config.Port)
+	return fmt.Sprintf("ftp://%s:%d", f.opts.Host, f.opts.Port)
 }
 
 // Connect establishes FTP connection
 func (f *FTPConnector) Connect(ctx context.Context) error {
-	addr := fmt.Sprintf("%s:%d", f.config.Host, f.config.Port)
+	min, max, err := f.opts.activePorts()
+	if err != nil {
+		return err
+	}
 
-	var opts []ftp.DialOption
-	opts = append(opts, ftp.DialWithContext(ctx))
-	opts = append(opts, ftp.DialWithTimeout(30*time.Second))
+	cfg := ftp.Config{
+		Address:       net.JoinHostPort(f.opts.Host, strconv.Itoa(f.opts.Port)),
+		Active:        !f.opts.IsPassive(),
+		PassiveMode:   ftp.PassiveMode(f.opts.PassiveMode),
+		ActivePortMin: min,
+		ActivePortMax: max,
+		ActiveAddress: f.opts.ActiveAddress,
+		Timeout:       f.config.connectTimeout(),
+	}
 
-	if f.config.TLS {
-		opts = append(opts, ftp.DialWithExplicitTLS(nil))
+	switch f.opts.tlsMode() {
+	case FTPTLSExplicit, FTPTLSImplicit:
+		tlsConfig, err := f.opts.tlsConfig()
+		if err != nil {
+			return err
+		}
+		cfg.TLSConfig = tlsConfig
+		cfg.ImplicitTLS = f.opts.tlsMode() == FTPTLSImplicit
 	}
 
-	conn, err := ftp.Dial(addr, opts...)
+	conn, err := ftp.Dial(ctx, cfg)
 	if err != nil {
 		return fmt.Errorf("failed to connect to FTP: %w", err)
 	}
 
-	if err := conn.Login(f.config.Username, f.config.Password); err != nil {
+	if err := conn.Login(ctx, f.opts.Username, f.opts.Password); err != nil {
 		conn.Quit()
 		return fmt.Errorf("FTP login failed: %w", err)
 	}
@@ -63,9 +196,9 @@ func (f *FTPConnector) List(ctx context.Context) ([]FileInfo, error) {
 	}
 
 	var files []FileInfo
-	err := f.walkDir(f.config.RemotePath, &files)
+	err := f.walkDir(ctx, f.config.RemotePath, &files)
 	if err != nil {
-		return nil, err
+		return nil, contextErr(ctx, err)
 	}
 
 	// Filter by patterns
@@ -82,8 +215,8 @@ func (f *FTPConnector) List(ctx context.Context) ([]FileInfo, error) {
 	return filtered, nil
 }
 
-func (f *FTPConnector) walkDir(dir string, files *[]FileInfo) error {
-	entries, err := f.conn.List(dir)
+func (f *FTPConnector) walkDir(ctx context.Context, dir string, files *[]FileInfo) error {
+	entries, err := f.conn.List(ctx, dir)
 	if err != nil {
 		return fmt.Errorf("failed to list %s: %w", dir, err)
 	}
@@ -104,14 +237,14 @@ func (f *FTPConnector) walkDir(dir string, files *[]FileInfo) error {
 
 		info := FileInfo{
 			Path:    relPath,
-			Size:    int64(entry.Size),
-			ModTime: entry.Time,
-			IsDir:   entry.Type == ftp.EntryTypeFolder,
+			Size:    entry.Size,
+			ModTime: entry.ModTime,
+			IsDir:   entry.IsDir,
 		}
 		*files = append(*files, info)
 
-		if entry.Type == ftp.EntryTypeFolder {
-			if err := f.walkDir(fullPath, files); err != nil {
+		if entry.IsDir {
+			if err := f.walkDir(ctx, fullPath, files); err != nil {
 				return err
 			}
 		}
@@ -122,19 +255,36 @@ func (f *FTPConnector) walkDir(dir string, files *[]FileInfo) error {
 
 // Download retrieves a file from FTP
 func (f *FTPConnector) Download(ctx context.Context, remotePath string, w io.Writer) error {
+	return f.DownloadFrom(ctx, remotePath, 0, w)
+}
+
+// DownloadFrom retrieves a file from offset on using REST. Servers that
+// reject REST send the whole file and the first offset bytes are skipped.
+func (f *FTPConnector) DownloadFrom(ctx context.Context, remotePath string, offset int64, w io.Writer) error {
 	if f.conn == nil {
 		return fmt.Errorf("not connected")
 	}
 
+	// The client only watches ctx while it talks to the server, not while
+	// the file streams in
+	defer context.AfterFunc(ctx, f.conn.Interrupt)()
+
 	fullPath := path.Join(f.config.RemotePath, remotePath)
-	resp, err := f.conn.Retr(fullPath)
+	resp, err := f.conn.Retr(ctx, fullPath, offset)
+	var protoErr *textproto.Error
+	if offset > 0 && errors.As(err, &protoErr) && protoErr.Code >= 500 && protoErr.Code < 550 {
+		w = &skipWriter{w: w, n: offset}
+		resp, err = f.conn.Retr(ctx, fullPath, 0)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to download %s: %w", remotePath, err)
+		return contextErr(ctx, fmt.Errorf("failed to download %s: %w", remotePath, err))
 	}
-	defer resp.Close()
 
 	_, err = io.Copy(w, resp)
-	return err
+	if closeErr := resp.Close(); err == nil {
+		err = closeErr
+	}
+	return contextErr(ctx, err)
 }
 
 // Upload sends a file to FTP
@@ -147,9 +297,11 @@ func (f *FTPConnector) Upload(ctx context.Context, r io.Reader, remotePath strin
 
 	// Ensure parent directory exists
 	dir := path.Dir(fullPath)
-	f.conn.MakeDir(dir) // Ignore error, may already exist
+	f.conn.MakeDir(ctx, dir) // Ignore error, may already exist
 
-	return f.conn.Stor(fullPath, r)
+	// Like downloads, the data stream is only bounded by ctx
+	defer context.AfterFunc(ctx, f.conn.Interrupt)()
+	return contextErr(ctx, f.conn.Stor(ctx, fullPath, f.config.Bandwidth.Reader(ctx, r)))
 }
 
 // Close terminates the FTP connection
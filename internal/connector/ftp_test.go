@@ -2,16 +2,31 @@
 package connector
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/devtheops/gsbt/internal/throttle"
 )
 
 func TestNewFTPConnector(t *testing.T) {
 	cfg := Config{
-		Type:       "ftp",
-		Host:       "localhost",
-		Port:       21,
-		Username:   "user",
-		Password:   "pass",
+		Type: "ftp",
+		Options: &FTPOptions{
+			Host:     "localhost",
+			Port:     21,
+			Username: "user",
+			Password: "pass",
+		},
 		RemotePath: "/saves/",
 		Include:    []string{"*"},
 		Exclude:    []string{"*.log"},
@@ -26,3 +41,434 @@ func TestNewFTPConnector(t *testing.T) {
 		t.Errorf("unexpected name: %s", conn.Name())
 	}
 }
+
+// connectFTPS connects to srv with the given TLS settings and downloads a file
+func connectFTPS(t *testing.T, srv *testFTPServer, opts FTPOptions) error {
+	t.Helper()
+
+	opts.Port = srv.Port()
+	opts.Username = "user"
+	opts.Password = "pass"
+	conn := NewFTPConnector(Config{Type: "ftp", RemotePath: "/", Options: &opts})
+	if err := conn.Connect(context.Background()); err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var buf bytes.Buffer
+	if err := conn.Download(context.Background(), "world.sav", &buf); err != nil {
+		return err
+	}
+	if buf.String() != "world-data" {
+		t.Errorf("downloaded %q, want world-data", buf.String())
+	}
+	return nil
+}
+
+func TestFTPConnectorTLS(t *testing.T) {
+	pki := newTestPKI(t, "127.0.0.1", "ftp.example.com")
+	clientCert, clientKey := pki.issue(t, "client", x509.ExtKeyUsageClientAuth)
+	fingerprint := sha256.Sum256(pki.serverCert.Certificate[0])
+
+	tests := []struct {
+		name     string
+		implicit bool
+		mtls     bool
+		opts     FTPOptions
+		wantErr  string
+	}{
+		{
+			name: "explicit with CA",
+			opts: FTPOptions{Host: "127.0.0.1", TLS: true, TLSCAFile: pki.caFile},
+		},
+		{
+			name:     "implicit with CA",
+			implicit: true,
+			opts:     FTPOptions{Host: "127.0.0.1", TLSMode: FTPTLSImplicit, TLSCAFile: pki.caFile},
+		},
+		{
+			name:    "untrusted certificate",
+			opts:    FTPOptions{Host: "127.0.0.1", TLSMode: FTPTLSExplicit},
+			wantErr: "certificate",
+		},
+		{
+			name: "insecure skip verify",
+			opts: FTPOptions{Host: "127.0.0.1", TLSMode: FTPTLSExplicit, TLSInsecureSkipVerify: true},
+		},
+		{
+			name: "server name override",
+			opts: FTPOptions{Host: "127.0.0.1", TLSMode: FTPTLSExplicit, TLSCAFile: pki.caFile, TLSServerName: "ftp.example.com"},
+		},
+		{
+			name:    "server name mismatch",
+			opts:    FTPOptions{Host: "127.0.0.1", TLSMode: FTPTLSExplicit, TLSCAFile: pki.caFile, TLSServerName: "other.example.com"},
+			wantErr: "other.example.com",
+		},
+		{
+			name: "pinned fingerprint",
+			opts: FTPOptions{Host: "127.0.0.1", TLSMode: FTPTLSExplicit, TLSFingerprint: "SHA256:" + formatFingerprint(fingerprint[:])},
+		},
+		{
+			name:    "wrong fingerprint",
+			opts:    FTPOptions{Host: "127.0.0.1", TLSMode: FTPTLSExplicit, TLSFingerprint: strings.Repeat("ab", 32)},
+			wantErr: "does not match",
+		},
+		{
+			name: "client certificate",
+			mtls: true,
+			opts: FTPOptions{Host: "127.0.0.1", TLSMode: FTPTLSExplicit, TLSCAFile: pki.caFile, TLSCertFile: clientCert, TLSKeyFile: clientKey},
+		},
+		{
+			name:    "missing client certificate",
+			mtls:    true,
+			opts:    FTPOptions{Host: "127.0.0.1", TLSMode: FTPTLSExplicit, TLSCAFile: pki.caFile},
+			wantErr: "certificate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestFTPServer(t, func(s *testFTPServer) {
+				s.tls = pki.serverTLS()
+				s.implicit = tt.implicit
+				if tt.mtls {
+					pool := x509.NewCertPool()
+					pool.AddCert(pki.ca)
+					s.tls.ClientCAs = pool
+					s.tls.ClientAuth = tls.RequireAndVerifyClientCert
+				}
+			})
+			srv.AddFile("/world.sav", "world-data", time.Now())
+
+			err := connectFTPS(t, srv, tt.opts)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestFTPOptionsValidateTLS(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    FTPOptions
+		wantErr bool
+	}{
+		{"plain", FTPOptions{Host: "h"}, false},
+		{"implicit", FTPOptions{Host: "h", TLSMode: "implicit"}, false},
+		{"bad mode", FTPOptions{Host: "h", TLSMode: "starttls"}, true},
+		{"cert without key", FTPOptions{Host: "h", TLSCertFile: "c.pem"}, true},
+		{"bad fingerprint", FTPOptions{Host: "h", TLSFingerprint: "abcd"}, true},
+		{"colon fingerprint", FTPOptions{Host: "h", TLSFingerprint: strings.TrimSuffix(strings.Repeat("AB:", 32), ":")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFTPImplicitDefaultPort(t *testing.T) {
+	conn := NewFTPConnector(Config{Type: "ftp", Options: &FTPOptions{Host: "h", TLSMode: FTPTLSImplicit}})
+	if conn.Name() != "ftp://h:990" {
+		t.Errorf("unexpected name: %s", conn.Name())
+	}
+}
+
+func TestFTPConnectorDataModes(t *testing.T) {
+	passive, active := true, false
+	// LIST omits the year for recent files, so keep the fixture recent
+	modTime := time.Now().UTC().Add(-48 * time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name      string
+		server    func(s *testFTPServer)
+		opts      FTPOptions
+		want      []string // commands that must have been sent
+		forbidden []string // commands that must not have been sent
+		exactTime bool     // listing carries seconds
+	}{
+		{
+			name:      "default passive uses EPSV and MLSD",
+			server:    func(s *testFTPServer) { s.mlsd = true },
+			want:      []string{"EPSV", "MLSD"},
+			forbidden: []string{"PASV", "PORT", "LIST"},
+			exactTime: true,
+		},
+		{
+			name:      "forced PASV",
+			opts:      FTPOptions{Passive: &passive, PassiveMode: "pasv"},
+			want:      []string{"PASV", "LIST"},
+			forbidden: []string{"EPSV"},
+		},
+		{
+			name:   "EPSV rejected falls back to PASV",
+			server: func(s *testFTPServer) { s.noEPSV = true },
+			want:   []string{"EPSV", "PASV"},
+		},
+		{
+			name:      "active mode",
+			server:    func(s *testFTPServer) { s.mlsd = true },
+			opts:      FTPOptions{Passive: &active},
+			want:      []string{"PORT", "MLSD"},
+			forbidden: []string{"EPSV", "PASV"},
+			exactTime: true,
+		},
+		{
+			name:      "active mode with port range and announced address",
+			opts:      FTPOptions{Passive: &active, ActivePortRange: "40100-40120", ActiveAddress: "127.0.0.1"},
+			want:      []string{"PORT"},
+			forbidden: []string{"EPSV", "PASV"},
+		},
+		{
+			name:   "broken MLSD falls back to LIST",
+			server: func(s *testFTPServer) { s.mlsd = true; s.noMLSD = true },
+			want:   []string{"MLSD", "LIST"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestFTPServer(t, tt.server)
+			srv.AddFile("/saves/world.sav", "world-data", modTime)
+			srv.AddFile("/saves/players/1.json", "{}", modTime)
+
+			opts := tt.opts
+			opts.Host = "127.0.0.1"
+			opts.Port = srv.Port()
+			opts.Username = "user"
+			opts.Password = "pass"
+			conn := NewFTPConnector(Config{Type: "ftp", RemotePath: "/saves", Options: &opts})
+
+			ctx := context.Background()
+			if err := conn.Connect(ctx); err != nil {
+				t.Fatalf("Connect: %v", err)
+			}
+			defer conn.Close()
+
+			files, err := conn.List(ctx)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			if len(files) != 2 {
+				t.Fatalf("expected 2 files, got %+v", files)
+			}
+			for _, f := range files {
+				want := modTime
+				if !tt.exactTime {
+					want = modTime.Truncate(time.Minute)
+				}
+				if !f.ModTime.Equal(want) {
+					t.Errorf("%s: mod time %v, want %v", f.Path, f.ModTime, want)
+				}
+			}
+
+			var buf bytes.Buffer
+			if err := conn.Download(ctx, "world.sav", &buf); err != nil {
+				t.Fatalf("Download: %v", err)
+			}
+			if buf.String() != "world-data" {
+				t.Errorf("downloaded %q", buf.String())
+			}
+
+			if err := conn.Upload(ctx, strings.NewReader("restored"), "restored.sav"); err != nil {
+				t.Fatalf("Upload: %v", err)
+			}
+			if data, _ := srv.File("/saves/restored.sav"); data != "restored" {
+				t.Errorf("uploaded %q", data)
+			}
+
+			sent := strings.Join(srv.Commands(), " ")
+			for _, c := range tt.want {
+				if !strings.Contains(sent, c) {
+					t.Errorf("expected %s to be sent, got %s", c, sent)
+				}
+			}
+			for _, c := range tt.forbidden {
+				if strings.Contains(sent, c) {
+					t.Errorf("did not expect %s to be sent, got %s", c, sent)
+				}
+			}
+
+			if opts.ActivePortRange != "" {
+				parts := strings.Split(srv.LastArg("PORT"), ",")
+				port := atoi(t, parts[4])*256 + atoi(t, parts[5])
+				if port < 40100 || port > 40120 {
+					t.Errorf("active port %d outside configured range", port)
+				}
+			}
+		})
+	}
+}
+
+func TestFTPConnectorActiveTLS(t *testing.T) {
+	pki := newTestPKI(t, "127.0.0.1")
+	srv := newTestFTPServer(t, func(s *testFTPServer) { s.tls = pki.serverTLS() })
+	srv.AddFile("/world.sav", "world-data", time.Now())
+
+	active := false
+	err := connectFTPS(t, srv, FTPOptions{Host: "127.0.0.1", TLSMode: FTPTLSExplicit, TLSCAFile: pki.caFile, Passive: &active})
+	if err != nil {
+		t.Fatalf("active FTPS download: %v", err)
+	}
+}
+
+func TestFTPConnectorDownloadFrom(t *testing.T) {
+	for _, noREST := range []bool{false, true} {
+		t.Run(fmt.Sprintf("noREST=%v", noREST), func(t *testing.T) {
+			srv := newTestFTPServer(t, func(s *testFTPServer) { s.noREST = noREST })
+			srv.AddFile("/saves/world.sav", "0123456789", time.Now())
+
+			conn := NewFTPConnector(Config{Type: "ftp", RemotePath: "/saves", Options: &FTPOptions{
+				Host: "127.0.0.1", Port: srv.Port(), Username: "user", Password: "pass",
+			}})
+			ctx := context.Background()
+			if err := conn.Connect(ctx); err != nil {
+				t.Fatalf("Connect: %v", err)
+			}
+			defer conn.Close()
+
+			var buf bytes.Buffer
+			if err := conn.DownloadFrom(ctx, "world.sav", 6, &buf); err != nil {
+				t.Fatalf("DownloadFrom: %v", err)
+			}
+			if buf.String() != "6789" {
+				t.Errorf("downloaded %q, want %q", buf.String(), "6789")
+			}
+			if srv.LastArg("REST") != "6" {
+				t.Errorf("REST arg = %q", srv.LastArg("REST"))
+			}
+		})
+	}
+}
+
+func TestFTPConnectorDownloadStalled(t *testing.T) {
+	srv := newTestFTPServer(t, func(s *testFTPServer) { s.stalls = 1 })
+	srv.AddFile("/saves/world.sav", "0123456789", time.Now())
+
+	newConn := func() *FTPConnector {
+		conn := NewFTPConnector(Config{Type: "ftp", RemotePath: "/saves", Options: &FTPOptions{
+			Host: "127.0.0.1", Port: srv.Port(), Username: "user", Password: "pass",
+		}})
+		if err := conn.Connect(context.Background()); err != nil {
+			t.Fatalf("Connect: %v", err)
+		}
+		return conn
+	}
+
+	conn := newConn()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- conn.Download(ctx, "world.sav", io.Discard) }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Download = %v, want DeadlineExceeded", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stalled download ignored the context")
+	}
+	conn.Close()
+
+	conn = newConn()
+	defer conn.Close()
+	var buf bytes.Buffer
+	if err := conn.Download(context.Background(), "world.sav", &buf); err != nil || buf.String() != "0123456789" {
+		t.Errorf("Download after reconnect = %q, %v", buf.String(), err)
+	}
+}
+
+func TestFTPConnectorUploadThrottled(t *testing.T) {
+	srv := newTestFTPServer(t, nil)
+	conn := NewFTPConnector(Config{Type: "ftp", RemotePath: "/saves",
+		Bandwidth: throttle.NewLimiter(throttle.NewBucket(throttle.Schedule{Rate: 256 << 10})),
+		Options:   &FTPOptions{Host: "127.0.0.1", Port: srv.Port(), Username: "user", Password: "pass"}})
+	ctx := context.Background()
+	if err := conn.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+
+	// 256 KiB at 256 KiB/s, less the quarter second burst
+	data := strings.Repeat("x", 256<<10)
+	start := time.Now()
+	if err := conn.Upload(ctx, strings.NewReader(data), "world.sav"); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 600*time.Millisecond {
+		t.Errorf("upload took %v, want it throttled to ~750ms", elapsed)
+	}
+	if got, _ := srv.File("/saves/world.sav"); got != data {
+		t.Errorf("uploaded %d bytes", len(got))
+	}
+}
+
+func TestFTPConnectorUnresponsive(t *testing.T) {
+	srv := newTestFTPServer(t, func(s *testFTPServer) { s.mute = "LIST" })
+	srv.AddFile("/saves/world.sav", "0123456789", time.Now())
+
+	conn := NewFTPConnector(Config{Type: "ftp", RemotePath: "/saves", ConnectTimeout: 200 * time.Millisecond,
+		Options: &FTPOptions{Host: "127.0.0.1", Port: srv.Port(), Username: "user", Password: "pass"}})
+	if err := conn.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+
+	// Without a deadline on ctx, the reply timeout still ends the wait
+	done := make(chan error, 1)
+	go func() {
+		_, err := conn.List(context.Background())
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("List succeeded without a reply")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("List waited for a server that stopped answering")
+	}
+}
+
+func TestFTPOptionsValidateDataModes(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    FTPOptions
+		wantErr bool
+	}{
+		{"epsv", FTPOptions{Host: "h", PassiveMode: "epsv"}, false},
+		{"bad passive mode", FTPOptions{Host: "h", PassiveMode: "lpsv"}, true},
+		{"port range", FTPOptions{Host: "h", ActivePortRange: "50000-50100"}, false},
+		{"inverted port range", FTPOptions{Host: "h", ActivePortRange: "50100-50000"}, true},
+		{"bad port range", FTPOptions{Host: "h", ActivePortRange: "50000"}, true},
+		{"active address", FTPOptions{Host: "h", ActiveAddress: "203.0.113.5"}, false},
+		{"active hostname", FTPOptions{Host: "h", ActiveAddress: "nat.example.com"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func atoi(t *testing.T, s string) int {
+	t.Helper()
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		t.Fatalf("atoi %q: %v", s, err)
+	}
+	return n
+}
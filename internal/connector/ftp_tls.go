@@ -0,0 +1,93 @@
+// internal/connector/ftp_tls.go
+package connector
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// tlsConfig builds the TLS configuration shared by the control and data connections
+func (o *FTPOptions) tlsConfig() (*tls.Config, error) {
+	serverName := o.TLSServerName
+	if serverName == "" {
+		serverName = o.Host
+	}
+
+	cfg := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: o.TLSInsecureSkipVerify,
+		// Many FTPS servers require data connections to resume the control session
+		ClientSessionCache: tls.NewLRUClientSessionCache(0),
+	}
+
+	if o.TLSCAFile != "" {
+		pem, err := os.ReadFile(o.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tls_ca_file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in tls_ca_file %s", o.TLSCAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if o.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.TLSCertFile, o.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if o.TLSFingerprint != "" {
+		// A pinned certificate replaces chain verification, which is what makes
+		// self-signed server certificates usable without skipping all checks.
+		want, err := parseFingerprint(o.TLSFingerprint)
+		if err != nil {
+			return nil, err
+		}
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return fmt.Errorf("server presented no certificate")
+			}
+			got := sha256.Sum256(rawCerts[0])
+			if !bytes.Equal(got[:], want) {
+				return fmt.Errorf("server certificate fingerprint %s does not match tls_fingerprint", formatFingerprint(got[:]))
+			}
+			return nil
+		}
+	}
+
+	return cfg, nil
+}
+
+// parseFingerprint decodes a SHA-256 fingerprint written as hex, optionally
+// colon separated and prefixed with "sha256:" (as printed by openssl x509 -fingerprint).
+func parseFingerprint(s string) ([]byte, error) {
+	s = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), "sha256:")
+	s = strings.NewReplacer(":", "", " ", "").Replace(s)
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != sha256.Size {
+		return nil, fmt.Errorf("connection.tls_fingerprint must be a SHA-256 hex fingerprint")
+	}
+	return b, nil
+}
+
+func formatFingerprint(b []byte) string {
+	parts := make([]string, len(b))
+	for i, c := range b {
+		parts[i] = fmt.Sprintf("%02X", c)
+	}
+	return strings.Join(parts, ":")
+}
@@ -0,0 +1,581 @@
+// internal/connector/ftpserver_test.go
+package connector
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testFTPFile is a file held by testFTPServer
+type testFTPFile struct {
+	data    []byte
+	modTime time.Time
+}
+
+// testFTPServer is a minimal in-process FTP/FTPS server for connector tests.
+// It implements just enough of RFC 959 and friends for the internal/ftp client.
+type testFTPServer struct {
+	ln       net.Listener
+	user     string
+	pass     string
+	tls      *tls.Config // enables AUTH TLS, PROT P and implicit TLS
+	implicit bool
+	mlsd     bool   // advertise MLST/MLSD in FEAT
+	noMLSD   bool   // reject MLSD even when advertised, like some broken daemons
+	noEPSV   bool   // reject EPSV
+	noREST   bool   // reject REST
+	stalls   int    // RETRs that send half the file and then hang
+	mute     string // verb the server never answers, like a hung daemon
+
+	mu       sync.Mutex
+	files    map[string]testFTPFile
+	commands []string
+	args     map[string]string
+}
+
+func newTestFTPServer(t *testing.T, configure func(s *testFTPServer)) *testFTPServer {
+	t.Helper()
+
+	s := &testFTPServer{
+		user:  "user",
+		pass:  "pass",
+		files: map[string]testFTPFile{},
+		args:  map[string]string{},
+	}
+	if configure != nil {
+		configure(s)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	if s.implicit {
+		ln = tls.NewListener(ln, s.tls)
+	}
+	s.ln = ln
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+// Port returns the control connection port
+func (s *testFTPServer) Port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+// AddFile stores a file at an absolute path
+func (s *testFTPServer) AddFile(p string, data string, modTime time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[path.Clean(p)] = testFTPFile{data: []byte(data), modTime: modTime}
+}
+
+// File returns the content stored at an absolute path
+func (s *testFTPServer) File(p string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.files[path.Clean(p)]
+	return string(f.data), ok
+}
+
+// Commands returns the verbs received so far, in order
+func (s *testFTPServer) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+// LastArg returns the argument of the most recent command with this verb
+func (s *testFTPServer) LastArg(verb string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.args[verb]
+}
+
+type testFTPSession struct {
+	s        *testFTPServer
+	conn     net.Conn
+	r        *bufio.Reader
+	authed   bool
+	protData bool
+	pasv     net.Listener
+	active   string
+	offset   int64
+}
+
+func (s *testFTPServer) serve(conn net.Conn) {
+	sess := &testFTPSession{s: s, conn: conn, r: bufio.NewReader(conn)}
+	defer func() {
+		sess.conn.Close()
+		if sess.pasv != nil {
+			sess.pasv.Close()
+		}
+	}()
+
+	sess.reply("220 gsbt test server ready")
+	for {
+		line, err := sess.r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+		verb = strings.ToUpper(verb)
+
+		s.mu.Lock()
+		s.commands = append(s.commands, verb)
+		s.args[verb] = arg
+		s.mu.Unlock()
+
+		if !sess.handle(verb, arg) {
+			return
+		}
+	}
+}
+
+func (c *testFTPSession) reply(format string, args ...interface{}) {
+	fmt.Fprintf(c.conn, format+"\r\n", args...)
+}
+
+// handle processes one command; it returns false to end the session
+func (c *testFTPSession) handle(verb, arg string) bool {
+	s := c.s
+
+	switch verb {
+	case "AUTH":
+		if s.tls == nil {
+			c.reply("502 TLS not configured")
+			return true
+		}
+		c.reply("234 AUTH TLS ok")
+		tlsConn := tls.Server(c.conn, s.tls)
+		if err := tlsConn.Handshake(); err != nil {
+			return false
+		}
+		c.conn = tlsConn
+		c.r = bufio.NewReader(tlsConn)
+	case "USER":
+		if s.tls != nil && !s.implicit {
+			if _, ok := c.conn.(*tls.Conn); !ok {
+				c.reply("530 TLS required")
+				return true
+			}
+		}
+		c.reply("331 password required")
+	case "PASS":
+		if arg != s.pass {
+			c.reply("530 login incorrect")
+			return true
+		}
+		c.authed = true
+		c.reply("230 logged in")
+	case "FEAT":
+		feats := []string{"UTF8", "EPSV", "PASV", "SIZE", "REST STREAM"}
+		if s.mlsd {
+			feats = append(feats, "MLST type*;size*;modify*;")
+		}
+		c.reply("211-Features:\r\n %s\r\n211 End", strings.Join(feats, "\r\n "))
+	case "TYPE", "OPTS", "PBSZ", "NOOP":
+		c.reply("200 ok")
+	case "PROT":
+		c.protData = strings.EqualFold(arg, "P")
+		c.reply("200 ok")
+	case "QUIT":
+		c.reply("221 bye")
+		return false
+	default:
+		if !c.authed {
+			c.reply("530 not logged in")
+			return true
+		}
+		c.handleAuthed(verb, arg)
+	}
+	return true
+}
+
+func (c *testFTPSession) handleAuthed(verb, arg string) {
+	s := c.s
+	if verb == s.mute {
+		return
+	}
+
+	switch {
+	case verb == "EPSV" && s.noEPSV, verb == "MLSD" && s.noMLSD, verb == "REST" && s.noREST:
+		c.reply("500 %s not understood", verb)
+		return
+	}
+
+	switch verb {
+	case "EPSV":
+		port, err := c.listenPassive()
+		if err != nil {
+			c.reply("425 %v", err)
+			return
+		}
+		c.reply("229 Entering Extended Passive Mode (|||%d|)", port)
+	case "PASV":
+		port, err := c.listenPassive()
+		if err != nil {
+			c.reply("425 %v", err)
+			return
+		}
+		c.reply("227 Entering Passive Mode (127,0,0,1,%d,%d)", port/256, port%256)
+	case "PORT":
+		parts := strings.Split(arg, ",")
+		if len(parts) != 6 {
+			c.reply("501 bad PORT")
+			return
+		}
+		p1, _ := strconv.Atoi(parts[4])
+		p2, _ := strconv.Atoi(parts[5])
+		c.active = net.JoinHostPort(strings.Join(parts[:4], "."), strconv.Itoa(p1*256+p2))
+		c.reply("200 PORT ok")
+	case "EPRT":
+		fields := strings.Split(arg, string(arg[0]))
+		if len(fields) < 5 {
+			c.reply("501 bad EPRT")
+			return
+		}
+		c.active = net.JoinHostPort(fields[2], fields[3])
+		c.reply("200 EPRT ok")
+	case "REST":
+		n, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			c.reply("501 bad offset")
+			return
+		}
+		c.offset = n
+		c.reply("350 restarting at %d", n)
+	case "SIZE":
+		s.mu.Lock()
+		f, ok := s.files[path.Clean(arg)]
+		s.mu.Unlock()
+		if !ok {
+			c.reply("550 not found")
+			return
+		}
+		c.reply("213 %d", len(f.data))
+	case "MKD":
+		c.reply("257 \"%s\" created", arg)
+	case "LIST", "MLSD":
+		dir := strings.TrimSpace(strings.TrimPrefix(arg, "-a"))
+		var buf strings.Builder
+		for _, e := range s.entries(dir) {
+			if verb == "MLSD" {
+				buf.WriteString(e.mlsdLine())
+			} else {
+				buf.WriteString(e.listLine())
+			}
+			buf.WriteString("\r\n")
+		}
+		c.transfer(func(w io.ReadWriter) error {
+			_, err := io.WriteString(w, buf.String())
+			return err
+		})
+	case "MLST":
+		e, ok := s.entry(arg)
+		if !ok || !s.mlsd {
+			c.reply("550 not found")
+			return
+		}
+		c.reply("250-Listing %s\r\n %s\r\n250 End", arg, e.mlsdLine())
+	case "RETR":
+		s.mu.Lock()
+		f, ok := s.files[path.Clean(arg)]
+		s.mu.Unlock()
+		if !ok {
+			c.reply("550 not found")
+			return
+		}
+		offset := c.offset
+		c.offset = 0
+		if offset > int64(len(f.data)) {
+			offset = int64(len(f.data))
+		}
+		s.mu.Lock()
+		stall := s.stalls > 0
+		if stall {
+			s.stalls--
+		}
+		s.mu.Unlock()
+		c.transfer(func(w io.ReadWriter) error {
+			if stall {
+				// hang with the data connection open until the client drops it
+				w.Write(f.data[offset : offset+(int64(len(f.data))-offset)/2])
+				io.Copy(io.Discard, w)
+				return io.ErrUnexpectedEOF
+			}
+			_, err := w.Write(f.data[offset:])
+			return err
+		})
+	case "STOR":
+		c.transfer(func(rw io.ReadWriter) error {
+			data, err := io.ReadAll(rw)
+			if err != nil {
+				return err
+			}
+			s.AddFile(arg, string(data), time.Now())
+			return nil
+		})
+	case "DELE":
+		s.mu.Lock()
+		delete(s.files, path.Clean(arg))
+		s.mu.Unlock()
+		c.reply("250 deleted")
+	default:
+		c.reply("502 %s not implemented", verb)
+	}
+}
+
+func (c *testFTPSession) listenPassive() (int, error) {
+	if c.pasv != nil {
+		c.pasv.Close()
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	c.pasv = ln
+	c.active = ""
+	return ln.Addr().(*net.TCPAddr).Port, nil
+}
+
+// transfer opens the data connection, runs fn and reports the result
+func (c *testFTPSession) transfer(fn func(rw io.ReadWriter) error) {
+	var (
+		conn net.Conn
+		err  error
+	)
+	switch {
+	case c.active != "":
+		conn, err = net.DialTimeout("tcp", c.active, 5*time.Second)
+	case c.pasv != nil:
+		c.reply("150 opening data connection")
+		c.pasv.(*net.TCPListener).SetDeadline(time.Now().Add(5 * time.Second))
+		conn, err = c.pasv.Accept()
+		c.pasv.Close()
+		c.pasv = nil
+	default:
+		c.reply("425 use PASV or PORT first")
+		return
+	}
+	if err != nil {
+		c.reply("425 can't open data connection: %v", err)
+		return
+	}
+	if c.active != "" {
+		c.reply("150 opening data connection")
+		c.active = ""
+	}
+
+	if c.protData {
+		tlsConn := tls.Server(conn, c.s.tls)
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			c.reply("425 TLS handshake failed: %v", err)
+			return
+		}
+		conn = tlsConn
+	}
+
+	err = fn(conn)
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		tlsConn.CloseWrite()
+	}
+	conn.Close()
+	if err != nil {
+		c.reply("426 transfer aborted: %v", err)
+		return
+	}
+	c.reply("226 transfer complete")
+}
+
+type testFTPEntry struct {
+	name    string
+	size    int
+	modTime time.Time
+	isDir   bool
+}
+
+func (e testFTPEntry) listLine() string {
+	mode := "-rw-r--r--"
+	if e.isDir {
+		mode = "drwxr-xr-x"
+	}
+	return fmt.Sprintf("%s 1 owner group %d %s %s", mode, e.size, e.modTime.Format("Jan _2 15:04"), e.name)
+}
+
+func (e testFTPEntry) mlsdLine() string {
+	typ := "file"
+	if e.isDir {
+		typ = "dir"
+	}
+	return fmt.Sprintf("type=%s;size=%d;modify=%s; %s", typ, e.size, e.modTime.UTC().Format("20060102150405"), e.name)
+}
+
+// entries returns the immediate children of dir
+func (s *testFTPServer) entries(dir string) []testFTPEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir = path.Clean("/" + dir)
+	seen := map[string]testFTPEntry{}
+	for p, f := range s.files {
+		rel := strings.TrimPrefix(p, dir+"/")
+		if dir == "/" {
+			rel = strings.TrimPrefix(p, "/")
+		}
+		if rel == p {
+			continue
+		}
+		name, _, nested := strings.Cut(rel, "/")
+		if nested {
+			seen[name] = testFTPEntry{name: name, modTime: f.modTime, isDir: true}
+			continue
+		}
+		seen[name] = testFTPEntry{name: name, size: len(f.data), modTime: f.modTime}
+	}
+
+	var out []testFTPEntry
+	for _, e := range seen {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+	return out
+}
+
+// entry returns the entry for a single path
+func (s *testFTPServer) entry(p string) (testFTPEntry, bool) {
+	for _, e := range s.entries(path.Dir(p)) {
+		if e.name == path.Base(p) {
+			return e, true
+		}
+	}
+	return testFTPEntry{}, false
+}
+
+// testPKI holds a throwaway CA plus leaf certificates written as PEM files
+type testPKI struct {
+	dir        string
+	caFile     string
+	ca         *x509.Certificate
+	caKey      *ecdsa.PrivateKey
+	serverCert tls.Certificate
+}
+
+// newTestPKI creates a CA and a server certificate valid for the given names
+func newTestPKI(t *testing.T, serverNames ...string) *testPKI {
+	t.Helper()
+
+	dir := t.TempDir()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate CA key: %v", err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "gsbt test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("create CA: %v", err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	p := &testPKI{dir: dir, ca: ca, caKey: caKey, caFile: filepath.Join(dir, "ca.pem")}
+	writePEM(t, p.caFile, "CERTIFICATE", caDER)
+
+	certFile, keyFile := p.issue(t, "server", x509.ExtKeyUsageServerAuth, serverNames...)
+	p.serverCert, err = tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("load server cert: %v", err)
+	}
+	return p
+}
+
+// issue signs a leaf certificate and returns the cert and key file paths
+func (p *testPKI) issue(t *testing.T, name string, usage x509.ExtKeyUsage, names ...string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	for _, n := range names {
+		if ip := net.ParseIP(n); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, n)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, p.ca, &key.PublicKey, p.caKey)
+	if err != nil {
+		t.Fatalf("create cert: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	certFile := filepath.Join(p.dir, name+".pem")
+	keyFile := filepath.Join(p.dir, name+"-key.pem")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile
+}
+
+// serverTLS returns a server config presenting the server certificate
+func (p *testPKI) serverTLS() *tls.Config {
+	return &tls.Config{Certificates: []tls.Certificate{p.serverCert}}
+}
+
+func writePEM(t *testing.T, file, typ string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		t.Fatalf("write %s: %v", file, err)
+	}
+}
@@ -12,6 +12,33 @@ import (
 
 const nitradoAPIBase = "https://api.nitrado.net"
 
+func init() {
+	Register(Registration{
+		Type:       "nitrado",
+		NewOptions: func() Options { return &NitradoOptions{} },
+		New: func(cfg Config) (Connector, error) {
+			return NewNitradoConnector(cfg), nil
+		},
+	})
+}
+
+// NitradoOptions holds Nitrado API settings
+type NitradoOptions struct {
+	APIKey    string `yaml:"api_key,omitempty"`
+	ServiceID string `yaml:"service_id"`
+}
+
+// Validate checks required Nitrado settings
+func (o *NitradoOptions) Validate() error {
+	if o.APIKey == "" {
+		return fmt.Errorf("connection.api_key (or defaults.nitrado_api_key) is required")
+	}
+	if o.ServiceID == "" {
+		return fmt.Errorf("connection.service_id is required")
+	}
+	return nil
+}
+
 // NitradoConnector implements Connector for Nitrado game servers
 // It fetches FTP credentials from Nitrado API and delegates to FTPConnector
 type NitradoConnector struct {
@@ -39,10 +66,11 @@ type nitradoFTPResponse struct {
 
 // NewNitradoConnector creates a new Nitrado connector
 func NewNitradoConnector(cfg Config) *NitradoConnector {
+	opts := optionsAs[NitradoOptions](cfg)
 	return &NitradoConnector{
 		config:     cfg,
-		apiKey:     cfg.APIKey,
-		serviceID:  cfg.ServiceID,
+		apiKey:     opts.APIKey,
+		serviceID:  opts.ServiceID,
 		apiBase:    nitradoAPIBase,
 		httpClient: &http.Client{Timeout: 30 * time.Second},
 	}
@@ -70,20 +98,24 @@ func (n *NitradoConnector) Connect(ctx context.Context) error {
 	}
 
 	// Create FTP connector with retrieved credentials
+	passive := true
 	ftpConfig := Config{
 		Type:       "ftp",
-		Host:       creds.Hostname,
-		Port:       creds.Port,
-		Username:   creds.Username,
-		Password:   creds.Password,
 		RemotePath: n.config.RemotePath,
 		Include:    n.config.Include,
 		Exclude:    n.config.Exclude,
-		Passive:    true,
+		Options: &FTPOptions{
+			Host:     creds.Hostname,
+			Port:     creds.Port,
+			Username: creds.Username,
+			Password: creds.Password,
+			Passive:  &passive,
+		},
 
 		RetryAttempts: n.config.RetryAttempts,
 		RetryDelay:    n.config.RetryDelay,
 		RetryBackoff:  n.config.RetryBackoff,
+		Bandwidth:     n.config.Bandwidth,
 	}
 
 	n.ftp = NewFTPConnector(ftpConfig)
@@ -164,6 +196,14 @@ func (n *NitradoConnector) Download(ctx context.Context, remotePath string, w io
 	return n.ftp.Download(ctx, remotePath, w)
 }
 
+// DownloadFrom delegates to FTP connector
+func (n *NitradoConnector) DownloadFrom(ctx context.Context, remotePath string, offset int64, w io.Writer) error {
+	if n.ftp == nil {
+		return fmt.Errorf("not connected")
+	}
+	return n.ftp.DownloadFrom(ctx, remotePath, offset, w)
+}
+
 // Upload delegates to FTP connector
 func (n *NitradoConnector) Upload(ctx context.Context, r io.Reader, remotePath string) error {
 	if n.ftp == nil {
@@ -7,9 +7,11 @@ import (
 
 func TestNewNitradoConnector(t *testing.T) {
 	cfg := Config{
-		Type:       "nitrado",
-		APIKey:     "test-api-key",
-		ServiceID:  "12345",
+		Type: "nitrado",
+		Options: &NitradoOptions{
+			APIKey:    "test-api-key",
+			ServiceID: "12345",
+		},
 		RemotePath: "/games/ark/",
 		Include:    []string{"*"},
 		Exclude:    []string{"*.log"},
@@ -0,0 +1,86 @@
+// internal/connector/registry.go
+package connector
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/invopop/jsonschema"
+)
+
+// Options is implemented by each connector's typed settings struct. The
+// struct is decoded from the YAML connection block, so fields use yaml tags.
+type Options interface {
+	// Validate checks required settings and applies connector defaults
+	Validate() error
+}
+
+// Registration describes a connector type
+type Registration struct {
+	// Type is the value of connection.type selecting this connector
+	Type string
+
+	// NewOptions returns a pointer to a zero options struct for decoding
+	NewOptions func() Options
+
+	// New builds the connector. cfg.Options holds the value from NewOptions.
+	New func(cfg Config) (Connector, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Registration{}
+)
+
+// Register adds a connector type. It panics on duplicate or incomplete
+// registrations, as those are programming errors.
+func Register(r Registration) {
+	if r.Type == "" || r.NewOptions == nil || r.New == nil {
+		panic("connector: incomplete registration")
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[r.Type]; exists {
+		panic(fmt.Sprintf("connector: type %s registered twice", r.Type))
+	}
+	registry[r.Type] = r
+}
+
+// Lookup returns the registration for a connector type
+func Lookup(typ string) (Registration, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	r, ok := registry[typ]
+	return r, ok
+}
+
+// Types returns all registered connector types, sorted
+func Types() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	types := make([]string, 0, len(registry))
+	for t := range registry {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Schema returns the JSON schema fragment for the connector's options
+func (r Registration) Schema(reflector *jsonschema.Reflector) *jsonschema.Schema {
+	return reflector.ReflectFromType(reflect.TypeOf(r.NewOptions()).Elem())
+}
+
+// optionsAs returns cfg.Options as T, or a fresh zero value if unset.
+// Connectors use it so they can be built from a bare Config in tests.
+func optionsAs[T any](cfg Config) *T {
+	if o, ok := any(cfg.Options).(*T); ok && o != nil {
+		return o
+	}
+	return new(T)
+}
@@ -5,33 +5,75 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"path"
 	"path/filepath"
-	"time"
 
 	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
 )
 
+func init() {
+	Register(Registration{
+		Type:       "sftp",
+		NewOptions: func() Options { return &SFTPOptions{} },
+		New: func(cfg Config) (Connector, error) {
+			return NewSFTPConnector(cfg), nil
+		},
+	})
+}
+
+// SFTPOptions holds SFTP connection settings
+type SFTPOptions struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port,omitempty"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password,omitempty"`
+	KeyFile  string `yaml:"key_file,omitempty"`
+
+	// Rsync transfers changed files with the rsync delta algorithm against
+	// the previous staging copy, falling back to SFTP when unavailable
+	Rsync     bool   `yaml:"rsync,omitempty"`
+	RsyncPath string `yaml:"rsync_path,omitempty"`
+}
+
+// Validate checks required SFTP settings
+func (o *SFTPOptions) Validate() error {
+	if o.Host == "" {
+		return fmt.Errorf("connection.host is required")
+	}
+	if o.Password == "" && o.KeyFile == "" {
+		return fmt.Errorf("connection.password or connection.key_file is required")
+	}
+	return nil
+}
+
 // SFTPConnector implements Connector for SFTP servers
 type SFTPConnector struct {
 	config     Config
+	opts       *SFTPOptions
 	sshClient  *ssh.Client
 	sftpClient *sftp.Client
+
+	// rsyncPipe is the command rsync runs as its remote shell; it must end
+	// up calling RsyncPipe. Defaults to this executable.
+	rsyncPipe []string
 }
 
 // NewSFTPConnector creates a new SFTP connector
 func NewSFTPConnector(cfg Config) *SFTPConnector {
-	if cfg.Port == 0 {
-		cfg.Port = 22
+	opts := *optionsAs[SFTPOptions](cfg)
+	if opts.Port == 0 {
+		opts.Port = 22
 	}
-	return &SFTPConnector{config: cfg}
+	cfg.Options = &opts
+	return &SFTPConnector{config: cfg, opts: &opts}
 }
 
 // Name returns the connector name for logging
 func (s *SFTPConnector) Name() string {
-	return fmt.Sprintf("sftp://%s:%d", s.config.Host, s.config.Port)
+	return fmt.Sprintf("sftp://%s:%d", s.opts.Host, s.opts.Port)
 }
 
 // Connect establishes SFTP connection
@@ -39,8 +81,8 @@ func (s *SFTPConnector) Connect(ctx context.Context) error {
 	var authMethods []ssh.AuthMethod
 
 	// Try key file authentication first
-	if s.config.KeyFile != "" {
-		key, err := os.ReadFile(s.config.KeyFile)
+	if s.opts.KeyFile != "" {
+		key, err := os.ReadFile(s.opts.KeyFile)
 		if err != nil {
 			return fmt.Errorf("failed to read key file: %w", err)
 		}
@@ -54,8 +96,8 @@ func (s *SFTPConnector) Connect(ctx context.Context) error {
 	}
 
 	// Fall back to password authentication
-	if s.config.Password != "" {
-		authMethods = append(authMethods, ssh.Password(s.config.Password))
+	if s.opts.Password != "" {
+		authMethods = append(authMethods, ssh.Password(s.opts.Password))
 	}
 
 	if len(authMethods) == 0 {
@@ -63,17 +105,25 @@ func (s *SFTPConnector) Connect(ctx context.Context) error {
 	}
 
 	sshConfig := &ssh.ClientConfig{
-		User:            s.config.Username,
+		User:            s.opts.Username,
 		Auth:            authMethods,
 		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // TODO: proper host key verification
-		Timeout:         30 * time.Second,
 	}
 
-	addr := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
-	sshClient, err := ssh.Dial("tcp", addr, sshConfig)
+	addr := fmt.Sprintf("%s:%d", s.opts.Host, s.opts.Port)
+	conn, err := (&net.Dialer{Timeout: s.config.connectTimeout()}).DialContext(ctx, "tcp", addr)
 	if err != nil {
 		return fmt.Errorf("failed to connect to SSH: %w", err)
 	}
+	// The handshake doesn't watch ctx; closing the connection ends it
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, sshConfig)
+	stop()
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to SSH: %w", contextErr(ctx, err))
+	}
+	sshClient := ssh.NewClient(c, chans, reqs)
 	s.sshClient = sshClient
 
 	sftpClient, err := sftp.NewClient(sshClient)
@@ -92,10 +142,13 @@ func (s *SFTPConnector) List(ctx context.Context) ([]FileInfo, error) {
 		return nil, fmt.Errorf("not connected")
 	}
 
+	// The SFTP client doesn't watch ctx, so a cancelled listing closes the connection
+	defer context.AfterFunc(ctx, s.abort)()
+
 	var files []FileInfo
 	err := s.walkDir(s.config.RemotePath, &files)
 	if err != nil {
-		return nil, err
+		return nil, contextErr(ctx, err)
 	}
 
 	// Filter by patterns
@@ -148,19 +201,35 @@ func (s *SFTPConnector) walkDir(dir string, files *[]FileInfo) error {
 
 // Download retrieves a file from SFTP
 func (s *SFTPConnector) Download(ctx context.Context, remotePath string, w io.Writer) error {
+	return s.DownloadFrom(ctx, remotePath, 0, w)
+}
+
+// DownloadFrom retrieves a file from SFTP starting at offset
+func (s *SFTPConnector) DownloadFrom(ctx context.Context, remotePath string, offset int64, w io.Writer) error {
 	if s.sftpClient == nil {
 		return fmt.Errorf("not connected")
 	}
 
+	defer context.AfterFunc(ctx, s.abort)()
+
 	fullPath := path.Join(s.config.RemotePath, remotePath)
 	f, err := s.sftpClient.Open(fullPath)
 	if err != nil {
-		return fmt.Errorf("failed to open %s: %w", remotePath, err)
+		return contextErr(ctx, fmt.Errorf("failed to open %s: %w", remotePath, err))
 	}
 	defer f.Close()
 
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek %s: %w", remotePath, err)
+	}
+
 	_, err = io.Copy(w, f)
-	return err
+	return contextErr(ctx, err)
+}
+
+// abort closes the SSH connection under a blocked operation
+func (s *SFTPConnector) abort() {
+	s.sshClient.Close()
 }
 
 // Upload sends a file to SFTP
@@ -170,6 +239,7 @@ func (s *SFTPConnector) Upload(ctx context.Context, r io.Reader, remotePath stri
 	}
 
 	fullPath := path.Join(s.config.RemotePath, remotePath)
+	r = s.config.Bandwidth.Reader(ctx, r)
 
 	// Ensure parent directory exists
 	dir := path.Dir(fullPath)
@@ -0,0 +1,247 @@
+// internal/connector/sftp_rsync.go
+package connector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// RsyncPipeCommand is the hidden gsbt subcommand rsync runs as its remote
+// shell. It forwards rsync's stdio to the connector's SSH connection, so
+// the delta transfer reuses the session (and credentials) SFTP already has.
+const RsyncPipeCommand = "__rsync-pipe"
+
+// SyncTo updates localDir with the local rsync binary talking to
+// `rsync --server` on the host. Unchanged files are skipped and changed
+// ones are rebuilt from the previous copy in localDir plus the differences.
+// It reports false when rsync is disabled or missing on either side.
+func (s *SFTPConnector) SyncTo(ctx context.Context, files []FileInfo, localDir string) (bool, error) {
+	if !s.opts.Rsync {
+		return false, nil
+	}
+	if s.sshClient == nil {
+		return false, fmt.Errorf("not connected")
+	}
+	if !s.rsyncAvailable() {
+		return false, nil
+	}
+
+	pipe := s.rsyncPipe
+	if pipe == nil {
+		exe, err := os.Executable()
+		if err != nil {
+			return false, nil
+		}
+		pipe = []string{exe, RsyncPipeCommand}
+	}
+
+	work, err := os.MkdirTemp("", "gsbt-rsync-")
+	if err != nil {
+		return true, err
+	}
+	defer os.RemoveAll(work)
+
+	var list strings.Builder
+	keep := map[string]bool{}
+	for _, f := range files {
+		if !f.IsDir {
+			list.WriteString(filepath.ToSlash(f.Path) + "\n")
+			keep[filepath.Clean(f.Path)] = true
+		}
+	}
+	listFile := filepath.Join(work, "files")
+	if err := os.WriteFile(listFile, []byte(list.String()), 0o600); err != nil {
+		return true, err
+	}
+
+	sock := filepath.Join(work, "ssh.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		return true, fmt.Errorf("rsync: %w", err)
+	}
+	defer ln.Close()
+
+	served := make(chan error, 1)
+	go func() { served <- s.serveRsync(ctx, ln) }()
+
+	var rsh []string
+	for _, arg := range append(pipe, sock) {
+		rsh = append(rsh, shellQuote(arg))
+	}
+	args := []string{
+		"-t",
+		"--files-from=" + listFile,
+		"--rsync-path=" + s.rsyncPath(),
+		"-e", strings.Join(rsh, " "),
+	}
+	args = append(args,
+		"gsbt:"+strings.TrimSuffix(s.config.RemotePath, "/")+"/",
+		localDir+string(filepath.Separator),
+	)
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "rsync", args...)
+	// rsync 3.2.4+ would escape the remote arguments itself; RsyncPipe
+	// quotes them instead, the same way for every rsync version
+	cmd.Env = append(os.Environ(), "RSYNC_OLD_ARGS=1")
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	ln.Close()
+	serveErr := <-served
+	if runErr != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return true, fmt.Errorf("rsync: %w: %s", runErr, msg)
+		}
+		return true, fmt.Errorf("rsync: %w", errors.Join(runErr, serveErr))
+	}
+
+	return true, removeStale(localDir, keep)
+}
+
+func (s *SFTPConnector) rsyncPath() string {
+	if s.opts.RsyncPath != "" {
+		return s.opts.RsyncPath
+	}
+	return "rsync"
+}
+
+// rsyncAvailable checks for rsync locally and on the host
+func (s *SFTPConnector) rsyncAvailable() bool {
+	if _, err := exec.LookPath("rsync"); err != nil {
+		return false
+	}
+	session, err := s.sshClient.NewSession()
+	if err != nil {
+		return false
+	}
+	defer session.Close()
+	return session.Run("command -v "+shellQuote(s.rsyncPath())) == nil
+}
+
+// serveRsync accepts the single connection from RsyncPipe and runs the
+// command it sends on a new session of the SSH connection. What the remote
+// sends is throttled like downloads, so rsync shares the bandwidth buckets.
+func (s *SFTPConnector) serveRsync(ctx context.Context, ln net.Listener) error {
+	conn, err := ln.Accept()
+	if err != nil {
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		return err
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	command, err := r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("read remote command: %w", err)
+	}
+
+	session, err := s.sshClient.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	var stderr bytes.Buffer
+	session.Stdin = r
+	session.Stderr = &stderr
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := session.Start(strings.TrimSuffix(command, "\n")); err != nil {
+		return err
+	}
+
+	// The session waits for stdin to close, which the pipe only does once
+	// it has seen the end of stdout, so forward that first
+	go func() {
+		io.Copy(conn, s.config.Bandwidth.Reader(ctx, stdout))
+		conn.(*net.UnixConn).CloseWrite()
+	}()
+
+	if err := session.Wait(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// RsyncPipe is the remote shell side of SyncTo. rsync invokes it as
+// "<socket> [-l user] <host> <command...>"; the command is sent to the
+// connector over the socket and stdio is relayed until the remote exits.
+func RsyncPipe(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) < 3 {
+		return fmt.Errorf("usage: %s <socket> <host> <command...>", RsyncPipeCommand)
+	}
+	sock, args := args[0], args[1:]
+	if args[0] == "-l" && len(args) > 3 {
+		args = args[2:]
+	}
+
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// The remote shell splits the command, so spaces and metacharacters in
+	// rsync_path or remote_path stay part of their argument
+	quoted := make([]string, len(args)-1)
+	for i, arg := range args[1:] {
+		quoted[i] = shellQuote(arg)
+	}
+	if _, err := fmt.Fprintln(conn, strings.Join(quoted, " ")); err != nil {
+		return err
+	}
+
+	go func() {
+		io.Copy(conn, stdin)
+		conn.(*net.UnixConn).CloseWrite()
+	}()
+
+	_, err = io.Copy(stdout, conn)
+	return err
+}
+
+// removeStale deletes files below dir that are not in keep, so a reused
+// staging directory matches the remote listing
+func removeStale(dir string, keep map[string]bool) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if !keep[rel] {
+			return os.Remove(path)
+		}
+		return nil
+	})
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_./:=@%+,-]+$`)
+
+// shellQuote quotes s for a POSIX shell when it contains special characters
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,298 @@
+// internal/connector/sftp_rsync_test.go
+package connector
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestRsyncHelperProcess is not a real test: it is re-executed as a fake
+// local rsync binary or as the remote shell pipe by installRsyncHelpers.
+func TestRsyncHelperProcess(t *testing.T) {
+	mode := os.Getenv("GSBT_RSYNC_HELPER")
+	if mode == "" {
+		return
+	}
+	args := os.Args
+	for i, arg := range args {
+		if arg == "--" {
+			args = args[i+1:]
+			break
+		}
+	}
+
+	var err error
+	switch mode {
+	case "rsync":
+		err = runFakeRsync(args)
+	case "pipe":
+		err = RsyncPipe(args, os.Stdin, os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// runFakeRsync stands in for the local rsync client. It starts the remote
+// shell like rsync does and speaks a trivial protocol with fakeRemoteRsync:
+// the file list goes out on stdin, "name\x00size\n" plus data comes back.
+func runFakeRsync(args []string) error {
+	var rsh, filesFrom, rsyncPath string
+	var paths []string
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "-e":
+			i++
+			rsh = args[i]
+		case strings.HasPrefix(arg, "--files-from="):
+			filesFrom = strings.TrimPrefix(arg, "--files-from=")
+		case strings.HasPrefix(arg, "--rsync-path="):
+			rsyncPath = strings.TrimPrefix(arg, "--rsync-path=")
+		case strings.HasPrefix(arg, "-"):
+		default:
+			paths = append(paths, arg)
+		}
+	}
+	if len(paths) != 2 {
+		return fmt.Errorf("fake rsync: want source and destination, got %v", paths)
+	}
+	if os.Getenv("RSYNC_OLD_ARGS") != "1" {
+		return fmt.Errorf("fake rsync: remote arguments would be escaped twice")
+	}
+	host, src, _ := strings.Cut(paths[0], ":")
+	dest := paths[1]
+
+	list, err := os.ReadFile(filesFrom)
+	if err != nil {
+		return err
+	}
+
+	shell := strings.Fields(rsh)
+	cmd := exec.Command(shell[0], append(shell[1:], host, rsyncPath, "--server", "--sender", "-t", ".", src)...)
+	cmd.Stdin = strings.NewReader(string(list))
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(stdout)
+	for {
+		header, err := r.ReadString('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		var size int
+		name, sizeStr, _ := strings.Cut(strings.TrimSuffix(header, "\n"), "\x00")
+		fmt.Sscan(sizeStr, &size)
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return err
+		}
+		p := filepath.Join(dest, name)
+		os.MkdirAll(filepath.Dir(p), 0o755)
+		if err := os.WriteFile(p, data, 0o644); err != nil {
+			return err
+		}
+	}
+	return cmd.Wait()
+}
+
+// fakeRemoteRsync is the testSSHServer exec handler for a host with rsync
+func fakeRemoteRsync(command string, stdin io.Reader, stdout, stderr io.Writer) int {
+	args := shellFields(command)
+	switch {
+	case len(args) == 3 && args[0] == "command" && args[2] == "rsync":
+		return 0
+	case len(args) > 2 && args[0] == "rsync" && args[1] == "--server":
+		src := args[len(args)-1]
+		scanner := bufio.NewScanner(stdin)
+		for scanner.Scan() {
+			data, err := os.ReadFile(filepath.Join(src, scanner.Text()))
+			if err != nil {
+				fmt.Fprintln(stderr, err)
+				return 23
+			}
+			fmt.Fprintf(stdout, "%s\x00%d\n", scanner.Text(), len(data))
+			stdout.Write(data)
+		}
+		return 0
+	}
+	return 127
+}
+
+// shellFields splits a command like a POSIX shell does for the words and
+// single quotes shellQuote produces
+func shellFields(command string) []string {
+	var args []string
+	var word strings.Builder
+	inWord, quoted := false, false
+	for _, r := range command {
+		switch {
+		case r == '\'':
+			quoted, inWord = !quoted, true
+		case r == ' ' && !quoted:
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+			}
+			inWord = false
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args
+}
+
+// installRsyncHelpers puts a fake rsync on $PATH and returns the remote
+// shell pipe command, both re-executing the test binary
+func installRsyncHelpers(t *testing.T) []string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell wrapper not supported on windows")
+	}
+
+	dir := t.TempDir()
+	write := func(name, mode string) string {
+		script := fmt.Sprintf("#!/bin/sh\nGSBT_RSYNC_HELPER=%s exec %q -test.run=^TestRsyncHelperProcess$ -- \"$@\"\n", mode, os.Args[0])
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(script), 0o755); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		return p
+	}
+	write("rsync", "rsync")
+	pipe := write("rsync-pipe", "pipe")
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return []string{pipe}
+}
+
+func TestSFTPConnectorRsync(t *testing.T) {
+	pipe := installRsyncHelpers(t)
+	srv := newTestSSHServer(t)
+	srv.exec = fakeRemoteRsync
+
+	// a space in remote_path must survive the remote shell
+	remote := filepath.Join(t.TempDir(), "my saves")
+	writeTree(t, remote, map[string]string{
+		"world.sav":      "world-data-v2",
+		"players/1.json": "player",
+	})
+	staging := t.TempDir()
+	writeTree(t, staging, map[string]string{
+		"world.sav":         "world-data-v1",
+		"players/gone.json": "deleted on the server",
+	})
+
+	conn := newTestSFTPConnector(srv, remote, SFTPOptions{Rsync: true})
+	conn.rsyncPipe = pipe
+	ctx := context.Background()
+	if err := conn.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+
+	files, err := conn.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	ok, err := conn.SyncTo(ctx, files, staging)
+	if err != nil {
+		t.Fatalf("SyncTo: %v", err)
+	}
+	if !ok {
+		t.Fatal("SyncTo fell back to SFTP")
+	}
+
+	for name, want := range map[string]string{"world.sav": "world-data-v2", "players/1.json": "player"} {
+		if data, _ := os.ReadFile(filepath.Join(staging, name)); string(data) != want {
+			t.Errorf("%s = %q, want %q", name, data, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(staging, "players/gone.json")); !os.IsNotExist(err) {
+		t.Errorf("stale file not removed: %v", err)
+	}
+
+	cmds := srv.Commands()
+	if len(cmds) != 2 || cmds[0] != "command -v rsync" || cmds[1] != "rsync --server --sender -t . '"+remote+"/'" {
+		t.Errorf("remote commands = %q", cmds)
+	}
+}
+
+func TestSFTPConnectorRsyncFallback(t *testing.T) {
+	tests := []struct {
+		name   string
+		rsync  bool
+		remote bool // rsync installed on the host
+		local  bool // rsync installed locally
+	}{
+		{"disabled", false, true, true},
+		{"missing on host", true, false, true},
+		{"missing locally", true, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.local {
+				installRsyncHelpers(t)
+			} else {
+				t.Setenv("PATH", t.TempDir())
+			}
+			srv := newTestSSHServer(t)
+			if tt.remote {
+				srv.exec = fakeRemoteRsync
+			}
+
+			remote := t.TempDir()
+			writeTree(t, remote, map[string]string{"world.sav": "world-data"})
+			staging := t.TempDir()
+
+			conn := newTestSFTPConnector(srv, remote, SFTPOptions{Rsync: tt.rsync})
+			ctx := context.Background()
+			if err := conn.Connect(ctx); err != nil {
+				t.Fatalf("Connect: %v", err)
+			}
+			defer conn.Close()
+
+			ok, err := conn.SyncTo(ctx, []FileInfo{{Path: "world.sav", Size: 10}}, staging)
+			if ok || err != nil {
+				t.Fatalf("SyncTo = %v, %v; want fallback", ok, err)
+			}
+			if entries, _ := os.ReadDir(staging); len(entries) != 0 {
+				t.Errorf("staging touched on fallback: %v", entries)
+			}
+		})
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"/usr/bin/rsync":      "/usr/bin/rsync",
+		"/opt/my tools/rsync": "'/opt/my tools/rsync'",
+		"it's":                `'it'\''s'`,
+	}
+	for in, want := range tests {
+		if got := shellQuote(in); got != want {
+			t.Errorf("shellQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
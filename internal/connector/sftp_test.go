@@ -2,16 +2,24 @@
 package connector
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
 func TestNewSFTPConnector(t *testing.T) {
 	cfg := Config{
-		Type:       "sftp",
-		Host:       "localhost",
-		Port:       22,
-		Username:   "user",
-		Password:   "pass",
+		Type: "sftp",
+		Options: &SFTPOptions{
+			Host:     "localhost",
+			Port:     22,
+			Username: "user",
+			Password: "pass",
+		},
 		RemotePath: "/home/user/saves/",
 		Include:    []string{"*"},
 		Exclude:    []string{"*.log"},
@@ -29,11 +37,13 @@ func TestNewSFTPConnector(t *testing.T) {
 
 func TestNewSFTPConnectorWithKeyFile(t *testing.T) {
 	cfg := Config{
-		Type:       "sftp",
-		Host:       "localhost",
-		Port:       22,
-		Username:   "user",
-		KeyFile:    "/home/user/.ssh/id_rsa",
+		Type: "sftp",
+		Options: &SFTPOptions{
+			Host:     "localhost",
+			Port:     22,
+			Username: "user",
+			KeyFile:  "/home/user/.ssh/id_rsa",
+		},
 		RemotePath: "/saves/",
 	}
 
@@ -42,3 +52,88 @@ func TestNewSFTPConnectorWithKeyFile(t *testing.T) {
 		t.Fatal("NewSFTPConnector returned nil")
 	}
 }
+
+// writeTree creates files (relative path -> content) below dir
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func newTestSFTPConnector(srv *testSSHServer, remote string, opts SFTPOptions) *SFTPConnector {
+	opts.Host = "127.0.0.1"
+	opts.Port = srv.Port()
+	opts.Username = "user"
+	opts.Password = "pass"
+	return NewSFTPConnector(Config{Type: "sftp", RemotePath: remote, Exclude: []string{"logs/"}, Options: &opts})
+}
+
+func TestSFTPConnector(t *testing.T) {
+	srv := newTestSSHServer(t)
+	remote := t.TempDir()
+	writeTree(t, remote, map[string]string{
+		"world.sav":       "world-data",
+		"players/1.json":  "player",
+		"logs/server.log": "log",
+	})
+
+	conn := newTestSFTPConnector(srv, remote, SFTPOptions{})
+	ctx := context.Background()
+	if err := conn.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+
+	files, err := conn.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	got := map[string]int64{}
+	for _, f := range files {
+		got[f.Path] = f.Size
+	}
+	want := map[string]int64{"world.sav": 10, "players/1.json": 6}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("List = %v, want %v", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := conn.Download(ctx, "world.sav", &buf); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if buf.String() != "world-data" {
+		t.Errorf("downloaded %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := conn.DownloadFrom(ctx, "world.sav", 6, &buf); err != nil {
+		t.Fatalf("DownloadFrom: %v", err)
+	}
+	if buf.String() != "data" {
+		t.Errorf("resumed download %q", buf.String())
+	}
+
+	if err := conn.Upload(ctx, strings.NewReader("restored"), "new/restored.sav"); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(remote, "new/restored.sav")); string(data) != "restored" {
+		t.Errorf("uploaded %q", data)
+	}
+}
+
+func TestSFTPConnectorBadCredentials(t *testing.T) {
+	srv := newTestSSHServer(t)
+	conn := newTestSFTPConnector(srv, "/", SFTPOptions{})
+	conn.opts.Password = "wrong"
+	if err := conn.Connect(context.Background()); err == nil {
+		conn.Close()
+		t.Fatal("expected authentication error")
+	}
+}
@@ -0,0 +1,269 @@
+// internal/connector/smb.go
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/cloudsoda/go-smb2"
+)
+
+func init() {
+	Register(Registration{
+		Type:       "smb",
+		NewOptions: func() Options { return &SMBOptions{} },
+		New: func(cfg Config) (Connector, error) {
+			return NewSMBConnector(cfg), nil
+		},
+	})
+}
+
+// SMBOptions holds SMB/CIFS connection settings
+type SMBOptions struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port,omitempty"`
+	Share    string `yaml:"share"`
+	Domain   string `yaml:"domain,omitempty"`
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+}
+
+// Validate checks required SMB settings
+func (o *SMBOptions) Validate() error {
+	if o.Host == "" {
+		return fmt.Errorf("connection.host is required")
+	}
+	if o.Share == "" {
+		return fmt.Errorf("connection.share is required")
+	}
+	if strings.ContainsAny(o.Share, `/\`) {
+		return fmt.Errorf("connection.share must be a share name such as Saves, got %q", o.Share)
+	}
+	return nil
+}
+
+// smbShare is the part of a mounted share the connector uses. Paths are
+// relative to the share root and use "/" as separator.
+type smbShare interface {
+	ReadDir(ctx context.Context, dir string) ([]os.FileInfo, error)
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+	Create(ctx context.Context, name string) (io.WriteCloser, error)
+	MkdirAll(ctx context.Context, dir string) error
+	Close() error
+}
+
+// SMBConnector implements Connector for SMB2/3 shares without mounting them
+type SMBConnector struct {
+	config Config
+	opts   *SMBOptions
+	share  smbShare
+
+	// mount opens the share; replaced in tests
+	mount func(ctx context.Context) (smbShare, error)
+}
+
+// NewSMBConnector creates a new SMB connector
+func NewSMBConnector(cfg Config) *SMBConnector {
+	opts := *optionsAs[SMBOptions](cfg)
+	if opts.Port == 0 {
+		opts.Port = 445
+	}
+	cfg.Options = &opts
+	c := &SMBConnector{config: cfg, opts: &opts}
+	c.mount = c.dial
+	return c
+}
+
+// Name returns the connector name for logging
+func (c *SMBConnector) Name() string {
+	return fmt.Sprintf("smb://%s:%d/%s", c.opts.Host, c.opts.Port, c.opts.Share)
+}
+
+// Connect authenticates with NTLM and mounts the share
+func (c *SMBConnector) Connect(ctx context.Context) error {
+	share, err := c.mount(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMB: %w", err)
+	}
+	c.share = share
+	return nil
+}
+
+func (c *SMBConnector) dial(ctx context.Context) (smbShare, error) {
+	addr := net.JoinHostPort(c.opts.Host, strconv.Itoa(c.opts.Port))
+	conn, err := (&net.Dialer{Timeout: c.config.connectTimeout()}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &smb2.Dialer{
+		Initiator: &smb2.NTLMInitiator{
+			User:     c.opts.Username,
+			Password: c.opts.Password,
+			Domain:   c.opts.Domain,
+		},
+	}
+	session, err := d.DialConn(ctx, conn, addr)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	share, err := session.WithContext(ctx).Mount(c.opts.Share)
+	if err != nil {
+		session.Logoff()
+		conn.Close()
+		return nil, fmt.Errorf("failed to mount share %s: %w", c.opts.Share, err)
+	}
+
+	return &smb2Share{conn: conn, session: session, share: share}, nil
+}
+
+// root returns remote_path relative to the share root
+func (c *SMBConnector) root() string {
+	return strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(c.config.RemotePath, `\`, "/")), "/")
+}
+
+// List returns files at remote_path matching include/exclude patterns
+func (c *SMBConnector) List(ctx context.Context) ([]FileInfo, error) {
+	if c.share == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	var files []FileInfo
+	if err := c.walkDir(ctx, "", &files); err != nil {
+		return nil, err
+	}
+
+	var filtered []FileInfo
+	for _, f := range files {
+		if MatchesPatterns(f.Path, c.config.Include, c.config.Exclude) {
+			filtered = append(filtered, f)
+		}
+	}
+	return filtered, nil
+}
+
+// walkDir collects files below rel (relative to remote_path)
+func (c *SMBConnector) walkDir(ctx context.Context, rel string, files *[]FileInfo) error {
+	dir := path.Join(c.root(), rel)
+	entries, err := c.share.ReadDir(ctx, dir)
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		relPath := path.Join(rel, entry.Name())
+		if entry.IsDir() {
+			if err := c.walkDir(ctx, relPath, files); err != nil {
+				return err
+			}
+			continue
+		}
+		*files = append(*files, FileInfo{
+			Path:    relPath,
+			Size:    entry.Size(),
+			ModTime: entry.ModTime(),
+		})
+	}
+	return nil
+}
+
+// Download retrieves a file from the share
+func (c *SMBConnector) Download(ctx context.Context, remotePath string, w io.Writer) error {
+	return c.DownloadFrom(ctx, remotePath, 0, w)
+}
+
+// DownloadFrom retrieves a file from the share starting at offset
+func (c *SMBConnector) DownloadFrom(ctx context.Context, remotePath string, offset int64, w io.Writer) error {
+	if c.share == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	f, err := c.share.Open(ctx, path.Join(c.root(), remotePath))
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", remotePath, err)
+	}
+	defer f.Close()
+
+	if offset > 0 {
+		if seeker, ok := f.(io.Seeker); ok {
+			if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+				return fmt.Errorf("failed to seek %s: %w", remotePath, err)
+			}
+		} else {
+			w = &skipWriter{w: w, n: offset}
+		}
+	}
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// Upload writes a file to the share, creating parent directories
+func (c *SMBConnector) Upload(ctx context.Context, r io.Reader, remotePath string) error {
+	if c.share == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	fullPath := path.Join(c.root(), remotePath)
+	r = c.config.Bandwidth.Reader(ctx, r)
+	if dir := path.Dir(fullPath); dir != "." {
+		if err := c.share.MkdirAll(ctx, dir); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+
+	f, err := c.share.Create(ctx, fullPath)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", remotePath, err)
+	}
+	_, err = io.Copy(f, r)
+	return errors.Join(err, f.Close())
+}
+
+// Close unmounts the share and logs off
+func (c *SMBConnector) Close() error {
+	if c.share == nil {
+		return nil
+	}
+	err := c.share.Close()
+	c.share = nil
+	return err
+}
+
+// smb2Share adapts a go-smb2 session and share to smbShare
+type smb2Share struct {
+	conn    net.Conn
+	session *smb2.Session
+	share   *smb2.Share
+}
+
+func (s *smb2Share) ReadDir(ctx context.Context, dir string) ([]os.FileInfo, error) {
+	return s.share.WithContext(ctx).ReadDir(dir)
+}
+
+func (s *smb2Share) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	return s.share.WithContext(ctx).Open(name)
+}
+
+func (s *smb2Share) Create(ctx context.Context, name string) (io.WriteCloser, error) {
+	return s.share.WithContext(ctx).Create(name)
+}
+
+func (s *smb2Share) MkdirAll(ctx context.Context, dir string) error {
+	return s.share.WithContext(ctx).MkdirAll(dir, 0o755)
+}
+
+func (s *smb2Share) Close() error {
+	err := errors.Join(s.share.Umount(), s.session.Logoff())
+	s.conn.Close()
+	return err
+}
@@ -0,0 +1,63 @@
+//go:build integration
+
+// internal/connector/smb_integration_test.go
+package connector
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// smbIntegrationOptions reads the server to test against, e.g. a Samba
+// container, from GSBT_TEST_SMB_*. Run with go test -tags integration.
+func smbIntegrationOptions(t *testing.T) SMBOptions {
+	t.Helper()
+	host := os.Getenv("GSBT_TEST_SMB_HOST")
+	if host == "" {
+		t.Fatal("GSBT_TEST_SMB_HOST is required with -tags integration")
+	}
+	port, _ := strconv.Atoi(os.Getenv("GSBT_TEST_SMB_PORT"))
+	return SMBOptions{
+		Host:     host,
+		Port:     port,
+		Share:    os.Getenv("GSBT_TEST_SMB_SHARE"),
+		Domain:   os.Getenv("GSBT_TEST_SMB_DOMAIN"),
+		Username: os.Getenv("GSBT_TEST_SMB_USER"),
+		Password: os.Getenv("GSBT_TEST_SMB_PASS"),
+	}
+}
+
+// TestSMBConnectorIntegration runs against a real server through go-smb2:
+// NTLM login, mounting, listing, seeking for resumed downloads and MkdirAll
+// for uploads. The share must contain the files described in
+// exerciseSMBConnector.
+func TestSMBConnectorIntegration(t *testing.T) {
+	opts := smbIntegrationOptions(t)
+	exerciseSMBConnector(t, NewSMBConnector(Config{
+		Type:       "smb",
+		RemotePath: "ShooterGame/Saved",
+		Exclude:    []string{"Logs/"},
+		Options:    &opts,
+	}))
+}
+
+func TestSMBConnectorIntegrationErrors(t *testing.T) {
+	ctx := context.Background()
+
+	opts := smbIntegrationOptions(t)
+	opts.Share = "gsbt-missing-share"
+	conn := NewSMBConnector(Config{Type: "smb", Options: &opts})
+	if err := conn.Connect(ctx); err == nil || !strings.Contains(err.Error(), "failed to mount share gsbt-missing-share") {
+		t.Errorf("missing share: %v", err)
+	}
+
+	opts = smbIntegrationOptions(t)
+	opts.Password += "-wrong"
+	conn = NewSMBConnector(Config{Type: "smb", Options: &opts})
+	if err := conn.Connect(ctx); err == nil || strings.Contains(err.Error(), "failed to mount") {
+		t.Errorf("wrong password: %v, want a login error", err)
+	}
+}
@@ -0,0 +1,252 @@
+// internal/connector/smb_test.go
+package connector
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudsoda/go-smb2"
+)
+
+// localShare is an in-process stand-in for a mounted SMB share backed by a
+// local directory
+type localShare struct {
+	root   string
+	closed bool
+	// noSeek hides io.Seeker on opened files
+	noSeek bool
+}
+
+func (s *localShare) path(name string) string {
+	return filepath.Join(s.root, filepath.FromSlash(name))
+}
+
+func (s *localShare) ReadDir(_ context.Context, dir string) ([]os.FileInfo, error) {
+	entries, err := os.ReadDir(s.path(dir))
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (s *localShare) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	f, err := os.Open(s.path(name))
+	if err != nil || !s.noSeek {
+		return f, err
+	}
+	return struct{ io.ReadCloser }{f}, nil
+}
+
+func (s *localShare) Create(_ context.Context, name string) (io.WriteCloser, error) {
+	return os.Create(s.path(name))
+}
+
+func (s *localShare) MkdirAll(_ context.Context, dir string) error {
+	return os.MkdirAll(s.path(dir), 0o755)
+}
+
+func (s *localShare) Close() error {
+	s.closed = true
+	return nil
+}
+
+func writeShareFile(t *testing.T, root, name, data string, modTime time.Time) {
+	t.Helper()
+	p := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(p, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// exerciseSMBConnector runs List, Download(From) and Upload against a share
+// containing ShooterGame/Saved/{TheIsland.ark,Logs/server.log}
+func exerciseSMBConnector(t *testing.T, conn *SMBConnector) {
+	t.Helper()
+	ctx := context.Background()
+
+	if err := conn.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+
+	files, err := conn.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "TheIsland.ark" || files[0].Size != 8 {
+		t.Fatalf("unexpected listing: %+v", files)
+	}
+
+	var buf bytes.Buffer
+	if err := conn.Download(ctx, "TheIsland.ark", &buf); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if buf.String() != "ark-save" {
+		t.Errorf("downloaded %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := conn.DownloadFrom(ctx, "TheIsland.ark", 4, &buf); err != nil {
+		t.Fatalf("DownloadFrom: %v", err)
+	}
+	if buf.String() != "save" {
+		t.Errorf("resumed download %q", buf.String())
+	}
+
+	if err := conn.Upload(ctx, strings.NewReader("restored"), "Restore/TheIsland.ark"); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	buf.Reset()
+	if err := conn.Download(ctx, "Restore/TheIsland.ark", &buf); err != nil {
+		t.Fatalf("Download uploaded file: %v", err)
+	}
+	if buf.String() != "restored" {
+		t.Errorf("round-tripped %q", buf.String())
+	}
+}
+
+func TestSMBConnector(t *testing.T) {
+	root := t.TempDir()
+	modTime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	writeShareFile(t, root, "ShooterGame/Saved/TheIsland.ark", "ark-save", modTime)
+	writeShareFile(t, root, "ShooterGame/Saved/Logs/server.log", "log", modTime)
+
+	conn := NewSMBConnector(Config{
+		Type:       "smb",
+		RemotePath: `\ShooterGame\Saved`,
+		Exclude:    []string{"Logs/"},
+		Options:    &SMBOptions{Host: "winbox", Share: "Games", Username: "backup"},
+	})
+	share := &localShare{root: root}
+	conn.mount = func(context.Context) (smbShare, error) { return share, nil }
+
+	exerciseSMBConnector(t, conn)
+
+	if !share.closed {
+		t.Error("expected share to be closed")
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "ShooterGame", "Saved", "Restore", "TheIsland.ark")); err != nil || string(data) != "restored" {
+		t.Errorf("uploaded file: %q, %v", data, err)
+	}
+}
+
+func TestSMBConnectorConnectError(t *testing.T) {
+	// Nothing listens on a port we just released, so the dial fails
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	conn := NewSMBConnector(Config{Type: "smb", Options: &SMBOptions{Host: "127.0.0.1", Port: port, Share: "Games"}})
+	if err := conn.Connect(context.Background()); err == nil || !strings.Contains(err.Error(), "failed to connect to SMB") {
+		t.Fatalf("expected connect error, got %v", err)
+	}
+	if _, err := conn.List(context.Background()); err == nil {
+		t.Error("expected List to fail when not connected")
+	}
+}
+
+func TestSMBConnectorResumeWithoutSeek(t *testing.T) {
+	root := t.TempDir()
+	writeShareFile(t, root, "saves/world.sav", "world-data", time.Now())
+
+	conn := NewSMBConnector(Config{Type: "smb", RemotePath: "saves", Options: &SMBOptions{Host: "winbox", Share: "Games"}})
+	conn.mount = func(context.Context) (smbShare, error) { return &localShare{root: root, noSeek: true}, nil }
+	ctx := context.Background()
+	if err := conn.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := conn.DownloadFrom(ctx, "world.sav", 6, &buf); err != nil || buf.String() != "data" {
+		t.Errorf("DownloadFrom = %q, %v, want the bytes after the offset", buf.String(), err)
+	}
+}
+
+func TestSMB2ShareSeeks(t *testing.T) {
+	// go-smb2 files can seek, so a resumed download skips nothing over the wire
+	var f io.ReadCloser = (*smb2.File)(nil)
+	if _, ok := f.(io.Seeker); !ok {
+		t.Error("smb2.File does not implement io.Seeker")
+	}
+}
+
+func TestSMBConnectorHandshakeTimeout(t *testing.T) {
+	// The server accepts but never answers the SMB negotiate
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	closed := make(chan struct{})
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		io.Copy(io.Discard, c)
+		close(closed)
+	}()
+
+	conn := NewSMBConnector(Config{Type: "smb", Options: &SMBOptions{
+		Host: "127.0.0.1", Port: ln.Addr().(*net.TCPAddr).Port, Share: "Games"}})
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := conn.Connect(ctx); err == nil || !strings.Contains(err.Error(), "failed to connect to SMB") {
+		t.Fatalf("expected connect error, got %v", err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Error("the TCP connection of a failed handshake was left open")
+	}
+}
+
+func TestSMBOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    SMBOptions
+		wantErr bool
+	}{
+		{"valid", SMBOptions{Host: "h", Share: "Games"}, false},
+		{"missing host", SMBOptions{Share: "Games"}, true},
+		{"missing share", SMBOptions{Host: "h"}, true},
+		{"unc share", SMBOptions{Host: "h", Share: `\\h\Games`}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	conn := NewSMBConnector(Config{Type: "smb", Options: &SMBOptions{Host: "winbox", Share: "Games"}})
+	if conn.Name() != "smb://winbox:445/Games" {
+		t.Errorf("unexpected name: %s", conn.Name())
+	}
+}
@@ -0,0 +1,401 @@
+// internal/connector/webdav.go
+package connector
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const webdavPropfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/><d:getcontentlength/><d:getlastmodified/></d:prop></d:propfind>`
+
+func init() {
+	Register(Registration{
+		Type:       "webdav",
+		NewOptions: func() Options { return &WebDAVOptions{} },
+		New: func(cfg Config) (Connector, error) {
+			return NewWebDAVConnector(cfg), nil
+		},
+	})
+}
+
+// WebDAVOptions holds WebDAV connection settings
+type WebDAVOptions struct {
+	// URL is the WebDAV root, e.g. https://cloud.example.com/remote.php/dav/files/backup
+	URL      string `yaml:"url"`
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+
+	// Auth forces basic or digest; by default the server's challenge decides
+	Auth string `yaml:"auth,omitempty" jsonschema:"enum=basic,enum=digest"`
+
+	// Depth is the PROPFIND depth used for listing. "1" (default) walks one
+	// directory per request; "infinity" lists everything at once where allowed.
+	Depth string `yaml:"depth,omitempty" jsonschema:"enum=1,enum=infinity"`
+}
+
+// Validate checks required WebDAV settings
+func (o *WebDAVOptions) Validate() error {
+	if o.URL == "" {
+		return fmt.Errorf("connection.url is required")
+	}
+	u, err := url.Parse(o.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("connection.url must be an http(s) URL, got %q", o.URL)
+	}
+	switch o.Auth {
+	case "", "basic", "digest":
+	default:
+		return fmt.Errorf("connection.auth must be basic or digest, got %q", o.Auth)
+	}
+	switch o.Depth {
+	case "", "1", "infinity":
+	default:
+		return fmt.Errorf("connection.depth must be 1 or infinity, got %q", o.Depth)
+	}
+	return nil
+}
+
+// WebDAVConnector implements Connector for WebDAV servers
+type WebDAVConnector struct {
+	config Config
+	opts   *WebDAVOptions
+	base   *url.URL // opts.URL with remote_path appended
+	client *http.Client
+	auth   *webdavAuth
+	dirs   map[string]bool // collections known to exist, relative to base
+}
+
+// webdavStatusError is an unexpected HTTP status
+type webdavStatusError struct {
+	Method string
+	Status string
+}
+
+func (e *webdavStatusError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Method, e.Status)
+}
+
+// NewWebDAVConnector creates a new WebDAV connector
+func NewWebDAVConnector(cfg Config) *WebDAVConnector {
+	opts := optionsAs[WebDAVOptions](cfg)
+	return &WebDAVConnector{
+		config: cfg,
+		opts:   opts,
+		client: &http.Client{},
+		auth:   &webdavAuth{user: opts.Username, pass: opts.Password, mode: opts.Auth},
+	}
+}
+
+// Name returns the connector name for logging
+func (c *WebDAVConnector) Name() string {
+	if u, err := url.Parse(c.opts.URL); err == nil {
+		return fmt.Sprintf("webdav://%s%s", u.Host, u.Path)
+	}
+	return "webdav://" + c.opts.URL
+}
+
+// Connect checks the remote path and learns the server's auth scheme, so
+// later uploads (which can't be replayed) are sent with credentials
+func (c *WebDAVConnector) Connect(ctx context.Context) error {
+	u, err := url.Parse(c.opts.URL)
+	if err != nil {
+		return fmt.Errorf("invalid WebDAV url: %w", err)
+	}
+	u.Path = strings.TrimSuffix(path.Join("/", u.Path, c.config.RemotePath), "/") + "/"
+	u.RawPath = ""
+	c.base = u
+	c.dirs = map[string]bool{}
+
+	resp, err := c.do(ctx, "PROPFIND", c.resolve("", true), http.Header{"Depth": {"0"}}, strings.NewReader(webdavPropfindBody))
+	if err != nil {
+		return fmt.Errorf("failed to connect to WebDAV: %w", err)
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusMultiStatus:
+		c.dirs[""] = true
+	case http.StatusNotFound:
+		// Uploads create it; listing will report the error
+	default:
+		return fmt.Errorf("failed to connect to WebDAV: %w", &webdavStatusError{"PROPFIND", resp.Status})
+	}
+	return nil
+}
+
+// List returns files at remote_path matching include/exclude patterns
+func (c *WebDAVConnector) List(ctx context.Context) ([]FileInfo, error) {
+	if c.base == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	var files []FileInfo
+	if c.opts.Depth == "infinity" {
+		entries, err := c.propfind(ctx, "", "infinity")
+		if err != nil {
+			return nil, err
+		}
+		files = entries
+	} else {
+		queue := []string{""}
+		for len(queue) > 0 {
+			dir := queue[0]
+			queue = queue[1:]
+
+			entries, err := c.propfind(ctx, dir, "1")
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range entries {
+				if e.IsDir {
+					queue = append(queue, e.Path)
+				}
+				files = append(files, e)
+			}
+		}
+	}
+
+	var filtered []FileInfo
+	for _, f := range files {
+		if !f.IsDir && MatchesPatterns(f.Path, c.config.Include, c.config.Exclude) {
+			filtered = append(filtered, f)
+		}
+	}
+	return filtered, nil
+}
+
+type davMultistatus struct {
+	Responses []struct {
+		Href      string `xml:"DAV: href"`
+		Propstats []struct {
+			Status string `xml:"DAV: status"`
+			Prop   struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"DAV: collection"`
+				} `xml:"DAV: resourcetype"`
+				ContentLength string `xml:"DAV: getcontentlength"`
+				LastModified  string `xml:"DAV: getlastmodified"`
+			} `xml:"DAV: prop"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+// propfind lists dir (relative to base) and returns its members, excluding dir itself
+func (c *WebDAVConnector) propfind(ctx context.Context, dir, depth string) ([]FileInfo, error) {
+	resp, err := c.do(ctx, "PROPFIND", c.resolve(dir, true), http.Header{
+		"Depth":        {depth},
+		"Content-Type": {"application/xml; charset=utf-8"},
+	}, strings.NewReader(webdavPropfindBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("failed to list %s: %w", dir, &webdavStatusError{"PROPFIND", resp.Status})
+	}
+
+	var ms davMultistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("failed to list %s: invalid PROPFIND response: %w", dir, err)
+	}
+
+	var files []FileInfo
+	for _, r := range ms.Responses {
+		rel, ok := c.relPath(r.Href)
+		if !ok || rel == dir {
+			continue
+		}
+		for _, ps := range r.Propstats {
+			if !strings.Contains(ps.Status, " 200 ") {
+				continue
+			}
+			info := FileInfo{Path: rel, IsDir: ps.Prop.ResourceType.Collection != nil}
+			info.Size, _ = strconv.ParseInt(strings.TrimSpace(ps.Prop.ContentLength), 10, 64)
+			info.ModTime, _ = http.ParseTime(ps.Prop.LastModified)
+			files = append(files, info)
+			break
+		}
+	}
+	return files, nil
+}
+
+// relPath converts a PROPFIND href (absolute URL or path) to a path relative to base
+func (c *WebDAVConnector) relPath(href string) (string, bool) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", false
+	}
+	p := strings.TrimSuffix(u.Path, "/")
+	root := strings.TrimSuffix(c.base.Path, "/")
+	if p == root {
+		return "", true
+	}
+	if !strings.HasPrefix(p, root+"/") {
+		return "", false
+	}
+	return p[len(root)+1:], true
+}
+
+// resolve returns the URL of a path relative to base; collections get a trailing slash
+func (c *WebDAVConnector) resolve(rel string, collection bool) *url.URL {
+	u := *c.base
+	u.Path = path.Join(c.base.Path, rel)
+	if collection && !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return &u
+}
+
+// Download retrieves a file with GET
+func (c *WebDAVConnector) Download(ctx context.Context, remotePath string, w io.Writer) error {
+	return c.DownloadFrom(ctx, remotePath, 0, w)
+}
+
+// DownloadFrom retrieves a file from offset on with a Range request
+func (c *WebDAVConnector) DownloadFrom(ctx context.Context, remotePath string, offset int64, w io.Writer) error {
+	if c.base == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	if err := c.get(ctx, remotePath, offset, w); err != nil {
+		return fmt.Errorf("failed to download %s: %w", remotePath, err)
+	}
+	return nil
+}
+
+// get writes the file from offset on
+func (c *WebDAVConnector) get(ctx context.Context, remotePath string, offset int64, w io.Writer) error {
+	header := http.Header{}
+	if offset > 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := c.do(ctx, http.MethodGet, c.resolve(remotePath, false), header, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+	case resp.StatusCode == http.StatusOK:
+		// The server ignored the Range header; skip what we already have
+		if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil {
+			return err
+		}
+	default:
+		return &webdavStatusError{"GET", resp.Status}
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// Upload sends a file with PUT, creating missing parent collections first
+func (c *WebDAVConnector) Upload(ctx context.Context, r io.Reader, remotePath string) error {
+	if c.base == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	if err := c.mkcolAll(ctx, path.Dir(path.Clean(remotePath))); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", remotePath, err)
+	}
+
+	resp, err := c.do(ctx, http.MethodPut, c.resolve(remotePath, false), nil, c.config.Bandwidth.Reader(ctx, r))
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", remotePath, err)
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return nil
+	default:
+		return fmt.Errorf("failed to upload %s: %w", remotePath, &webdavStatusError{"PUT", resp.Status})
+	}
+}
+
+// mkcolAll creates dir and its parents below (and including) the base collection
+func (c *WebDAVConnector) mkcolAll(ctx context.Context, dir string) error {
+	if dir == "." || dir == "/" {
+		dir = ""
+	}
+	if c.dirs[dir] {
+		return nil
+	}
+	if dir != "" {
+		if err := c.mkcolAll(ctx, path.Dir(dir)); err != nil {
+			return err
+		}
+	}
+
+	resp, err := c.do(ctx, "MKCOL", c.resolve(dir, true), nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	// 405 Method Not Allowed means the collection already exists
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+		return &webdavStatusError{"MKCOL " + dir, resp.Status}
+	}
+	c.dirs[dir] = true
+	return nil
+}
+
+// Close releases idle connections
+func (c *WebDAVConnector) Close() error {
+	c.client.CloseIdleConnections()
+	return nil
+}
+
+// do sends a request with credentials. A 401 teaches the auth helper the
+// server's scheme and the request is retried once if its body can be replayed.
+func (c *WebDAVConnector) do(ctx context.Context, method string, u *url.URL, header http.Header, body io.Reader) (*http.Response, error) {
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		c.auth.apply(req)
+		return req, nil
+	}
+
+	req, err := newRequest()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized || !c.auth.challenge(resp) {
+		return resp, nil
+	}
+	if body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	if body != nil {
+		if body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	if req, err = newRequest(); err != nil {
+		return nil, err
+	}
+	return c.client.Do(req)
+}
@@ -0,0 +1,167 @@
+// internal/connector/webdav_auth.go
+package connector
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// webdavAuth adds basic or digest (RFC 7616) credentials to requests
+type webdavAuth struct {
+	user string
+	pass string
+	mode string // "basic", "digest" or "" until the server challenges us
+
+	mu     sync.Mutex
+	digest map[string]string // last digest challenge parameters
+	nc     int
+}
+
+// apply sets the Authorization header if the scheme is known
+func (a *webdavAuth) apply(req *http.Request) {
+	if a.user == "" {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch {
+	case a.mode == "basic":
+		req.SetBasicAuth(a.user, a.pass)
+	case a.digest != nil:
+		req.Header.Set("Authorization", a.digestHeader(req.Method, req.URL.RequestURI()))
+	}
+}
+
+// challenge learns from a 401 response. It returns true if a retry with
+// the updated credentials can succeed.
+func (a *webdavAuth) challenge(resp *http.Response) bool {
+	if a.user == "" {
+		return false
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, h := range resp.Header.Values("WWW-Authenticate") {
+		scheme, params, _ := strings.Cut(h, " ")
+		switch {
+		case strings.EqualFold(scheme, "Digest") && a.mode != "basic":
+			ch := parseAuthParams(params)
+			// A rejected digest is only worth retrying if the nonce went stale
+			if a.digest != nil && !strings.EqualFold(ch["stale"], "true") {
+				return false
+			}
+			a.mode = "digest"
+			a.digest = ch
+			a.nc = 0
+			return true
+		case strings.EqualFold(scheme, "Basic") && a.mode == "":
+			a.mode = "basic"
+			return true
+		}
+	}
+	return false
+}
+
+// digestHeader computes the Authorization header for one request
+func (a *webdavAuth) digestHeader(method, uri string) string {
+	ch := a.digest
+	a.nc++
+
+	algorithm := ch["algorithm"]
+	if algorithm == "" {
+		algorithm = "MD5"
+	}
+	var newHash func() hash.Hash = md5.New
+	if strings.HasPrefix(strings.ToUpper(algorithm), "SHA-256") {
+		newHash = sha256.New
+	}
+	h := func(parts ...string) string {
+		sum := newHash()
+		sum.Write([]byte(strings.Join(parts, ":")))
+		return hex.EncodeToString(sum.Sum(nil))
+	}
+
+	cnonceBytes := make([]byte, 8)
+	rand.Read(cnonceBytes)
+	cnonce := hex.EncodeToString(cnonceBytes)
+	nc := fmt.Sprintf("%08x", a.nc)
+
+	ha1 := h(a.user, ch["realm"], a.pass)
+	if strings.HasSuffix(strings.ToLower(algorithm), "-sess") {
+		ha1 = h(ha1, ch["nonce"], cnonce)
+	}
+	ha2 := h(method, uri)
+
+	qop := ""
+	for _, q := range strings.Split(ch["qop"], ",") {
+		if strings.TrimSpace(q) == "auth" {
+			qop = "auth"
+		}
+	}
+
+	var response string
+	if qop != "" {
+		response = h(ha1, ch["nonce"], nc, cnonce, qop, ha2)
+	} else {
+		response = h(ha1, ch["nonce"], ha2)
+	}
+
+	header := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s", algorithm=%s, response="%s"`,
+		a.user, ch["realm"], ch["nonce"], uri, algorithm, response)
+	if qop != "" {
+		header += fmt.Sprintf(`, qop=%s, nc=%s, cnonce="%s"`, qop, nc, cnonce)
+	}
+	if opaque, ok := ch["opaque"]; ok {
+		header += fmt.Sprintf(`, opaque="%s"`, opaque)
+	}
+	return header
+}
+
+// parseAuthParams parses comma separated key=value pairs where values may
+// be quoted strings containing commas
+func parseAuthParams(s string) map[string]string {
+	params := map[string]string{}
+	for {
+		s = strings.TrimLeft(s, " ,")
+		if s == "" {
+			return params
+		}
+		eq := strings.IndexByte(s, '=')
+		if eq == -1 {
+			return params
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			value = strings.ReplaceAll(s[1:min(end, len(s))], `\`, "")
+			s = s[min(end+1, len(s)):]
+		} else {
+			end := strings.IndexByte(s, ',')
+			if end == -1 {
+				end = len(s)
+			}
+			value = strings.TrimSpace(s[:end])
+			s = s[end:]
+		}
+		params[key] = value
+	}
+}
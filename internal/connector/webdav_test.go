@@ -0,0 +1,278 @@
+// internal/connector/webdav_test.go
+package connector
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/webdav"
+)
+
+// testWebDAVServer wraps the x/net/webdav handler with authentication and
+// a switch to cut the first GET of a file short
+type testWebDAVServer struct {
+	*httptest.Server
+	fs       webdav.FileSystem
+	auth     string // "", "basic" or "digest"
+	truncate string // path whose first full GET is aborted halfway
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func newTestWebDAVServer(t *testing.T, auth string) *testWebDAVServer {
+	t.Helper()
+
+	s := &testWebDAVServer{fs: webdav.NewMemFS(), auth: auth}
+	dav := &webdav.Handler{FileSystem: s.fs, LockSystem: webdav.NewMemLS()}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			if s.auth == "digest" {
+				w.Header().Set("WWW-Authenticate", `Digest realm="gsbt", nonce="n0nce", qop="auth,auth-int", opaque="0paque", algorithm=MD5`)
+			} else {
+				w.Header().Set("WWW-Authenticate", `Basic realm="gsbt"`)
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		s.mu.Lock()
+		s.requests = append(s.requests, strings.TrimSpace(r.Method+" "+r.URL.Path+" "+r.Header.Get("Range")))
+		cut := r.Method == http.MethodGet && r.URL.Path == s.truncate && r.Header.Get("Range") == ""
+		if cut {
+			s.truncate = ""
+		}
+		s.mu.Unlock()
+
+		if cut {
+			f, err := s.fs.OpenFile(r.Context(), r.URL.Path, os.O_RDONLY, 0)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			defer f.Close()
+			info, _ := f.Stat()
+			half := make([]byte, info.Size()/2)
+			f.Read(half)
+
+			w.Header().Set("Content-Length", fmt.Sprint(info.Size()))
+			w.WriteHeader(http.StatusOK)
+			w.Write(half)
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+
+		dav.ServeHTTP(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *testWebDAVServer) authorized(r *http.Request) bool {
+	switch s.auth {
+	case "basic":
+		user, pass, ok := r.BasicAuth()
+		return ok && user == "user" && pass == "pass"
+	case "digest":
+		scheme, params, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		if scheme != "Digest" {
+			return false
+		}
+		p := parseAuthParams(params)
+		md5hex := func(s string) string {
+			sum := md5.Sum([]byte(s))
+			return hex.EncodeToString(sum[:])
+		}
+		ha1 := md5hex("user:gsbt:pass")
+		ha2 := md5hex(r.Method + ":" + p["uri"])
+		want := md5hex(strings.Join([]string{ha1, "n0nce", p["nc"], p["cnonce"], "auth", ha2}, ":"))
+		return p["username"] == "user" && p["uri"] == r.URL.RequestURI() && p["opaque"] == "0paque" && p["response"] == want
+	}
+	return true
+}
+
+func (s *testWebDAVServer) put(t *testing.T, name, data string) {
+	t.Helper()
+	ctx := context.Background()
+	parts := strings.Split(strings.Trim(name, "/"), "/")
+	for i := 1; i < len(parts); i++ {
+		s.fs.Mkdir(ctx, "/"+strings.Join(parts[:i], "/"), 0o755)
+	}
+	f, err := s.fs.OpenFile(ctx, name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		t.Fatalf("create %s: %v", name, err)
+	}
+	f.Write([]byte(data))
+	f.Close()
+}
+
+func (s *testWebDAVServer) get(t *testing.T, name string) string {
+	t.Helper()
+	f, err := s.fs.OpenFile(context.Background(), name, os.O_RDONLY, 0)
+	if err != nil {
+		t.Fatalf("open %s: %v", name, err)
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	buf.ReadFrom(f)
+	return buf.String()
+}
+
+func (s *testWebDAVServer) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func TestWebDAVConnector(t *testing.T) {
+	for _, auth := range []string{"", "basic", "digest"} {
+		for _, depth := range []string{"", "infinity"} {
+			t.Run(fmt.Sprintf("auth=%s depth=%s", auth, depth), func(t *testing.T) {
+				srv := newTestWebDAVServer(t, auth)
+				srv.put(t, "/saves/world.sav", "world-data")
+				srv.put(t, "/saves/Saved Games/slot 1.sav", "slot-1")
+				srv.put(t, "/saves/logs/server.log", "log")
+
+				conn := NewWebDAVConnector(Config{
+					Type:       "webdav",
+					RemotePath: "/saves",
+					Exclude:    []string{"logs/"},
+					Options:    &WebDAVOptions{URL: srv.URL, Username: "user", Password: "pass", Depth: depth},
+				})
+				ctx := context.Background()
+				if err := conn.Connect(ctx); err != nil {
+					t.Fatalf("Connect: %v", err)
+				}
+				defer conn.Close()
+
+				files, err := conn.List(ctx)
+				if err != nil {
+					t.Fatalf("List: %v", err)
+				}
+				got := map[string]int64{}
+				for _, f := range files {
+					got[f.Path] = f.Size
+					if time.Since(f.ModTime) > time.Minute {
+						t.Errorf("%s: unexpected mod time %v", f.Path, f.ModTime)
+					}
+				}
+				want := map[string]int64{"world.sav": 10, "Saved Games/slot 1.sav": 6}
+				if fmt.Sprint(got) != fmt.Sprint(want) {
+					t.Errorf("List = %v, want %v", got, want)
+				}
+
+				var buf bytes.Buffer
+				if err := conn.Download(ctx, "Saved Games/slot 1.sav", &buf); err != nil {
+					t.Fatalf("Download: %v", err)
+				}
+				if buf.String() != "slot-1" {
+					t.Errorf("downloaded %q", buf.String())
+				}
+
+				if err := conn.Upload(ctx, strings.NewReader("restored"), "new/dir/restored.sav"); err != nil {
+					t.Fatalf("Upload: %v", err)
+				}
+				if data := srv.get(t, "/saves/new/dir/restored.sav"); data != "restored" {
+					t.Errorf("uploaded %q", data)
+				}
+			})
+		}
+	}
+}
+
+func TestWebDAVConnectorResumesDownload(t *testing.T) {
+	srv := newTestWebDAVServer(t, "basic")
+	payload := strings.Repeat("0123456789", 10_000)
+	srv.put(t, "/big.bin", payload)
+	srv.truncate = "/big.bin"
+
+	conn := NewWebDAVConnector(Config{
+		Type:       "webdav",
+		RemotePath: "/",
+		Options:    &WebDAVOptions{URL: srv.URL, Username: "user", Password: "pass", Auth: "basic"},
+	})
+	ctx := context.Background()
+	if err := conn.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+
+	// the manager retries a cut download from what it received
+	var buf bytes.Buffer
+	if err := conn.Download(ctx, "big.bin", &buf); err == nil {
+		t.Fatal("Download of a cut response succeeded")
+	}
+	if err := conn.DownloadFrom(ctx, "big.bin", int64(buf.Len()), &buf); err != nil {
+		t.Fatalf("DownloadFrom: %v", err)
+	}
+	if buf.String() != payload {
+		t.Fatalf("resumed download mismatch: got %d bytes", buf.Len())
+	}
+
+	ranged := false
+	for _, r := range srv.Requests() {
+		if r == fmt.Sprintf("GET /big.bin bytes=%d-", len(payload)/2) {
+			ranged = true
+		}
+	}
+	if !ranged {
+		t.Errorf("expected a Range request, got %v", srv.Requests())
+	}
+
+	buf.Reset()
+	if err := conn.DownloadFrom(ctx, "big.bin", 99_990, &buf); err != nil {
+		t.Fatalf("DownloadFrom: %v", err)
+	}
+	if buf.String() != "0123456789" {
+		t.Errorf("DownloadFrom = %q", buf.String())
+	}
+}
+
+func TestWebDAVConnectorBadCredentials(t *testing.T) {
+	for _, auth := range []string{"basic", "digest"} {
+		t.Run(auth, func(t *testing.T) {
+			srv := newTestWebDAVServer(t, auth)
+			conn := NewWebDAVConnector(Config{
+				Type:    "webdav",
+				Options: &WebDAVOptions{URL: srv.URL, Username: "user", Password: "wrong"},
+			})
+			err := conn.Connect(context.Background())
+			if err == nil || !strings.Contains(err.Error(), "401") {
+				t.Fatalf("expected 401 error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWebDAVOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    WebDAVOptions
+		wantErr bool
+	}{
+		{"valid", WebDAVOptions{URL: "https://dav.example.com/files"}, false},
+		{"missing url", WebDAVOptions{}, true},
+		{"not http", WebDAVOptions{URL: "ftp://dav.example.com"}, true},
+		{"bad auth", WebDAVOptions{URL: "https://h", Auth: "ntlm"}, true},
+		{"bad depth", WebDAVOptions{URL: "https://h", Depth: "2"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,508 @@
+// internal/ftp/client.go
+// Package ftp is a small FTP/FTPS client covering what the connectors need:
+// explicit and implicit TLS, passive (EPSV/PASV) and active (PORT/EPRT) data
+// connections, MLSD/MLST listings with a LIST fallback and resumable RETR.
+package ftp
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds dialing, waiting for data connections and each
+// exchange on the control connection
+const DefaultTimeout = 30 * time.Second
+
+// errInterrupted is returned by commands sent after Interrupt
+var errInterrupted = errors.New("connection interrupted")
+
+// PassiveMode selects the command used to open passive data connections
+type PassiveMode string
+
+const (
+	// PassiveAuto tries EPSV first and falls back to PASV for the rest of the session
+	PassiveAuto PassiveMode = ""
+	PassiveEPSV PassiveMode = "epsv"
+	PassivePASV PassiveMode = "pasv"
+)
+
+// Config describes how to reach an FTP server
+type Config struct {
+	// Address is host:port of the control connection
+	Address string
+
+	// TLSConfig enables FTPS. Without ImplicitTLS the session is upgraded
+	// with AUTH TLS; data connections are always protected (PROT P).
+	TLSConfig   *tls.Config
+	ImplicitTLS bool
+
+	// Active makes the server connect back to us (PORT/EPRT) instead of
+	// the other way round
+	Active bool
+
+	// PassiveMode forces EPSV or PASV; ignored in active mode
+	PassiveMode PassiveMode
+
+	// ActivePortMin and ActivePortMax restrict the local ports used in
+	// active mode. Zero means any free port.
+	ActivePortMin int
+	ActivePortMax int
+
+	// ActiveAddress is the IP announced in PORT/EPRT, for clients behind NAT.
+	// It defaults to the local address of the control connection.
+	ActiveAddress string
+
+	// Timeout bounds dialing, accepting data connections and waiting for a
+	// reply on the control connection (DefaultTimeout if zero)
+	Timeout time.Duration
+}
+
+// Conn is an FTP control connection. It is not safe for concurrent use,
+// except for Interrupt.
+type Conn struct {
+	cfg      Config
+	conn     net.Conn
+	text     *textproto.Reader
+	features map[string]string
+	skipEPSV bool
+
+	// data is the latest data connection, guarded by mu for Interrupt
+	mu          sync.Mutex
+	data        net.Conn
+	interrupted bool
+}
+
+// Dial connects to the server, reads the greeting and negotiates explicit TLS
+func Dial(ctx context.Context, cfg Config) (*Conn, error) {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if cfg.TLSConfig != nil && cfg.ImplicitTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: cfg.TLSConfig}).DialContext(ctx, "tcp", cfg.Address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", cfg.Address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Conn{cfg: cfg, features: map[string]string{}}
+	c.setConn(conn)
+
+	if _, _, err := c.response(ctx, 2); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if cfg.TLSConfig != nil && !cfg.ImplicitTLS {
+		if _, _, err := c.cmd(ctx, 2, "AUTH TLS"); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("AUTH TLS: %w", err)
+		}
+		tlsConn := tls.Client(conn, cfg.TLSConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		c.setConn(tlsConn)
+	}
+
+	return c, nil
+}
+
+func (c *Conn) setConn(conn net.Conn) {
+	c.conn = conn
+	c.text = textproto.NewReader(bufio.NewReader(conn))
+}
+
+// Login authenticates and prepares the session for binary transfers
+func (c *Conn) Login(ctx context.Context, user, password string) error {
+	code, msg, err := c.cmd(ctx, -1, "USER %s", user)
+	if err != nil {
+		return err
+	}
+	switch code {
+	case 230:
+	case 331:
+		if _, _, err := c.cmd(ctx, 2, "PASS %s", password); err != nil {
+			return err
+		}
+	default:
+		return &textproto.Error{Code: code, Msg: msg}
+	}
+
+	// FEAT is optional; servers without it simply get the conservative defaults
+	if err := c.feat(ctx); err != nil {
+		return err
+	}
+
+	if c.cfg.TLSConfig != nil {
+		if _, _, err := c.cmd(ctx, 2, "PBSZ 0"); err != nil {
+			return err
+		}
+		if _, _, err := c.cmd(ctx, 2, "PROT P"); err != nil {
+			return err
+		}
+	}
+
+	if c.HasFeature("UTF8") {
+		if _, _, err := c.cmd(ctx, -1, "OPTS UTF8 ON"); err != nil {
+			return err
+		}
+	}
+
+	_, _, err = c.cmd(ctx, 2, "TYPE I")
+	return err
+}
+
+func (c *Conn) feat(ctx context.Context) error {
+	code, msg, err := c.cmd(ctx, -1, "FEAT")
+	if err != nil {
+		return err
+	}
+	if code != 211 {
+		return nil
+	}
+	for _, line := range strings.Split(msg, "\n") {
+		if !strings.HasPrefix(line, " ") {
+			continue
+		}
+		name, params, _ := strings.Cut(strings.TrimSpace(line), " ")
+		c.features[strings.ToUpper(name)] = params
+	}
+	return nil
+}
+
+// HasFeature reports whether the server advertised a FEAT entry
+func (c *Conn) HasFeature(name string) bool {
+	_, ok := c.features[strings.ToUpper(name)]
+	return ok
+}
+
+// MakeDir creates a directory
+func (c *Conn) MakeDir(ctx context.Context, path string) error {
+	_, _, err := c.cmd(ctx, 2, "MKD %s", path)
+	return err
+}
+
+// Retr starts downloading path from offset (sent as REST when non-zero).
+// The caller must Close the reader to complete the transfer.
+func (c *Conn) Retr(ctx context.Context, path string, offset int64) (io.ReadCloser, error) {
+	conn, err := c.openTransfer(ctx, offset, "RETR %s", path)
+	if err != nil {
+		return nil, err
+	}
+	return &transferReader{c: c, ctx: ctx, conn: conn}, nil
+}
+
+// Stor uploads r to path
+func (c *Conn) Stor(ctx context.Context, path string, r io.Reader) error {
+	conn, err := c.openTransfer(ctx, 0, "STOR %s", path)
+	if err != nil {
+		return err
+	}
+
+	// An empty upload never writes, so the TLS handshake has to be forced
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			c.response(ctx, -1)
+			return err
+		}
+	}
+
+	_, copyErr := io.Copy(conn, r)
+	closeErr := conn.Close()
+	_, _, respErr := c.response(ctx, 2)
+	return errors.Join(copyErr, closeErr, respErr)
+}
+
+// Interrupt makes blocked reads and writes on the control connection and
+// the open data connection fail right away, e.g. when a transfer stalls or
+// is cancelled. It may be called from another goroutine; the session can't
+// be used afterwards except for Quit.
+func (c *Conn) Interrupt() {
+	past := time.Unix(1, 0)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interrupted = true
+	c.conn.SetDeadline(past)
+	if c.data != nil {
+		c.data.SetDeadline(past)
+	}
+}
+
+// Quit ends the session and closes the control connection
+func (c *Conn) Quit() error {
+	c.cmd(context.Background(), -1, "QUIT")
+	return c.conn.Close()
+}
+
+// cmd sends a command and reads its reply, checking it against expect
+// (see textproto.Reader.ReadResponse; -1 accepts any code)
+func (c *Conn) cmd(ctx context.Context, expect int, format string, args ...interface{}) (int, string, error) {
+	done, err := c.begin(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	defer done()
+	if _, err := fmt.Fprintf(c.conn, format+"\r\n", args...); err != nil {
+		return 0, "", err
+	}
+	return c.text.ReadResponse(expect)
+}
+
+// response reads a reply to a command sent earlier, like the end of a
+// transfer
+func (c *Conn) response(ctx context.Context, expect int) (int, string, error) {
+	done, err := c.begin(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	defer done()
+	return c.text.ReadResponse(expect)
+}
+
+// begin bounds the next exchange on the control connection, so a server
+// that stops answering can't block forever: it has to finish within
+// Timeout, or by ctx's deadline if that is sooner, and is interrupted when
+// ctx is cancelled. The returned func stops watching ctx.
+func (c *Conn) begin(ctx context.Context) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(c.cfg.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	c.mu.Lock()
+	if c.interrupted {
+		c.mu.Unlock()
+		return nil, errInterrupted
+	}
+	c.conn.SetDeadline(deadline)
+	c.mu.Unlock()
+
+	stop := context.AfterFunc(ctx, c.Interrupt)
+	return func() { stop() }, nil
+}
+
+// openTransfer sets up a data connection, sends the command and waits for
+// the server to start the transfer
+func (c *Conn) openTransfer(ctx context.Context, offset int64, format string, args ...interface{}) (net.Conn, error) {
+	var (
+		conn net.Conn
+		ln   *net.TCPListener
+		err  error
+	)
+	if c.cfg.Active {
+		if ln, err = c.listenActive(ctx); err != nil {
+			return nil, err
+		}
+		defer ln.Close()
+	} else {
+		addr, err := c.passiveAddr(ctx)
+		if err != nil {
+			return nil, err
+		}
+		dialer := &net.Dialer{Timeout: c.cfg.Timeout}
+		if conn, err = dialer.DialContext(ctx, "tcp", addr); err != nil {
+			return nil, fmt.Errorf("failed to open data connection: %w", err)
+		}
+	}
+
+	fail := func(err error) (net.Conn, error) {
+		if conn != nil {
+			conn.Close()
+		}
+		return nil, err
+	}
+
+	if offset > 0 {
+		if _, _, err := c.cmd(ctx, 3, "REST %d", offset); err != nil {
+			return fail(err)
+		}
+	}
+	if _, _, err := c.cmd(ctx, 1, format, args...); err != nil {
+		return fail(err)
+	}
+
+	if ln != nil {
+		ln.SetDeadline(time.Now().Add(c.cfg.Timeout))
+		if conn, err = ln.Accept(); err != nil {
+			// The server gives up on its side and reports the failure
+			c.response(ctx, -1)
+			return nil, fmt.Errorf("server did not open active data connection: %w", err)
+		}
+	}
+
+	if c.cfg.TLSConfig != nil {
+		conn = tls.Client(conn, c.cfg.TLSConfig)
+	}
+	c.mu.Lock()
+	c.data = conn
+	c.mu.Unlock()
+	return conn, nil
+}
+
+// passiveAddr asks the server for a passive data port
+func (c *Conn) passiveAddr(ctx context.Context) (string, error) {
+	remote := c.conn.RemoteAddr().(*net.TCPAddr).IP
+
+	if c.cfg.PassiveMode != PassivePASV && !c.skipEPSV {
+		_, msg, err := c.cmd(ctx, 229, "EPSV")
+		if err == nil {
+			var port int
+			if port, err = parseEPSV(msg); err == nil {
+				return net.JoinHostPort(remote.String(), strconv.Itoa(port)), nil
+			}
+		}
+		if c.cfg.PassiveMode == PassiveEPSV {
+			return "", fmt.Errorf("EPSV failed: %w", err)
+		}
+		c.skipEPSV = true
+	}
+
+	_, msg, err := c.cmd(ctx, 227, "PASV")
+	if err != nil {
+		return "", err
+	}
+	ip, port, err := parsePASV(msg)
+	if err != nil {
+		return "", err
+	}
+	// Servers behind NAT often announce their private address
+	if ip.IsUnspecified() || (ip.IsPrivate() && !remote.IsPrivate()) {
+		ip = remote
+	}
+	return net.JoinHostPort(ip.String(), strconv.Itoa(port)), nil
+}
+
+// listenActive opens a local data port and announces it with PORT or EPRT
+func (c *Conn) listenActive(ctx context.Context) (*net.TCPListener, error) {
+	local := c.conn.LocalAddr().(*net.TCPAddr).IP
+	announce, bind := local, local
+	if c.cfg.ActiveAddress != "" {
+		announce = net.ParseIP(c.cfg.ActiveAddress)
+		if announce == nil {
+			return nil, fmt.Errorf("invalid active address %q", c.cfg.ActiveAddress)
+		}
+		// The announced address is usually a NAT address we can't bind to
+		bind = nil
+	}
+
+	ln, err := listenInRange(bind, c.cfg.ActivePortMin, c.cfg.ActivePortMax)
+	if err != nil {
+		return nil, err
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	if ip4 := announce.To4(); ip4 != nil {
+		_, _, err = c.cmd(ctx, 2, "PORT %d,%d,%d,%d,%d,%d", ip4[0], ip4[1], ip4[2], ip4[3], port/256, port%256)
+	} else {
+		_, _, err = c.cmd(ctx, 2, "EPRT |2|%s|%d|", announce, port)
+	}
+	if err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// listenInRange listens on a free port between min and max, starting at a
+// random offset so concurrent sessions don't race for the same port
+func listenInRange(ip net.IP, min, max int) (*net.TCPListener, error) {
+	if min == 0 {
+		return net.ListenTCP("tcp", &net.TCPAddr{IP: ip})
+	}
+
+	n := max - min + 1
+	start := rand.IntN(n)
+	for i := 0; i < n; i++ {
+		ln, err := net.ListenTCP("tcp", &net.TCPAddr{IP: ip, Port: min + (start+i)%n})
+		if err == nil {
+			return ln, nil
+		}
+	}
+	return nil, fmt.Errorf("no free port in active range %d-%d", min, max)
+}
+
+// parseEPSV extracts the port from "229 Entering Extended Passive Mode (|||port|)"
+func parseEPSV(msg string) (int, error) {
+	start := strings.Index(msg, "(")
+	end := strings.LastIndex(msg, ")")
+	if start == -1 || end < start+5 {
+		return 0, fmt.Errorf("invalid EPSV response %q", msg)
+	}
+	fields := strings.Split(msg[start+1:end], string(msg[start+1]))
+	if len(fields) != 5 {
+		return 0, fmt.Errorf("invalid EPSV response %q", msg)
+	}
+	return strconv.Atoi(fields[3])
+}
+
+// parsePASV extracts the address from "227 Entering Passive Mode (h1,h2,h3,h4,p1,p2)"
+func parsePASV(msg string) (net.IP, int, error) {
+	start := strings.Index(msg, "(")
+	end := strings.LastIndex(msg, ")")
+	if start == -1 || end == -1 {
+		// Some servers omit the parentheses
+		start = strings.LastIndex(msg, " ")
+		end = len(msg)
+	}
+
+	parts := strings.Split(msg[start+1:end], ",")
+	if len(parts) != 6 {
+		return nil, 0, fmt.Errorf("invalid PASV response %q", msg)
+	}
+	var nums [6]byte
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil || n < 0 || n > 255 {
+			return nil, 0, fmt.Errorf("invalid PASV response %q", msg)
+		}
+		nums[i] = byte(n)
+	}
+	return net.IPv4(nums[0], nums[1], nums[2], nums[3]), int(nums[4])<<8 | int(nums[5]), nil
+}
+
+// transferReader completes a RETR when closed
+type transferReader struct {
+	c      *Conn
+	ctx    context.Context
+	conn   net.Conn
+	closed bool
+}
+
+func (r *transferReader) Read(p []byte) (int, error) {
+	return r.conn.Read(p)
+}
+
+func (r *transferReader) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	// The server may already have torn down its side; the reply is what counts
+	r.conn.Close()
+	_, _, err := r.c.response(r.ctx, 2)
+	return err
+}
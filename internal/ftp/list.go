@@ -0,0 +1,309 @@
+// internal/ftp/list.go
+package ftp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is a single file or directory from a listing
+type Entry struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+	IsDir   bool
+}
+
+// List returns the entries of dir. MLSD is used when the server advertises
+// MLST, since its timestamps are exact and machine readable; LIST output is
+// parsed as a fallback and only has minute precision for recent files.
+func (c *Conn) List(ctx context.Context, dir string) ([]Entry, error) {
+	if c.HasFeature("MLST") {
+		entries, err := c.listLines(ctx, "MLSD", dir, func(line string) (Entry, bool) {
+			return parseMLSDLine(line)
+		})
+		var protoErr *textproto.Error
+		if err == nil || !errors.As(err, &protoErr) || protoErr.Code < 500 {
+			return entries, err
+		}
+		// Permanent MLSD failure despite FEAT: some servers advertise it
+		// without implementing it, so try LIST before giving up
+	}
+
+	now := time.Now().UTC()
+	return c.listLines(ctx, "LIST", dir, func(line string) (Entry, bool) {
+		return parseListLine(line, now)
+	})
+}
+
+func (c *Conn) listLines(ctx context.Context, verb, dir string, parse func(string) (Entry, bool)) ([]Entry, error) {
+	r, err := c.dataCommand(ctx, verb, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if e, ok := parse(line); ok {
+			entries = append(entries, e)
+		}
+	}
+	scanErr := scanner.Err()
+	if err := r.Close(); err != nil {
+		return nil, err
+	}
+	return entries, scanErr
+}
+
+// dataCommand runs a listing command over a data connection
+func (c *Conn) dataCommand(ctx context.Context, verb, arg string) (io.ReadCloser, error) {
+	conn, err := c.openTransfer(ctx, 0, "%s %s", verb, arg)
+	if err != nil {
+		return nil, err
+	}
+	return &transferReader{c: c, ctx: ctx, conn: conn}, nil
+}
+
+// Stat returns size and modification time of a single path, using MLST
+// when available and SIZE/MDTM otherwise
+func (c *Conn) Stat(ctx context.Context, path string) (Entry, error) {
+	if c.HasFeature("MLST") {
+		_, msg, err := c.cmd(ctx, 2, "MLST %s", path)
+		if err != nil {
+			return Entry{}, err
+		}
+		// The fact line is the only one starting with a space
+		for _, line := range strings.Split(msg, "\n") {
+			if strings.HasPrefix(line, " ") {
+				if e, ok := parseMLSDLine(line[1:]); ok {
+					return e, nil
+				}
+			}
+		}
+		return Entry{}, fmt.Errorf("invalid MLST response %q", msg)
+	}
+
+	e := Entry{Name: path}
+	_, msg, err := c.cmd(ctx, 2, "SIZE %s", path)
+	if err != nil {
+		return Entry{}, err
+	}
+	if e.Size, err = strconv.ParseInt(strings.TrimSpace(msg), 10, 64); err != nil {
+		return Entry{}, fmt.Errorf("invalid SIZE response %q", msg)
+	}
+	if c.HasFeature("MDTM") {
+		_, msg, err := c.cmd(ctx, 2, "MDTM %s", path)
+		if err != nil {
+			return Entry{}, err
+		}
+		if e.ModTime, err = parseMLSDTime(strings.TrimSpace(msg)); err != nil {
+			return Entry{}, fmt.Errorf("invalid MDTM response %q", msg)
+		}
+	}
+	return e, nil
+}
+
+// parseMLSDLine parses "type=file;size=12;modify=20260102030405; name" (RFC 3659).
+// The current and parent directory entries are skipped.
+func parseMLSDLine(line string) (Entry, bool) {
+	facts, name, ok := strings.Cut(line, " ")
+	if !ok || name == "" {
+		return Entry{}, false
+	}
+
+	e := Entry{Name: name}
+	for _, fact := range strings.Split(facts, ";") {
+		key, value, ok := strings.Cut(fact, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "type":
+			switch strings.ToLower(value) {
+			case "cdir", "pdir":
+				return Entry{}, false
+			case "dir":
+				e.IsDir = true
+			}
+		case "size", "sizd":
+			e.Size, _ = strconv.ParseInt(value, 10, 64)
+		case "modify":
+			e.ModTime, _ = parseMLSDTime(value)
+		}
+	}
+	return e, true
+}
+
+// parseMLSDTime parses YYYYMMDDHHMMSS with optional fractional seconds, in UTC
+func parseMLSDTime(s string) (time.Time, error) {
+	if len(s) > 14 && s[14] == '.' {
+		s = s[:14]
+	}
+	return time.ParseInLocation("20060102150405", s, time.UTC)
+}
+
+var isoDate = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// parseListLine parses one line of LIST output. It understands the usual
+// Unix ls format (with or without the group column, or with ISO dates) and
+// the MS-DOS/IIS format. now resolves Unix dates that omit the year.
+func parseListLine(line string, now time.Time) (Entry, bool) {
+	if line == "" || strings.HasPrefix(line, "total ") {
+		return Entry{}, false
+	}
+	if e, ok := parseDOSLine(line); ok {
+		return e, true
+	}
+	return parseUnixLine(line, now)
+}
+
+// parseUnixLine handles "drwxr-xr-x 2 owner group 4096 Jan  2 03:04 name"
+// and its variants. The date is located first and everything else is
+// derived from its position, which copes with missing owner/group columns.
+func parseUnixLine(line string, now time.Time) (Entry, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 5 || len(fields[0]) < 10 {
+		return Entry{}, false
+	}
+	kind := fields[0][0]
+	if !strings.ContainsRune("-dlbcps", rune(kind)) {
+		return Entry{}, false
+	}
+
+	for i := 2; i < len(fields)-2; i++ {
+		var (
+			mod   time.Time
+			ok    bool
+			width int
+		)
+		if isoDate.MatchString(fields[i]) {
+			mod, ok = parseISODate(fields[i], fields[i+1])
+			width = 2
+		} else if i < len(fields)-3 {
+			mod, ok = parseUnixDate(fields[i], fields[i+1], fields[i+2], now)
+			width = 3
+		}
+		if !ok {
+			continue
+		}
+
+		size, err := strconv.ParseInt(fields[i-1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		name := afterFields(line, i+width)
+		if name == "" {
+			return Entry{}, false
+		}
+		if kind == 'l' {
+			name, _, _ = strings.Cut(name, " -> ")
+		}
+		if name == "." || name == ".." {
+			return Entry{}, false
+		}
+		return Entry{Name: name, Size: size, ModTime: mod, IsDir: kind == 'd'}, true
+	}
+	return Entry{}, false
+}
+
+// parseUnixDate parses "Jan 2 03:04" (last twelve months) or "Jan 2 2025"
+func parseUnixDate(month, day, yearOrTime string, now time.Time) (time.Time, bool) {
+	m, err := time.Parse("Jan", month)
+	if err != nil {
+		return time.Time{}, false
+	}
+	d, err := strconv.Atoi(day)
+	if err != nil || d < 1 || d > 31 {
+		return time.Time{}, false
+	}
+
+	if strings.Contains(yearOrTime, ":") {
+		t, err := time.Parse("15:04", yearOrTime)
+		if err != nil {
+			t, err = time.Parse("15:04:05", yearOrTime)
+			if err != nil {
+				return time.Time{}, false
+			}
+		}
+		mod := time.Date(now.Year(), m.Month(), d, t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+		// ls omits the year for the last six months; anything "in the future" is from last year
+		if mod.After(now.Add(24 * time.Hour)) {
+			mod = mod.AddDate(-1, 0, 0)
+		}
+		return mod, true
+	}
+
+	y, err := strconv.Atoi(yearOrTime)
+	if err != nil || y < 1970 {
+		return time.Time{}, false
+	}
+	return time.Date(y, m.Month(), d, 0, 0, 0, 0, time.UTC), true
+}
+
+// parseISODate parses "2025-01-02 03:04" or "2025-01-02 03:04:05"
+func parseISODate(date, clock string) (time.Time, bool) {
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, date+" "+clock, time.UTC); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseDOSLine handles "01-02-26  03:04PM  <DIR>  name" and "01-02-26  03:04PM  1234 name"
+func parseDOSLine(line string) (Entry, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 {
+		return Entry{}, false
+	}
+
+	var mod time.Time
+	var err error
+	for _, layout := range []string{"01-02-06 03:04PM", "01-02-2006 03:04PM", "01-02-06 15:04", "01-02-2006 15:04"} {
+		if mod, err = time.ParseInLocation(layout, fields[0]+" "+fields[1], time.UTC); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return Entry{}, false
+	}
+
+	name := afterFields(line, 3)
+	if name == "" {
+		return Entry{}, false
+	}
+	if fields[2] == "<DIR>" {
+		return Entry{Name: name, ModTime: mod, IsDir: true}, true
+	}
+	size, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return Entry{}, false
+	}
+	return Entry{Name: name, Size: size, ModTime: mod}, true
+}
+
+// afterFields returns the rest of line after skipping n whitespace separated
+// fields, preserving spaces inside the remainder (file names)
+func afterFields(line string, n int) string {
+	rest := line
+	for i := 0; i < n; i++ {
+		rest = strings.TrimLeft(rest, " \t")
+		end := strings.IndexAny(rest, " \t")
+		if end == -1 {
+			return ""
+		}
+		rest = rest[end:]
+	}
+	return strings.TrimLeft(rest, " \t")
+}
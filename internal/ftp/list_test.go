@@ -0,0 +1,133 @@
+// internal/ftp/list_test.go
+package ftp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMLSDLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want Entry
+		ok   bool
+	}{
+		{
+			line: "type=file;size=1024;modify=20260102030405;perm=r; world.sav",
+			want: Entry{Name: "world.sav", Size: 1024, ModTime: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)},
+			ok:   true,
+		},
+		{
+			line: "Type=dir;Modify=20260102030405.123; Saved Games",
+			want: Entry{Name: "Saved Games", IsDir: true, ModTime: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)},
+			ok:   true,
+		},
+		{line: "type=cdir;modify=20260102030405; .", ok: false},
+		{line: "type=pdir;modify=20260102030405; ..", ok: false},
+		{line: "garbage", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseMLSDLine(tt.line)
+		if ok != tt.ok {
+			t.Errorf("parseMLSDLine(%q) ok = %v, want %v", tt.line, ok, tt.ok)
+			continue
+		}
+		if ok && got != tt.want {
+			t.Errorf("parseMLSDLine(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseListLine(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		line string
+		want Entry
+		ok   bool
+	}{
+		{
+			name: "unix recent",
+			line: "-rw-r--r--   1 gameserver gameserver     1024 Mar  9 03:04 world.sav",
+			want: Entry{Name: "world.sav", Size: 1024, ModTime: time.Date(2026, 3, 9, 3, 4, 0, 0, time.UTC)},
+			ok:   true,
+		},
+		{
+			name: "unix recent from last year",
+			line: "-rw-r--r--   1 user group 10 Dec 24 18:00 xmas.sav",
+			want: Entry{Name: "xmas.sav", Size: 10, ModTime: time.Date(2025, 12, 24, 18, 0, 0, 0, time.UTC)},
+			ok:   true,
+		},
+		{
+			name: "unix with year and spaces in name",
+			line: "drwxr-xr-x   2 user group 4096 Jan  2  2024 Saved Games",
+			want: Entry{Name: "Saved Games", Size: 4096, IsDir: true, ModTime: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+			ok:   true,
+		},
+		{
+			name: "missing group column",
+			line: "-rw-r--r-- 1 ftp 512 Feb 28 23:59 config.ini",
+			want: Entry{Name: "config.ini", Size: 512, ModTime: time.Date(2026, 2, 28, 23, 59, 0, 0, time.UTC)},
+			ok:   true,
+		},
+		{
+			name: "iso date with seconds",
+			line: "-rw-r--r-- 1 user group 77 2026-01-02 03:04:05 level.dat",
+			want: Entry{Name: "level.dat", Size: 77, ModTime: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)},
+			ok:   true,
+		},
+		{
+			name: "symlink",
+			line: "lrwxrwxrwx 1 user group 9 Jan  2  2024 latest -> world.sav",
+			want: Entry{Name: "latest", Size: 9, ModTime: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+			ok:   true,
+		},
+		{
+			name: "dos file",
+			line: "01-02-26  03:04PM                 2048 ShooterGame.ark",
+			want: Entry{Name: "ShooterGame.ark", Size: 2048, ModTime: time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC)},
+			ok:   true,
+		},
+		{
+			name: "dos dir",
+			line: "01-02-2026  03:04AM       <DIR>          Saved",
+			want: Entry{Name: "Saved", IsDir: true, ModTime: time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)},
+			ok:   true,
+		},
+		{name: "total", line: "total 12"},
+		{name: "dot", line: "drwxr-xr-x 2 user group 4096 Jan  2  2024 ."},
+		{name: "garbage", line: "this is not a listing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseListLine(tt.line, now)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v (got %+v)", ok, tt.ok, got)
+			}
+			if ok && got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePassiveReplies(t *testing.T) {
+	port, err := parseEPSV("Entering Extended Passive Mode (|||50123|)")
+	if err != nil || port != 50123 {
+		t.Errorf("parseEPSV = %d, %v", port, err)
+	}
+	if _, err := parseEPSV("Entering Extended Passive Mode"); err == nil {
+		t.Error("expected error for malformed EPSV reply")
+	}
+
+	ip, port, err := parsePASV("Entering Passive Mode (10,0,0,5,195,203)")
+	if err != nil || ip.String() != "10.0.0.5" || port != 50123 {
+		t.Errorf("parsePASV = %v, %d, %v", ip, port, err)
+	}
+	if _, _, err := parsePASV("Entering Passive Mode (10,0,0,5,300,1)"); err == nil {
+		t.Error("expected error for out of range PASV reply")
+	}
+}
@@ -0,0 +1,166 @@
+// internal/healthcheck/healthcheck.go
+package healthcheck
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults keep a monitoring outage from holding up a backup for long
+const (
+	DefaultTimeout    = 5 * time.Second
+	DefaultAttempts   = 3
+	DefaultRetryDelay = 500 * time.Millisecond
+)
+
+// Check pings a healthchecks.io-style URL: URL/start when a run begins,
+// URL when it succeeds and URL/fail when it fails
+type Check struct {
+	URL string
+
+	// Timeout bounds a ping, retries included
+	Timeout    time.Duration
+	Attempts   int
+	RetryDelay time.Duration
+
+	client *http.Client
+}
+
+// New returns a check pinging url with the default timeout and retries
+func New(url string) *Check {
+	return &Check{
+		URL:        strings.TrimRight(url, "/"),
+		Timeout:    DefaultTimeout,
+		Attempts:   DefaultAttempts,
+		RetryDelay: DefaultRetryDelay,
+		client:     http.DefaultClient,
+	}
+}
+
+// Start signals that a run has begun
+func (c *Check) Start(ctx context.Context) error {
+	return c.ping(ctx, "start", nil)
+}
+
+// Success signals that a run has finished
+func (c *Check) Success(ctx context.Context) error {
+	return c.ping(ctx, "", nil)
+}
+
+// Fail signals that a run has failed, log explaining why
+func (c *Check) Fail(ctx context.Context, log []byte) error {
+	return c.ping(ctx, "fail", log)
+}
+
+// ping posts body to the URL with suffix added to its path, retrying
+// network errors, rate limits and server errors until Timeout
+func (c *Check) ping(ctx context.Context, suffix string, body []byte) error {
+	target := c.URL
+	if suffix != "" {
+		var err error
+		if target, err = url.JoinPath(c.URL, suffix); err != nil {
+			return fmt.Errorf("ping %s: %w", c.URL, err)
+		}
+	}
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	var err error
+	for attempt := 1; attempt <= max(c.Attempts, 1); attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(c.RetryDelay):
+			}
+		}
+		var retry bool
+		retry, err = c.post(ctx, target, body)
+		if err == nil || !retry {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("ping %s: %w", target, err)
+	}
+	return nil
+}
+
+// post sends one ping and reports whether a failure is worth retrying
+func (c *Check) post(ctx context.Context, url string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("User-Agent", "gsbt")
+
+	client := c.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode/100 != 2 {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("%s", resp.Status)
+	}
+	return false, nil
+}
+
+// DefaultTailSize is how much log a failure ping carries; healthchecks.io
+// keeps the first 10KB of a ping body
+const DefaultTailSize = 10_000
+
+// Tail is an io.Writer keeping the last Size bytes written to it
+type Tail struct {
+	Size int
+
+	mu  sync.Mutex
+	buf []byte
+	cut bool
+}
+
+// NewTail returns a tail keeping the last size bytes
+func NewTail(size int) *Tail {
+	return &Tail{Size: size}
+}
+
+func (t *Tail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - t.Size; over > 0 {
+		t.buf = append(t.buf[:0], t.buf[over:]...)
+		t.cut = true
+	}
+	return len(p), nil
+}
+
+// Bytes returns the kept output, starting at a line boundary when the
+// beginning was cut off
+func (t *Tail) Bytes() []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := t.buf
+	if t.cut {
+		if i := bytes.IndexByte(out, '\n'); i >= 0 {
+			out = out[i+1:]
+		}
+	}
+	return bytes.Clone(out)
+}
@@ -0,0 +1,144 @@
+// internal/healthcheck/healthcheck_test.go
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type ping struct {
+	path, body string
+}
+
+// receiver records pings, answering the first failures with status
+func receiver(t *testing.T, failures, status int) (*httptest.Server, func() []ping) {
+	var mu sync.Mutex
+	var pings []ping
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		pings = append(pings, ping{r.URL.RequestURI(), string(body)})
+		if len(pings) <= failures {
+			w.WriteHeader(status)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []ping {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]ping(nil), pings...)
+	}
+}
+
+func TestCheckPings(t *testing.T) {
+	srv, pings := receiver(t, 0, 0)
+	c := New(srv.URL + "/abc-123/")
+	ctx := context.Background()
+
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := c.Success(ctx); err != nil {
+		t.Fatalf("Success: %v", err)
+	}
+	if err := c.Fail(ctx, []byte("backup failed: connection refused\n")); err != nil {
+		t.Fatalf("Fail: %v", err)
+	}
+
+	want := []ping{
+		{"/abc-123/start", ""},
+		{"/abc-123", ""},
+		{"/abc-123/fail", "backup failed: connection refused\n"},
+	}
+	got := pings()
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("pings = %q, want %q", got, want)
+	}
+}
+
+func TestCheckPingsQuery(t *testing.T) {
+	srv, pings := receiver(t, 0, 0)
+	c := New(srv.URL + "/abc-123?rid=7")
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if got := pings(); len(got) != 1 || got[0].path != "/abc-123/start?rid=7" {
+		t.Errorf("pings = %q, want the suffix on the path", got)
+	}
+}
+
+func TestCheckRetry(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		failures  int
+		status    int
+		wantPings int
+		wantErr   bool
+	}{
+		{"server error recovers", 2, http.StatusBadGateway, 3, false},
+		{"rate limited recovers", 1, http.StatusTooManyRequests, 2, false},
+		{"persistent outage", 5, http.StatusServiceUnavailable, 3, true},
+		{"not found is final", 5, http.StatusNotFound, 1, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, pings := receiver(t, tc.failures, tc.status)
+			c := New(srv.URL)
+			c.RetryDelay = time.Millisecond
+
+			err := c.Start(context.Background())
+			if (err != nil) != tc.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if n := len(pings()); n != tc.wantPings {
+				t.Errorf("%d pings, want %d", n, tc.wantPings)
+			}
+		})
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	c := New(srv.URL)
+	c.Timeout = 50 * time.Millisecond
+	c.RetryDelay = time.Millisecond
+
+	// the timeout covers every attempt, not each one
+	start := time.Now()
+	if err := c.Start(context.Background()); err == nil {
+		t.Error("expected timeout error")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("ping took %v", d)
+	}
+}
+
+func TestTail(t *testing.T) {
+	tail := NewTail(16)
+	fmt.Fprintln(tail, "short")
+	if got := string(tail.Bytes()); got != "short\n" {
+		t.Errorf("Bytes = %q, want the whole output", got)
+	}
+
+	fmt.Fprintln(tail, "first line")
+	fmt.Fprintln(tail, "last")
+	if got := string(tail.Bytes()); got != "last\n" {
+		t.Errorf("Bytes = %q, want the last whole line", got)
+	}
+	if !strings.HasSuffix(string(tail.buf), "last\n") || len(tail.buf) != 16 {
+		t.Errorf("buf = %q, want the last 16 bytes", tail.buf)
+	}
+}
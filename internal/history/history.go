@@ -0,0 +1,160 @@
+// internal/history/history.go
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileName is the history file kept next to the config file
+const FileName = "history.jsonl"
+
+// Outcomes of a run
+const (
+	Success   = "success"
+	Failed    = "failed"
+	Cancelled = "cancelled"
+)
+
+// Record is one server's part of a backup, prune or restore run
+type Record struct {
+	Command string    `json:"command"`
+	Server  string    `json:"server"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Outcome string    `json:"outcome"`
+	Error   string    `json:"error,omitempty"`
+
+	Archive    string   `json:"archive,omitempty"`
+	Files      int      `json:"files,omitempty"`
+	Bytes      int64    `json:"bytes,omitempty"`
+	Changed    []string `json:"changed,omitempty"`
+	Suspicious []string `json:"suspicious,omitempty"`
+	Pruned     []string `json:"pruned,omitempty"`
+
+	Version string `json:"version"`
+}
+
+// Duration is how long the run took
+func (r Record) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+// Failed reports whether the run failed or was cancelled
+func (r Record) Failed() bool {
+	return r.Outcome != Success
+}
+
+// Filter selects records; zero fields match everything
+type Filter struct {
+	Server string
+	Since  time.Time
+	Failed bool
+}
+
+// Match reports whether r passes the filter
+func (f Filter) Match(r Record) bool {
+	switch {
+	case f.Server != "" && r.Server != f.Server:
+		return false
+	case !f.Since.IsZero() && r.Start.Before(f.Since):
+		return false
+	case f.Failed && !r.Failed():
+		return false
+	}
+	return true
+}
+
+// appendMu serializes appends from this process, e.g. parallel backups
+var appendMu sync.Mutex
+
+// Append adds records to the history file at path, one JSON object per
+// line, creating it and its directory as needed
+func Append(path string, records ...Record) error {
+	var buf []byte
+	for _, r := range records {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+
+	appendMu.Lock()
+	defer appendMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	// One write per call keeps lines from concurrent gsbt runs whole
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read returns the records in the history file at path that match filter,
+// oldest first. A missing file is an empty history; lines that don't parse,
+// such as one cut short by a crash, are skipped.
+func Read(path string, filter Filter) ([]Record, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		if filter.Match(r) {
+			records = append(records, r)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// Status sums up a server's backups
+type Status struct {
+	LastRun     *Record
+	LastSuccess *Record
+	LastFailure *Record
+}
+
+// Statuses returns the status of each server's backups in records, which
+// are oldest first as Read returns them
+func Statuses(records []Record) map[string]Status {
+	statuses := map[string]Status{}
+	for i := range records {
+		r := &records[i]
+		if r.Command != "backup" {
+			continue
+		}
+		s := statuses[r.Server]
+		s.LastRun = r
+		if r.Failed() {
+			s.LastFailure = r
+		} else {
+			s.LastSuccess = r
+		}
+		statuses[r.Server] = s
+	}
+	return statuses
+}
@@ -0,0 +1,88 @@
+// internal/history/history_test.go
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2026, 3, 1, 4, 0, 0, 0, time.UTC)
+
+func record(command, server, outcome string, hours int) Record {
+	r := Record{
+		Command: command,
+		Server:  server,
+		Start:   start.Add(time.Duration(hours) * time.Hour),
+		End:     start.Add(time.Duration(hours)*time.Hour + time.Minute),
+		Outcome: outcome,
+		Version: "1.2.3",
+	}
+	if outcome != Success {
+		r.Error = "connection refused"
+	}
+	return r
+}
+
+func TestAppendRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", FileName)
+
+	if records, err := Read(path, Filter{}); err != nil || len(records) != 0 {
+		t.Fatalf("Read of a missing file = %v, %v", records, err)
+	}
+
+	if err := Append(path, record("backup", "valheim", Success, 0), record("backup", "ark", Failed, 0)); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	// a line cut short by a crash doesn't hide the rest
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.WriteString(`{"command":"backup","serv` + "\n")
+	f.Close()
+	if err := Append(path, record("prune", "valheim", Success, 1), record("backup", "ark", Cancelled, 2)); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		filter Filter
+		want   int
+	}{
+		{"all", Filter{}, 4},
+		{"server", Filter{Server: "valheim"}, 2},
+		{"failed", Filter{Failed: true}, 2},
+		{"since", Filter{Since: start.Add(time.Hour)}, 2},
+		{"combined", Filter{Server: "ark", Failed: true, Since: start.Add(time.Hour)}, 1},
+	} {
+		records, err := Read(path, tc.filter)
+		if err != nil {
+			t.Fatalf("%s: Read: %v", tc.name, err)
+		}
+		if len(records) != tc.want {
+			t.Errorf("%s: got %d records, want %d: %+v", tc.name, len(records), tc.want, records)
+		}
+	}
+
+	records, _ := Read(path, Filter{Server: "valheim"})
+	if r := records[0]; r.Command != "backup" || r.Duration() != time.Minute || r.Version != "1.2.3" {
+		t.Errorf("first record = %+v", r)
+	}
+}
+
+func TestStatuses(t *testing.T) {
+	statuses := Statuses([]Record{
+		record("backup", "ark", Success, 0),
+		record("backup", "ark", Failed, 1),
+		record("backup", "valheim", Success, 1),
+		record("prune", "valheim", Failed, 2),
+	})
+
+	ark := statuses["ark"]
+	if ark.LastRun == nil || ark.LastRun.Outcome != Failed || ark.LastSuccess.Start != start || ark.LastFailure.Error != "connection refused" {
+		t.Errorf("ark = %+v", ark)
+	}
+	valheim := statuses["valheim"]
+	if valheim.LastFailure != nil || valheim.LastSuccess == nil {
+		t.Errorf("valheim = %+v, want prunes ignored", valheim)
+	}
+}
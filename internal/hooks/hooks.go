@@ -0,0 +1,152 @@
+// internal/hooks/hooks.go
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devtheops/gsbt/internal/log"
+)
+
+// Kind names the point a hook runs at
+type Kind string
+
+const (
+	PreBackup   Kind = "pre_backup"
+	PostBackup  Kind = "post_backup"
+	OnFailure   Kind = "on_failure"
+	PreRestore  Kind = "pre_restore"
+	PostRestore Kind = "post_restore"
+)
+
+// Run statuses passed as GSBT_STATUS
+const (
+	StatusSuccess   = "success"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// PreFailurePolicy decides whether a failing pre hook stops the backup
+type PreFailurePolicy string
+
+const (
+	// Abort fails the backup without running it
+	Abort PreFailurePolicy = "abort"
+	// Continue logs the failure and backs up anyway
+	Continue PreFailurePolicy = "continue"
+)
+
+// ParsePreFailure validates a policy name, defaulting to abort when empty
+func ParsePreFailure(s string) (PreFailurePolicy, error) {
+	switch p := PreFailurePolicy(strings.ToLower(s)); p {
+	case "":
+		return Abort, nil
+	case Abort, Continue:
+		return p, nil
+	}
+	return "", fmt.Errorf("unsupported pre hook failure policy %q (want abort or continue)", s)
+}
+
+// killGrace is how long a timed out hook's output is still collected,
+// in case it started children that keep the pipes open
+var killGrace = 5 * time.Second
+
+// Env describes the run a hook is called for. It is passed to the hook as
+// GSBT_* environment variables; empty values are left out.
+type Env struct {
+	Server         string
+	BackupLocation string
+	Status         string
+	ArchivePath    string
+	Files          int
+	Bytes          int64
+	Duration       time.Duration
+	Error          string
+}
+
+// Vars returns the environment variables for a hook of kind
+func (e Env) Vars(kind Kind) []string {
+	vars := []string{"GSBT_HOOK=" + string(kind), "GSBT_SERVER=" + e.Server}
+	add := func(name, value string) {
+		if value != "" {
+			vars = append(vars, name+"="+value)
+		}
+	}
+	add("GSBT_BACKUP_LOCATION", e.BackupLocation)
+	add("GSBT_STATUS", e.Status)
+	add("GSBT_ARCHIVE_PATH", e.ArchivePath)
+	if e.ArchivePath != "" {
+		add("GSBT_FILES", strconv.Itoa(e.Files))
+		add("GSBT_BYTES", strconv.FormatInt(e.Bytes, 10))
+	}
+	if e.Duration > 0 {
+		add("GSBT_DURATION", strconv.FormatFloat(e.Duration.Seconds(), 'f', 1, 64))
+	}
+	add("GSBT_ERROR", e.Error)
+	return vars
+}
+
+// Error reports a failed hook command
+type Error struct {
+	Kind    Kind
+	Command string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s hook %q: %v", e.Kind, e.Command, e.Err)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Run runs commands through the shell one after another, stopping at the
+// first that fails. Each command is killed after timeout (0 for none).
+// Output is passed to output line by line as it arrives.
+func Run(ctx context.Context, kind Kind, commands []string, env Env, timeout time.Duration, output func(line string, stderr bool)) error {
+	for _, command := range commands {
+		if err := runOne(ctx, kind, command, env, timeout, output); err != nil {
+			return &Error{Kind: kind, Command: command, Err: err}
+		}
+	}
+	return nil
+}
+
+func runOne(ctx context.Context, kind Kind, command string, env Env, timeout time.Duration, output func(string, bool)) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	cmd := shell(ctx, command)
+	cmd.Env = append(os.Environ(), env.Vars(kind)...)
+	stdout := log.NewLineWriter(func(line string) { output(line, false) })
+	stderr := log.NewLineWriter(func(line string) { output(line, true) })
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	cmd.WaitDelay = killGrace
+
+	err := cmd.Run()
+	stdout.Flush()
+	stderr.Flush()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func shell(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
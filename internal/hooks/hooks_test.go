@@ -0,0 +1,103 @@
+// internal/hooks/hooks_test.go
+package hooks
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type capture struct {
+	mu     sync.Mutex
+	stdout []string
+	stderr []string
+}
+
+func (c *capture) output(line string, stderr bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stderr {
+		c.stderr = append(c.stderr, line)
+	} else {
+		c.stdout = append(c.stdout, line)
+	}
+}
+
+func skipWithoutSh(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks tests use sh")
+	}
+}
+
+func TestRun(t *testing.T) {
+	skipWithoutSh(t)
+	env := Env{Server: "ark", Status: StatusSuccess, ArchivePath: "/b/ark/x.tar.gz", Files: 3, Bytes: 1024}
+	var c capture
+	err := Run(context.Background(), PostBackup, []string{
+		`echo "$GSBT_HOOK $GSBT_SERVER $GSBT_STATUS $GSBT_FILES $GSBT_BYTES"; echo "$GSBT_ARCHIVE_PATH"`,
+		`echo oops >&2; printf 'no newline'`,
+	}, env, time.Minute, c.output)
+	if err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+
+	want := []string{"post_backup ark success 3 1024", "/b/ark/x.tar.gz", "no newline"}
+	if strings.Join(c.stdout, "|") != strings.Join(want, "|") {
+		t.Errorf("stdout = %q, want %q", c.stdout, want)
+	}
+	if len(c.stderr) != 1 || c.stderr[0] != "oops" {
+		t.Errorf("stderr = %q, want [oops]", c.stderr)
+	}
+}
+
+func TestRunFailure(t *testing.T) {
+	skipWithoutSh(t)
+	var c capture
+	err := Run(context.Background(), PreBackup, []string{"exit 3", "echo never"}, Env{Server: "ark"}, 0, c.output)
+	var hookErr *Error
+	if !errors.As(err, &hookErr) || hookErr.Kind != PreBackup || hookErr.Command != "exit 3" {
+		t.Fatalf("err = %v, want pre_backup hook error", err)
+	}
+	if len(c.stdout) != 0 {
+		t.Errorf("ran commands after the failure: %q", c.stdout)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	skipWithoutSh(t)
+	defer func(d time.Duration) { killGrace = d }(killGrace)
+	killGrace = 100 * time.Millisecond
+
+	var c capture
+	start := time.Now()
+	err := Run(context.Background(), PreBackup, []string{"sleep 10"}, Env{}, 50*time.Millisecond, c.output)
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Errorf("err = %v, want timeout", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("timed out hook took %v to stop", time.Since(start))
+	}
+}
+
+func TestEnvVars(t *testing.T) {
+	vars := Env{Server: "ark", Status: StatusFailed, Error: "boom"}.Vars(OnFailure)
+	want := []string{"GSBT_HOOK=on_failure", "GSBT_SERVER=ark", "GSBT_STATUS=failed", "GSBT_ERROR=boom"}
+	if strings.Join(vars, " ") != strings.Join(want, " ") {
+		t.Errorf("Vars = %q, want %q", vars, want)
+	}
+}
+
+func TestParsePreFailure(t *testing.T) {
+	for in, want := range map[string]PreFailurePolicy{"": Abort, "abort": Abort, "Continue": Continue} {
+		if got, err := ParsePreFailure(in); err != nil || got != want {
+			t.Errorf("ParsePreFailure(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParsePreFailure("ignore"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
@@ -0,0 +1,144 @@
+// internal/lock/lock.go
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileName is the lock file gsbt keeps in each server's backup location
+const FileName = ".gsbt.lock"
+
+// pollInterval is how often a waiting Acquire retries
+var pollInterval = 500 * time.Millisecond
+
+// Info is recorded in the lock file by the process holding it
+type Info struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host,omitempty"`
+	Command string    `json:"command,omitempty"`
+	Started time.Time `json:"started"`
+}
+
+func (i Info) String() string {
+	s := fmt.Sprintf("pid %d", i.PID)
+	if i.Host != "" {
+		s += " on " + i.Host
+	}
+	if i.Command != "" {
+		s += " (" + i.Command + ")"
+	}
+	return s + " since " + i.Started.Format(time.RFC3339)
+}
+
+// LockedError is returned when another process holds the lock
+type LockedError struct {
+	Path string
+	// Holder is nil if the holder has not written its info yet
+	Holder *Info
+}
+
+func (e *LockedError) Error() string {
+	if e.Holder == nil {
+		return fmt.Sprintf("locked by another gsbt process (%s)", e.Path)
+	}
+	return fmt.Sprintf("locked by %s (%s)", e.Holder, e.Path)
+}
+
+// Lock is an exclusive lock on a file. The operating system drops it when
+// the holding process exits, so a crashed run never blocks the next one.
+type Lock struct {
+	f *os.File
+
+	// Stale is the info a previous holder left behind when it exited
+	// without releasing the lock, e.g. because it was killed
+	Stale *Info
+}
+
+// Acquire locks the file at path, creating it if needed, and records the
+// current process in it. If the lock is held elsewhere it returns a
+// *LockedError, or with wait retries until the lock is free or ctx is done.
+func Acquire(ctx context.Context, path, command string, wait bool) (*Lock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open lock: %w", err)
+	}
+
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+		if ok {
+			break
+		}
+		if !wait {
+			f.Close()
+			return nil, &LockedError{Path: path, Holder: readInfo(path)}
+		}
+		select {
+		case <-ctx.Done():
+			f.Close()
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+
+	l := &Lock{f: f, Stale: readInfo(path)}
+	host, _ := os.Hostname()
+	data, _ := json.Marshal(Info{PID: os.Getpid(), Host: host, Command: command, Started: time.Now()})
+	if err := l.write(data); err != nil {
+		l.Release()
+		return nil, fmt.Errorf("write lock %s: %w", path, err)
+	}
+	return l, nil
+}
+
+// AcquireDir locks FileName in dir, creating dir if needed
+func AcquireDir(ctx context.Context, dir, command string, wait bool) (*Lock, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return Acquire(ctx, filepath.Join(dir, FileName), command, wait)
+}
+
+// Release empties the lock file and unlocks it. The file itself stays: a
+// process waiting on the old file would otherwise lock a deleted file
+// while a newcomer locks a new one.
+func (l *Lock) Release() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	err := errors.Join(l.f.Truncate(0), unlock(l.f), l.f.Close())
+	l.f = nil
+	return err
+}
+
+func (l *Lock) write(data []byte) error {
+	if err := l.f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := l.f.WriteAt(data, 0); err != nil {
+		return err
+	}
+	return l.f.Sync()
+}
+
+// readInfo returns the holder recorded in path, or nil if there is none
+func readInfo(path string) *Info {
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	var info Info
+	if json.Unmarshal(data, &info) != nil {
+		return nil
+	}
+	return &info
+}
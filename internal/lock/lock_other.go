@@ -0,0 +1,16 @@
+// internal/lock/lock_other.go
+//go:build !linux && !darwin && !freebsd && !windows
+
+package lock
+
+import "os"
+
+// tryLock always succeeds: there is no file locking here, the lock file
+// only records the holder
+func tryLock(f *os.File) (bool, error) {
+	return true, nil
+}
+
+func unlock(f *os.File) error {
+	return nil
+}
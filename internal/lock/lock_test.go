@@ -0,0 +1,101 @@
+// internal/lock/lock_test.go
+package lock
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAcquire(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	l, err := AcquireDir(ctx, filepath.Join(dir, "alpha"), "backup", false)
+	if err != nil {
+		t.Fatalf("Acquire error: %v", err)
+	}
+	if l.Stale != nil {
+		t.Errorf("Stale = %+v on a fresh lock", l.Stale)
+	}
+
+	_, err = AcquireDir(ctx, filepath.Join(dir, "alpha"), "prune", false)
+	var locked *LockedError
+	if !errors.As(err, &locked) || locked.Holder == nil {
+		t.Fatalf("err = %v, want LockedError with holder", err)
+	}
+	if locked.Holder.PID != os.Getpid() || locked.Holder.Command != "backup" {
+		t.Errorf("holder = %+v, want this process running backup", locked.Holder)
+	}
+
+	if err := l.Release(); err != nil {
+		t.Fatalf("Release error: %v", err)
+	}
+	l, err = AcquireDir(ctx, filepath.Join(dir, "alpha"), "prune", false)
+	if err != nil {
+		t.Fatalf("Acquire after release: %v", err)
+	}
+	if l.Stale != nil {
+		t.Errorf("Stale = %+v after a clean release", l.Stale)
+	}
+	l.Release()
+}
+
+func TestAcquireStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	// left behind by a killed process; the OS already dropped its lock
+	os.WriteFile(path, []byte(`{"pid":4242,"command":"backup","started":"2026-03-01T10:00:00Z"}`), 0o644)
+
+	l, err := Acquire(context.Background(), path, "backup", false)
+	if err != nil {
+		t.Fatalf("Acquire error: %v", err)
+	}
+	defer l.Release()
+	if l.Stale == nil || l.Stale.PID != 4242 {
+		t.Errorf("Stale = %+v, want pid 4242", l.Stale)
+	}
+	if info := readInfo(path); info == nil || info.PID != os.Getpid() {
+		t.Errorf("lock file holds %+v, want this process", info)
+	}
+}
+
+func TestAcquireWait(t *testing.T) {
+	defer func(d time.Duration) { pollInterval = d }(pollInterval)
+	pollInterval = 5 * time.Millisecond
+
+	path := filepath.Join(t.TempDir(), FileName)
+	held, err := Acquire(context.Background(), path, "backup", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(chan error, 1)
+	go func() {
+		l, err := Acquire(context.Background(), path, "backup", true)
+		if err == nil {
+			l.Release()
+		}
+		got <- err
+	}()
+
+	select {
+	case err := <-got:
+		t.Fatalf("Acquire returned %v while the lock was held", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	held.Release()
+	if err := <-got; err != nil {
+		t.Errorf("waiting Acquire: %v", err)
+	}
+
+	held, _ = Acquire(context.Background(), path, "backup", false)
+	defer held.Release()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := Acquire(ctx, path, "backup", true); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,45 @@
+// internal/log/lines.go
+package log
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+)
+
+// LineWriter is an io.Writer that calls emit for every complete line
+// written to it, so a child process's output can be logged line by line
+type LineWriter struct {
+	mu   sync.Mutex
+	buf  []byte
+	emit func(string)
+}
+
+// NewLineWriter returns a writer passing each line to emit
+func NewLineWriter(emit func(line string)) *LineWriter {
+	return &LineWriter{emit: emit}
+}
+
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		w.emit(strings.TrimRight(string(w.buf[:i]), "\r"))
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// Flush emits a final line that has no newline
+func (w *LineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.emit(string(w.buf))
+		w.buf = nil
+	}
+}
@@ -0,0 +1,23 @@
+// internal/log/lines_test.go
+package log
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLineWriter(t *testing.T) {
+	var lines []string
+	w := NewLineWriter(func(line string) { lines = append(lines, line) })
+
+	fmt.Fprint(w, "first\r\nsec")
+	fmt.Fprint(w, "ond\n\nlast")
+	if want := []string{"first", "second", ""}; fmt.Sprint(lines) != fmt.Sprint(want) {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+	w.Flush()
+	w.Flush()
+	if len(lines) != 4 || lines[3] != "last" {
+		t.Errorf("lines = %q, want the unterminated line once after Flush", lines)
+	}
+}
@@ -50,6 +50,8 @@ type Logger struct {
 	quiet        bool
 	verbose      bool
 	mu           sync.Mutex
+	// wmu is shared with the logger's copies so their lines never interleave
+	wmu *sync.Mutex
 }
 
 // New creates a new Logger with default stdout/stderr
@@ -59,6 +61,7 @@ func New() *Logger {
 		err:          os.Stderr,
 		level:        InfoLevel,
 		outputFormat: "text",
+		wmu:          new(sync.Mutex),
 	}
 }
 
@@ -69,6 +72,7 @@ func NewWithWriters(out, err io.Writer) *Logger {
 		err:          err,
 		level:        InfoLevel,
 		outputFormat: "text",
+		wmu:          new(sync.Mutex),
 	}
 }
 
@@ -130,9 +134,18 @@ func (l *Logger) WithPrefix(prefix string) *Logger {
 		outputFormat: l.outputFormat,
 		quiet:        l.quiet,
 		verbose:      l.verbose,
+		wmu:          l.wmu,
 	}
 }
 
+// WithTee returns a copy of the logger that also writes its output to w
+func (l *Logger) WithTee(w io.Writer) *Logger {
+	c := l.WithPrefix(l.prefix)
+	c.out = io.MultiWriter(c.out, w)
+	c.err = io.MultiWriter(c.err, w)
+	return c
+}
+
 // Debug logs debug-level messages (only shown in verbose mode)
 func (l *Logger) Debug(msg string, meta ...Meta) {
 	l.log(DebugLevel, msg, meta...)
@@ -179,6 +192,8 @@ func (l *Logger) log(level Level, msg string, meta ...Meta) {
 		w = l.out
 	}
 
+	unlock := l.lockWrites()
+	defer unlock()
 	switch l.outputFormat {
 	case "json":
 		l.writeJSON(w, level, msg, metadata)
@@ -187,6 +202,29 @@ func (l *Logger) log(level Level, msg string, meta ...Meta) {
 	}
 }
 
+// Event writes v as a JSON line to the logger's output, never in the
+// middle of a line logged by the logger or its copies
+func (l *Logger) Event(v interface{ }) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	unlock := l.lockWrites()
+	defer unlock()
+	_, err = fmt.Fprintln(l.out, string(data))
+	return err
+}
+
+func (l *Logger) lockWrites() func() {
+	if l.wmu == nil {
+		return func() {}
+	}
+	l.wmu.Lock()
+	return l.wmu.Unlock
+}
+
 func (l *Logger) writeJSON(w io.Writer, level Level, msg string, meta Meta) {
 	entry := map[string]interface{ } {
 		"timestamp": time.Now().Format(time.RFC3339),
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -205,4 +206,53 @@ func TestStripMarkup(t *testing.T) {
 			t.Errorf("stripMarkup(%q) = %q, want %q", tt.input, result, tt.expected)
 		}
 	}
-}
\ No newline at end of file
+}
+func TestLoggerWithTee(t *testing.T) {
+	var out, errOut, tee bytes.Buffer
+	logger := NewWithWriters(&out, &errOut)
+	logger.SetOutputFormat("text")
+
+	teed := logger.WithTee(&tee).WithPrefix("[test-server]")
+	teed.Info("started")
+	teed.Error("failed")
+	logger.Info("not teed")
+
+	if !strings.Contains(out.String(), "started") || !strings.Contains(errOut.String(), "failed") {
+		t.Errorf("expected normal output, got out=%q err=%q", out.String(), errOut.String())
+	}
+	if got := tee.String(); !strings.Contains(got, "[test-server] started") || !strings.Contains(got, "failed") || strings.Contains(got, "not teed") {
+		t.Errorf("tee = %q", got)
+	}
+}
+
+func TestLoggerEvent(t *testing.T) {
+	var out bytes.Buffer
+	logger := NewWithWriters(&out, &out)
+	logger.SetOutputFormat("json")
+
+	// copies share the writer lock, so concurrent lines stay whole
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			prefixed := logger.WithPrefix("[test-server]")
+			for j := 0; j < 50; j++ {
+				prefixed.Info("message")
+				prefixed.Event(map[string]int{"n": j})
+			}
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 400 {
+		t.Fatalf("got %d lines, want 400", len(lines))
+	}
+	for _, line := range lines {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+	}
+}
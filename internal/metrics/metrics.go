@@ -0,0 +1,259 @@
+// internal/metrics/metrics.go
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devtheops/gsbt/internal/backup"
+)
+
+// Metric names are part of gsbt's interface: dashboards and alerts depend
+// on them, so never rename one. Every metric has a single "server" label.
+const (
+	LastRun     = "gsbt_backup_last_run_timestamp_seconds"
+	LastSuccess = "gsbt_backup_last_success_timestamp_seconds"
+	Success     = "gsbt_backup_success"
+	Duration    = "gsbt_backup_duration_seconds"
+	Bytes       = "gsbt_backup_bytes"
+	Files       = "gsbt_backup_files"
+	Suspicious  = "gsbt_backup_suspicious"
+	Failures    = "gsbt_backup_failures_total"
+)
+
+type metric struct {
+	name, kind, help string
+	value            func(s *server) (float64, bool)
+}
+
+// metrics lists what Write exposes, in order
+var metrics = []metric{
+	{LastRun, "gauge", "Unix time the last backup finished",
+		func(s *server) (float64, bool) { return unix(s.lastRun) }},
+	{LastSuccess, "gauge", "Unix time the last successful backup finished",
+		func(s *server) (float64, bool) { return unix(s.lastSuccess) }},
+	{Success, "gauge", "Whether the last backup succeeded (1) or failed (0)",
+		func(s *server) (float64, bool) { return bit(s.success), !s.lastRun.IsZero() }},
+	{Duration, "gauge", "Duration of the last backup in seconds",
+		func(s *server) (float64, bool) { return s.duration.Seconds(), !s.lastRun.IsZero() }},
+	{Bytes, "gauge", "Bytes archived by the last successful backup",
+		func(s *server) (float64, bool) { return float64(s.bytes), !s.lastSuccess.IsZero() }},
+	{Files, "gauge", "Files archived by the last successful backup",
+		func(s *server) (float64, bool) { return float64(s.files), !s.lastSuccess.IsZero() }},
+	{Suspicious, "gauge", "Whether the last successful backup was marked suspicious by a sanity guard",
+		func(s *server) (float64, bool) { return bit(s.suspicious), !s.lastSuccess.IsZero() }},
+	{Failures, "counter", "Failed backups",
+		func(s *server) (float64, bool) { return float64(s.failures), true }},
+}
+
+// Run is the outcome of one server's backup
+type Run struct {
+	Server   string
+	End      time.Time
+	Duration time.Duration
+	Stats    backup.Stats
+	Err      error
+}
+
+type server struct {
+	lastRun, lastSuccess time.Time
+	success, suspicious  bool
+	duration             time.Duration
+	bytes                int64
+	files                int
+	failures             int
+}
+
+// Registry holds the latest backup metrics of each server. It serves them
+// over HTTP in the Prometheus text format.
+type Registry struct {
+	mu      sync.Mutex
+	servers map[string]*server
+	// fileMu keeps concurrent WriteFiles from replacing newer metrics with older
+	fileMu sync.Mutex
+}
+
+// New returns an empty registry
+func New() *Registry {
+	return &Registry{servers: map[string]*server{}}
+}
+
+func (r *Registry) server(name string) *server {
+	s, ok := r.servers[name]
+	if !ok {
+		s = &server{}
+		r.servers[name] = s
+	}
+	return s
+}
+
+// Record updates a server's metrics with a backup's outcome
+func (r *Registry) Record(run Run) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.server(run.Server)
+	s.lastRun = run.End
+	s.duration = run.Duration
+	s.success = run.Err == nil
+	if run.Err != nil {
+		s.failures++
+		return
+	}
+	s.lastSuccess = run.End
+	s.bytes, s.files = run.Stats.Bytes, run.Stats.Files
+	s.suspicious = len(run.Stats.Suspicious) > 0
+}
+
+// Write writes the metrics in the Prometheus text format
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.servers))
+	for name := range r.servers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+		for _, name := range names {
+			if v, ok := m.value(r.servers[name]); ok {
+				fmt.Fprintf(bw, "%s{server=\"%s\"} %s\n", m.name, escape(name),
+					strconv.FormatFloat(v, 'f', -1, 64))
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// ServeHTTP serves the metrics to a Prometheus scrape
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.Write(w)
+}
+
+// WriteFile writes the metrics for node_exporter's textfile collector,
+// replacing path in one step so the collector never reads a partial file
+func (r *Registry) WriteFile(path string) error {
+	r.fileMu.Lock()
+	defer r.fileMu.Unlock()
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	f, err := os.CreateTemp(dir, "."+base+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := r.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Load reads metrics written by WriteFile, so servers not backed up in this
+// run keep their values and counters keep counting. A missing file is not
+// an error.
+func (r *Registry) Load(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		name, srv, value, ok := parseLine(scanner.Text())
+		if !ok || !slices.ContainsFunc(metrics, func(m metric) bool { return m.name == name }) {
+			continue
+		}
+		s := r.server(srv)
+		switch name {
+		case LastRun:
+			s.lastRun = fromUnix(value)
+		case LastSuccess:
+			s.lastSuccess = fromUnix(value)
+		case Success:
+			s.success = value == 1
+		case Duration:
+			s.duration = time.Duration(value * float64(time.Second))
+		case Bytes:
+			s.bytes = int64(value)
+		case Files:
+			s.files = int(value)
+		case Suspicious:
+			s.suspicious = value == 1
+		case Failures:
+			s.failures = int(value)
+		}
+	}
+	return scanner.Err()
+}
+
+// parseLine parses a sample line as Write formats it
+func parseLine(line string) (name, server string, value float64, ok bool) {
+	name, rest, found := strings.Cut(line, `{server="`)
+	if !found || strings.HasPrefix(line, "#") {
+		return "", "", 0, false
+	}
+	i := strings.LastIndex(rest, `"} `)
+	if i < 0 {
+		return "", "", 0, false
+	}
+	value, err := strconv.ParseFloat(rest[i+3:], 64)
+	if err != nil {
+		return "", "", 0, false
+	}
+	return name, unescape(rest[:i]), value, true
+}
+
+var (
+	escaper   = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	unescaper = strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\n`, "\n")
+)
+
+func escape(s string) string   { return escaper.Replace(s) }
+func unescape(s string) string { return unescaper.Replace(s) }
+
+func unix(t time.Time) (float64, bool) {
+	if t.IsZero() {
+		return 0, false
+	}
+	return float64(t.UnixMilli()) / 1000, true
+}
+
+func fromUnix(v float64) time.Time {
+	return time.UnixMilli(int64(v * 1000))
+}
+
+func bit(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
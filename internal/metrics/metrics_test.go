@@ -0,0 +1,115 @@
+// internal/metrics/metrics_test.go
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devtheops/gsbt/internal/backup"
+)
+
+var end = time.Date(2026, 3, 1, 4, 0, 0, 0, time.UTC)
+
+func TestRegistryWrite(t *testing.T) {
+	r := New()
+	r.Record(Run{Server: "valheim", End: end, Duration: 90 * time.Second,
+		Stats: backup.Stats{Files: 12, Bytes: 4096, Suspicious: []string{"too few files"}}})
+	r.Record(Run{Server: `odd "name"`, End: end, Duration: time.Second, Err: errors.New("refused")})
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"# HELP gsbt_backup_duration_seconds Duration of the last backup in seconds\n# TYPE gsbt_backup_duration_seconds gauge\n",
+		`gsbt_backup_last_run_timestamp_seconds{server="valheim"} 1772337600` + "\n",
+		`gsbt_backup_last_success_timestamp_seconds{server="valheim"} 1772337600` + "\n",
+		`gsbt_backup_success{server="odd \"name\""} 0` + "\n",
+		`gsbt_backup_success{server="valheim"} 1` + "\n",
+		`gsbt_backup_duration_seconds{server="valheim"} 90` + "\n",
+		`gsbt_backup_bytes{server="valheim"} 4096` + "\n",
+		`gsbt_backup_files{server="valheim"} 12` + "\n",
+		`gsbt_backup_suspicious{server="valheim"} 1` + "\n",
+		"# TYPE gsbt_backup_failures_total counter\n",
+		`gsbt_backup_failures_total{server="odd \"name\""} 1` + "\n",
+		`gsbt_backup_failures_total{server="valheim"} 0` + "\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+	// a server that never succeeded has no success time or sizes
+	if strings.Contains(got, `gsbt_backup_bytes{server="odd`) || strings.Contains(got, `gsbt_backup_last_success_timestamp_seconds{server="odd`) {
+		t.Errorf("unexpected metrics for a failing server:\n%s", got)
+	}
+}
+
+func TestRegistryFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gsbt.prom")
+
+	r := New()
+	if err := r.Load(path); err != nil {
+		t.Fatalf("Load of a missing file: %v", err)
+	}
+	r.Record(Run{Server: "valheim", End: end, Duration: 1500 * time.Millisecond, Stats: backup.Stats{Files: 3, Bytes: 100}})
+	r.Record(Run{Server: "ark", End: end, Err: errors.New("refused")})
+	if err := r.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	// the next run only backs up ark, which fails again
+	next := New()
+	if err := next.Load(path); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	next.Record(Run{Server: "ark", End: end.Add(time.Hour), Err: errors.New("refused")})
+	if err := next.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	got := string(data)
+	for _, want := range []string{
+		`gsbt_backup_failures_total{server="ark"} 2`,
+		`gsbt_backup_last_run_timestamp_seconds{server="ark"} 1772341200`,
+		`gsbt_backup_duration_seconds{server="valheim"} 1.5`,
+		`gsbt_backup_files{server="valheim"} 3`,
+		`gsbt_backup_last_success_timestamp_seconds{server="valheim"} 1772337600`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only the metrics file", len(entries))
+	}
+}
+
+func TestRegistryServeHTTP(t *testing.T) {
+	r := New()
+	r.Record(Run{Server: "valheim", End: end, Stats: backup.Stats{Files: 1}})
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if !strings.Contains(string(body), `gsbt_backup_files{server="valheim"} 1`) {
+		t.Errorf("body = %s", body)
+	}
+}
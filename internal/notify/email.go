@@ -0,0 +1,269 @@
+// internal/notify/email.go
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/devtheops/gsbt/internal/bytesize"
+)
+
+// SMTPOptions configure an email target
+type SMTPOptions struct {
+	Host string
+	// Port defaults to 587 for starttls, 465 for implicit and 25 for none
+	Port int
+	// TLS is starttls (default), implicit or none
+	TLS      string
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+type emailSender struct {
+	opts SMTPOptions
+	addr string
+	// tlsConfig overrides the default verification, for tests
+	tlsConfig *tls.Config
+}
+
+func newEmailSender(opts SMTPOptions) (*emailSender, error) {
+	if opts.Host == "" {
+		return nil, fmt.Errorf("email: host is required")
+	}
+	if _, err := mail.ParseAddress(opts.From); err != nil {
+		return nil, fmt.Errorf("email: invalid from address %q", opts.From)
+	}
+	if len(opts.To) == 0 {
+		return nil, fmt.Errorf("email: to is required")
+	}
+	for _, to := range opts.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return nil, fmt.Errorf("email: invalid to address %q", to)
+		}
+	}
+
+	opts.TLS = strings.ToLower(opts.TLS)
+	port := opts.Port
+	switch opts.TLS {
+	case "", "starttls":
+		opts.TLS = "starttls"
+		port = orDefault(port, 587)
+	case "implicit":
+		port = orDefault(port, 465)
+	case "none":
+		port = orDefault(port, 25)
+	default:
+		return nil, fmt.Errorf("email: unsupported tls %q (want starttls, implicit or none)", opts.TLS)
+	}
+	return &emailSender{opts: opts, addr: net.JoinHostPort(opts.Host, strconv.Itoa(port))}, nil
+}
+
+func orDefault(v, def int) int {
+	if v > 0 {
+		return v
+	}
+	return def
+}
+
+func (e *emailSender) send(ctx context.Context, m Message) error {
+	msg, err := e.compose(m, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	tlsConfig := e.tlsConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{ServerName: e.opts.Host}
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", e.addr)
+	if err != nil {
+		return err
+	}
+	if e.opts.TLS == "implicit" {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	// net/smtp has no context support; the deadline bounds the session
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, e.opts.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if e.opts.TLS == "starttls" {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if e.opts.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.opts.Username, e.opts.Password, e.opts.Host)); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+
+	from, _ := mail.ParseAddress(e.opts.From)
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, to := range e.opts.To {
+		addr, _ := mail.ParseAddress(to)
+		if err := c.Rcpt(addr.Address); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// compose builds a multipart/alternative message with a plain text and an
+// HTML version of the summary table
+func (e *emailSender) compose(m Message, now time.Time) ([]byte, error) {
+	rows := emailRows(m.Summary.Events)
+
+	var text bytes.Buffer
+	text.WriteString(m.Title + "\n\n")
+	tw := tabwriter.NewWriter(&text, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERVER\tOUTCOME\tARCHIVE\tFILES\tSIZE\tDURATION")
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Server, r.Outcome, dash(r.Archive), dash(r.Files), dash(r.Size), dash(r.Duration))
+	}
+	tw.Flush()
+	for _, r := range rows {
+		if r.Error != "" {
+			fmt.Fprintf(&text, "\n%s: %s", r.Server, r.Error)
+		}
+	}
+	text.WriteString("\n")
+
+	var html bytes.Buffer
+	if err := emailHTML.Execute(&html, map[string]any{"Title": m.Title, "Rows": rows}); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	body := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "From: %s\r\n", e.opts.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(e.opts.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Title))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", body.Boundary())
+
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{
+		{"text/plain; charset=utf-8", text.Bytes()},
+		{"text/html; charset=utf-8", html.Bytes()},
+	} {
+		w, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write(part.content); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// emailRow is one event as shown in the summary table
+type emailRow struct {
+	Server   string
+	Outcome  string
+	Archive  string
+	Files    string
+	Size     string
+	Duration string
+	Error    string
+	Failed   bool
+	Warning  bool
+}
+
+func emailRows(events []Event) []emailRow {
+	rows := make([]emailRow, 0, len(events))
+	for _, e := range events {
+		r := emailRow{Server: e.Server, Outcome: string(e.Type), Error: e.Error}
+		switch e.Type {
+		case EventFailure:
+			r.Failed = true
+		case EventSuspicious:
+			r.Warning = true
+			r.Error = strings.Join(e.Stats.Suspicious, "; ")
+		case EventPrune:
+			r.Outcome = fmt.Sprintf("pruned %d archives", len(e.Pruned))
+		}
+		if e.Archive != "" {
+			r.Archive = filepath.Base(e.Archive)
+			r.Files = strconv.Itoa(e.Stats.Files)
+			r.Size = bytesize.Format(e.Stats.Bytes)
+		}
+		if e.Stats.Duration > 0 {
+			r.Duration = e.Stats.Duration.Round(100 * time.Millisecond).String()
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+var emailHTML = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html><body style="font-family: sans-serif">
+<h2>{{.Title}}</h2>
+<table cellpadding="6" style="border-collapse: collapse">
+<tr style="background: #eeeeee; text-align: left"><th>Server</th><th>Outcome</th><th>Archive</th><th>Files</th><th>Size</th><th>Duration</th><th>Error</th></tr>
+{{range .Rows}}<tr style="border-top: 1px solid #dddddd{{if .Failed}}; background: #fde2e2{{else if .Warning}}; background: #fff4d6{{end}}">
+<td>{{.Server}}</td><td>{{if .Failed}}<strong>{{.Outcome}}</strong>{{else}}{{.Outcome}}{{end}}</td><td>{{.Archive}}</td><td>{{.Files}}</td><td>{{.Size}}</td><td>{{.Duration}}</td><td>{{.Error}}</td>
+</tr>
+{{end}}</table>
+</body></html>
+`))
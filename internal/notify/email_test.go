@@ -0,0 +1,258 @@
+// internal/notify/email_test.go
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpMail is what the stand-in server received
+type smtpMail struct {
+	tls  bool
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// smtpServer is a minimal in-process SMTP server accepting one message
+type smtpServer struct {
+	addr     string
+	implicit bool
+	tls      *tls.Config
+	mail     chan smtpMail
+}
+
+func newSMTPServer(t *testing.T, implicit bool) (*smtpServer, *tls.Config) {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+
+	s := &smtpServer{
+		implicit: implicit,
+		tls:      &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}},
+		mail:     make(chan smtpMail, 1),
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s.addr = ln.Addr().String()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if implicit {
+			conn = tls.Server(conn, s.tls)
+		}
+		s.serve(conn)
+	}()
+	return s, &tls.Config{RootCAs: roots, ServerName: "127.0.0.1"}
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	tp := textproto.NewConn(conn)
+	m := smtpMail{tls: s.implicit}
+	tp.PrintfLine("220 stand-in ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		switch strings.ToUpper(fields[0]) {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-stand-in")
+			if !m.tls {
+				tp.PrintfLine("250-STARTTLS")
+			}
+			tp.PrintfLine("250 AUTH PLAIN")
+		case "STARTTLS":
+			tp.PrintfLine("220 ready")
+			tc := tls.Server(conn, s.tls)
+			if tc.Handshake() != nil {
+				return
+			}
+			conn, tp, m.tls = tc, textproto.NewConn(tc), true
+		case "AUTH":
+			creds, _ := base64.StdEncoding.DecodeString(fields[2])
+			m.auth = string(creds)
+			tp.PrintfLine("235 ok")
+		case "MAIL":
+			m.from = line
+			tp.PrintfLine("250 ok")
+		case "RCPT":
+			m.to = append(m.to, line)
+			tp.PrintfLine("250 ok")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, _ := tp.ReadDotBytes()
+			m.data = string(data)
+			tp.PrintfLine("250 queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			s.mail <- m
+			return
+		default:
+			tp.PrintfLine("502 unknown")
+		}
+	}
+}
+
+func newEmailTarget(t *testing.T, s *smtpServer, clientTLS *tls.Config, mode string) *Target {
+	t.Helper()
+	host, port, _ := net.SplitHostPort(s.addr)
+	portNum, _ := strconv.Atoi(port)
+	target, err := New(Options{
+		Type:   "email",
+		Events: []string{"success", "failure", "suspicious"},
+		SMTP: SMTPOptions{
+			Host: host, Port: portNum, TLS: mode,
+			Username: "gsbt", Password: "hunter2",
+			From: "gsbt <gsbt@example.com>",
+			To:   []string{"ops@example.com", "Owner <owner@example.com>"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	target.sender.(*emailSender).tlsConfig = clientTLS
+	return target
+}
+
+// parts returns the decoded plain text and HTML bodies of a message
+func parts(t *testing.T, data string) (*mail.Message, string, string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	var bodies []string
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		io.Copy(&buf, p) // quoted-printable is decoded by NextPart
+		bodies = append(bodies, buf.String())
+	}
+	if len(bodies) != 2 {
+		t.Fatalf("got %d parts, want text and html", len(bodies))
+	}
+	return msg, bodies[0], bodies[1]
+}
+
+func TestEmailStartTLS(t *testing.T) {
+	s, clientTLS := newSMTPServer(t, false)
+	target := newEmailTarget(t, s, clientTLS, "starttls")
+	if err := target.Notify(context.Background(), testSummary()); err != nil {
+		t.Fatalf("Notify error: %v", err)
+	}
+
+	m := <-s.mail
+	if !m.tls || m.auth != "\x00gsbt\x00hunter2" {
+		t.Errorf("tls = %v, auth = %q; want PLAIN auth after STARTTLS", m.tls, m.auth)
+	}
+	if m.from != "MAIL FROM:<gsbt@example.com>" || len(m.to) != 2 || m.to[1] != "RCPT TO:<owner@example.com>" {
+		t.Errorf("envelope = %q %q", m.from, m.to)
+	}
+
+	msg, text, html := parts(t, m.data)
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "gsbt backup: 1 failed, 1 suspicious, 1 succeeded" {
+		t.Errorf("subject = %q", subject)
+	}
+	for _, want := range []string{
+		"SERVER   OUTCOME     ARCHIVE                   FILES  SIZE     DURATION",
+		"ark      success     2026-03-01_040000.tar.gz  12     3.0 MiB  4.2s",
+		"rust     failure     -                         -      -        -",
+		"rust: connect: connection refused",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text part missing %q:\n%s", want, text)
+		}
+	}
+	for _, want := range []string{
+		`background: #fde2e2">`,
+		`<td>rust</td><td><strong>failure</strong></td>`,
+		`<td>1 files, want at least 5</td>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("html part missing %q:\n%s", want, html)
+		}
+	}
+	if strings.Contains(text, "pruned") {
+		t.Error("prune event sent although not in events")
+	}
+}
+
+func TestEmailImplicitTLS(t *testing.T) {
+	s, clientTLS := newSMTPServer(t, true)
+	target := newEmailTarget(t, s, clientTLS, "implicit")
+	if err := target.Notify(context.Background(), testSummary()); err != nil {
+		t.Fatalf("Notify error: %v", err)
+	}
+	if m := <-s.mail; !m.tls || m.data == "" {
+		t.Errorf("mail = %+v, want a message over TLS", m)
+	}
+}
+
+func TestEmailOptionsInvalid(t *testing.T) {
+	valid := SMTPOptions{Host: "smtp.example.com", From: "gsbt@example.com", To: []string{"ops@example.com"}}
+	if s, err := newEmailSender(valid); err != nil || s.addr != "smtp.example.com:587" {
+		t.Errorf("newEmailSender = %+v, %v; want port 587", s, err)
+	}
+	for _, change := range []func(o *SMTPOptions){
+		func(o *SMTPOptions) { o.Host = "" },
+		func(o *SMTPOptions) { o.From = "not an address" },
+		func(o *SMTPOptions) { o.To = nil },
+		func(o *SMTPOptions) { o.TLS = "sometimes" },
+	} {
+		opts := valid
+		change(&opts)
+		if _, err := newEmailSender(opts); err == nil {
+			t.Errorf("newEmailSender(%+v) expected error", opts)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// internal/notify/notify.go
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/devtheops/gsbt/internal/backup"
+	"github.com/devtheops/gsbt/internal/bytesize"
+)
+
+// EventType classifies the outcome of a server's run
+type EventType string
+
+const (
+	EventSuccess    EventType = "success"
+	EventFailure    EventType = "failure"
+	EventSuspicious EventType = "suspicious"
+	EventPrune      EventType = "prune"
+)
+
+// DefaultEvents are notified when a target doesn't choose any
+var DefaultEvents = []EventType{EventFailure, EventSuspicious}
+
+// ParseEvents validates event names, returning DefaultEvents for none
+func ParseEvents(names []string) ([]EventType, error) {
+	if len(names) == 0 {
+		return DefaultEvents, nil
+	}
+	var events []EventType
+	for _, name := range names {
+		switch e := EventType(strings.ToLower(name)); e {
+		case EventSuccess, EventFailure, EventSuspicious, EventPrune:
+			events = append(events, e)
+		default:
+			return nil, fmt.Errorf("unknown event %q (want success, failure, suspicious or prune)", name)
+		}
+	}
+	return events, nil
+}
+
+// Event is the outcome of one server's backup or prune
+type Event struct {
+	Type    EventType
+	Server  string
+	Time    time.Time
+	Archive string
+	Stats   backup.Stats
+	// Pruned lists the archives a prune deleted
+	Pruned []string
+	Error  string
+}
+
+// Summary batches the events of one gsbt run into a single notification
+type Summary struct {
+	Command string
+	Events  []Event
+}
+
+// Level is the severity of a message, from its worst event
+type Level int
+
+const (
+	LevelInfo Level = iota
+	LevelWarning
+	LevelError
+)
+
+// Message is a rendered summary, ready to be sent
+type Message struct {
+	Title string
+	Lines []string
+	Level Level
+	// Summary holds the events the message was rendered from
+	Summary Summary
+}
+
+// Text is the title followed by one line per event
+func (m Message) Text() string {
+	return m.Title + "\n" + strings.Join(m.Lines, "\n")
+}
+
+// DefaultTemplate renders one line per event
+const DefaultTemplate = `{{.Server}}: ` +
+	`{{if eq .Type "failure"}}failed: {{.Error}}` +
+	`{{else if eq .Type "prune"}}pruned {{len .Pruned}} archives` +
+	`{{else}}saved {{base .Archive}} ({{.Stats.Files}} files, {{size .Stats.Bytes}}, {{duration .Stats.Duration}})` +
+	`{{if .Stats.Suspicious}}, suspicious: {{join .Stats.Suspicious "; "}}{{end}}` +
+	`{{if .Stats.Changed}}, changed during download: {{join .Stats.Changed ", "}}{{end}}{{end}}`
+
+var funcs = template.FuncMap{
+	"base": filepath.Base,
+	"join": strings.Join,
+	"size": bytesize.Format,
+	"duration": func(d time.Duration) string {
+		return d.Round(100 * time.Millisecond).String()
+	},
+}
+
+// ParseTemplate parses a per-event template, or DefaultTemplate when empty
+func ParseTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultTemplate
+	}
+	t, err := template.New("event").Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return t, nil
+}
+
+// Options configure a target
+type Options struct {
+	// Type is webhook, discord, slack or email
+	Type     string
+	URL      string
+	Headers  map[string]string
+	Events   []string
+	Template string
+	// SMTP configures email targets
+	SMTP SMTPOptions
+}
+
+// requestTimeout bounds each delivery
+const requestTimeout = 15 * time.Second
+
+// New returns the target described by opts
+func New(opts Options) (*Target, error) {
+	events, err := ParseEvents(opts.Events)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := ParseTemplate(opts.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Target{Name: strings.ToLower(opts.Type), Events: events, Template: tmpl}
+	switch t.Name {
+	case "webhook", "discord", "slack":
+		if opts.URL == "" {
+			return nil, fmt.Errorf("%s: url is required", t.Name)
+		}
+		t.sender = &webhookSender{kind: t.Name, url: opts.URL, headers: opts.Headers}
+	case "email":
+		if t.sender, err = newEmailSender(opts.SMTP); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported notification type %q (want webhook, discord, slack or email)", opts.Type)
+	}
+	return t, nil
+}
+
+// sender delivers a rendered message to one kind of service
+type sender interface {
+	send(ctx context.Context, m Message) error
+}
+
+// Target is a configured destination with its event filter and template
+type Target struct {
+	Name     string
+	Events   []EventType
+	Template *template.Template
+
+	sender sender
+}
+
+// Notify renders the events of s the target is interested in and sends
+// them as one message. Nothing is sent when no event matches.
+func (t *Target) Notify(ctx context.Context, s Summary) error {
+	m, ok, err := t.render(s)
+	if err != nil || !ok {
+		return err
+	}
+	if err := t.sender.send(ctx, m); err != nil {
+		return fmt.Errorf("%s: %w", t.Name, err)
+	}
+	return nil
+}
+
+func (t *Target) render(s Summary) (Message, bool, error) {
+	m := Message{Summary: Summary{Command: s.Command}}
+	counts := map[EventType]int{}
+	for _, e := range s.Events {
+		if !slices.Contains(t.Events, e.Type) {
+			continue
+		}
+		var line strings.Builder
+		if err := t.Template.Execute(&line, e); err != nil {
+			return m, false, fmt.Errorf("%s: %w", t.Name, err)
+		}
+		m.Lines = append(m.Lines, line.String())
+		m.Summary.Events = append(m.Summary.Events, e)
+		counts[e.Type]++
+
+		switch e.Type {
+		case EventFailure:
+			m.Level = LevelError
+		case EventSuspicious:
+			m.Level = max(m.Level, LevelWarning)
+		}
+	}
+	if len(m.Lines) == 0 {
+		return m, false, nil
+	}
+
+	var parts []string
+	for _, c := range []struct {
+		event EventType
+		word  string
+	}{
+		{EventFailure, "failed"},
+		{EventSuspicious, "suspicious"},
+		{EventSuccess, "succeeded"},
+		{EventPrune, "pruned"},
+	} {
+		if n := counts[c.event]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, c.word))
+		}
+	}
+	m.Title = fmt.Sprintf("gsbt %s: %s", s.Command, strings.Join(parts, ", "))
+	return m, true, nil
+}
+
+// NotifyAll sends s to every target, returning their errors joined
+func NotifyAll(ctx context.Context, targets []*Target, s Summary) error {
+	var errs []error
+	for _, t := range targets {
+		errs = append(errs, t.Notify(ctx, s))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,174 @@
+// internal/notify/notify_test.go
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/devtheops/gsbt/internal/backup"
+)
+
+// receiver records the JSON bodies posted to it
+type receiver struct {
+	*httptest.Server
+	bodies  []map[string]any
+	headers []http.Header
+	status  int
+}
+
+func newReceiver(t *testing.T) *receiver {
+	r := &receiver{status: http.StatusNoContent}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		data, _ := io.ReadAll(req.Body)
+		var body map[string]any
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("invalid JSON body %q: %v", data, err)
+		}
+		r.bodies = append(r.bodies, body)
+		r.headers = append(r.headers, req.Header)
+		w.WriteHeader(r.status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func testSummary() Summary {
+	at := time.Date(2026, 3, 1, 4, 0, 0, 0, time.UTC)
+	return Summary{Command: "backup", Events: []Event{
+		{Type: EventSuccess, Server: "ark", Time: at, Archive: "/b/ark/2026-03-01_040000.tar.gz",
+			Stats: backup.Stats{Files: 12, Bytes: 3 << 20, Duration: 4200 * time.Millisecond}},
+		{Type: EventFailure, Server: "rust", Time: at, Error: "connect: connection refused"},
+		{Type: EventSuspicious, Server: "valheim", Time: at, Archive: "/b/valheim/2026-03-01_040000.tar.gz",
+			Stats: backup.Stats{Files: 1, Bytes: 10, Suspicious: []string{"1 files, want at least 5"}}},
+		{Type: EventPrune, Server: "ark", Time: at, Pruned: []string{"/b/ark/2026-01-01_040000.tar.gz"}},
+	}}
+}
+
+func TestWebhook(t *testing.T) {
+	r := newReceiver(t)
+	target, err := New(Options{
+		Type:    "webhook",
+		URL:     r.URL,
+		Headers: map[string]string{"Authorization": "Bearer secret"},
+		Events:  []string{"success", "failure", "suspicious", "prune"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := target.Notify(context.Background(), testSummary()); err != nil {
+		t.Fatalf("Notify error: %v", err)
+	}
+
+	if len(r.bodies) != 1 {
+		t.Fatalf("got %d requests, want one batched summary", len(r.bodies))
+	}
+	body := r.bodies[0]
+	if got := r.headers[0].Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("Authorization = %q", got)
+	}
+	if body["title"] != "gsbt backup: 1 failed, 1 suspicious, 1 succeeded, 1 pruned" {
+		t.Errorf("title = %q", body["title"])
+	}
+	wantText := strings.Join([]string{
+		"ark: saved 2026-03-01_040000.tar.gz (12 files, 3.0 MiB, 4.2s)",
+		"rust: failed: connect: connection refused",
+		"valheim: saved 2026-03-01_040000.tar.gz (1 files, 10 B, 0s), suspicious: 1 files, want at least 5",
+		"ark: pruned 1 archives",
+	}, "\n")
+	if body["text"] != wantText {
+		t.Errorf("text =\n%s\nwant\n%s", body["text"], wantText)
+	}
+	events := body["events"].([]any)
+	first := events[0].(map[string]any)
+	if len(events) != 4 || first["server"] != "ark" || first["files"] != float64(12) || first["duration_sec"] != 4.2 {
+		t.Errorf("events = %v", events)
+	}
+}
+
+func TestDiscordAndSlack(t *testing.T) {
+	discord, slack := newReceiver(t), newReceiver(t)
+	var targets []*Target
+	for _, opts := range []Options{
+		{Type: "discord", URL: discord.URL},
+		{Type: "slack", URL: slack.URL, Template: "{{.Server}} is {{.Type}}"},
+	} {
+		target, err := New(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		targets = append(targets, target)
+	}
+	if err := NotifyAll(context.Background(), targets, testSummary()); err != nil {
+		t.Fatalf("NotifyAll error: %v", err)
+	}
+
+	// the default filter is failure and suspicious
+	embed := discord.bodies[0]["embeds"].([]any)[0].(map[string]any)
+	if embed["title"] != "gsbt backup: 1 failed, 1 suspicious" || embed["color"] != float64(levelColors[LevelError]) {
+		t.Errorf("embed = %v", embed)
+	}
+	if desc := embed["description"].(string); !strings.HasPrefix(desc, "rust: failed") || strings.Contains(desc, "ark") {
+		t.Errorf("description = %q", desc)
+	}
+
+	attachment := slack.bodies[0]["attachments"].([]any)[0].(map[string]any)
+	if slack.bodies[0]["text"] != "gsbt backup: 1 failed, 1 suspicious" || attachment["text"] != "rust is failure\nvalheim is suspicious" {
+		t.Errorf("slack payload = %v", slack.bodies[0])
+	}
+}
+
+func TestDiscordPayloadTruncates(t *testing.T) {
+	// a multi-byte character straddles the cut in bytes
+	m := Message{Title: "gsbt backup", Lines: []string{strings.Repeat("ü", discordMaxDescription+10)}}
+	body, err := json.Marshal(discordPayload(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload struct {
+		Embeds []struct{ Description string } `json:"embeds"`
+	}
+	json.Unmarshal(body, &payload)
+	desc := payload.Embeds[0].Description
+	if !utf8.ValidString(desc) || utf8.RuneCountInString(desc) != discordMaxDescription || !strings.HasSuffix(desc, "ü...") {
+		t.Errorf("description has %d characters, valid UTF-8 %v", utf8.RuneCountInString(desc), utf8.ValidString(desc))
+	}
+}
+
+func TestNotifyNothingToSend(t *testing.T) {
+	r := newReceiver(t)
+	target, _ := New(Options{Type: "discord", URL: r.URL})
+	s := Summary{Command: "backup", Events: []Event{{Type: EventSuccess, Server: "ark"}}}
+	if err := target.Notify(context.Background(), s); err != nil || len(r.bodies) != 0 {
+		t.Errorf("Notify = %v with %d requests, want nothing sent", err, len(r.bodies))
+	}
+}
+
+func TestNotifyError(t *testing.T) {
+	r := newReceiver(t)
+	r.status = http.StatusTooManyRequests
+	target, _ := New(Options{Type: "slack", URL: r.URL})
+	err := target.Notify(context.Background(), testSummary())
+	if err == nil || !strings.Contains(err.Error(), "slack: 429") {
+		t.Errorf("err = %v, want 429 from slack", err)
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	for _, opts := range []Options{
+		{Type: "pager", URL: "http://x"},
+		{Type: "discord"},
+		{Type: "slack", URL: "http://x", Events: []string{"sometimes"}},
+		{Type: "webhook", URL: "http://x", Template: "{{.Server"},
+	} {
+		if _, err := New(opts); err == nil {
+			t.Errorf("New(%+v) expected error", opts)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// internal/notify/webhook.go
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// webhookSender posts JSON: the summary itself for generic webhooks, or
+// the payload Discord and Slack-compatible incoming webhooks expect
+type webhookSender struct {
+	kind    string
+	url     string
+	headers map[string]string
+}
+
+func (w *webhookSender) send(ctx context.Context, m Message) error {
+	var payload any
+	switch w.kind {
+	case "discord":
+		payload = discordPayload(m)
+	case "slack":
+		payload = slackPayload(m)
+	default:
+		payload = webhookPayload(m)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gsbt")
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// webhookEvent is an Event as generic webhooks receive it
+type webhookEvent struct {
+	Type        EventType `json:"type"`
+	Server      string    `json:"server"`
+	Time        time.Time `json:"time"`
+	Archive     string    `json:"archive,omitempty"`
+	Files       int       `json:"files,omitempty"`
+	Bytes       int64     `json:"bytes,omitempty"`
+	DurationSec float64   `json:"duration_sec,omitempty"`
+	Changed     []string  `json:"changed,omitempty"`
+	Suspicious  []string  `json:"suspicious,omitempty"`
+	Pruned      []string  `json:"pruned,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+func webhookPayload(m Message) any {
+	events := make([]webhookEvent, 0, len(m.Summary.Events))
+	for _, e := range m.Summary.Events {
+		events = append(events, webhookEvent{
+			Type:        e.Type,
+			Server:      e.Server,
+			Time:        e.Time,
+			Archive:     e.Archive,
+			Files:       e.Stats.Files,
+			Bytes:       e.Stats.Bytes,
+			DurationSec: e.Stats.Duration.Seconds(),
+			Changed:     e.Stats.Changed,
+			Suspicious:  e.Stats.Suspicious,
+			Pruned:      e.Pruned,
+			Error:       e.Error,
+		})
+	}
+	return map[string]any{
+		"title":   m.Title,
+		"text":    strings.Join(m.Lines, "\n"),
+		"command": m.Summary.Command,
+		"events":  events,
+	}
+}
+
+var levelColors = map[Level]int{LevelInfo: 0x2eb67d, LevelWarning: 0xecb22e, LevelError: 0xe01e5a}
+
+// Discord caps an embed description at 4096 characters
+const discordMaxDescription = 4096
+
+func discordPayload(m Message) any {
+	desc := strings.Join(m.Lines, "\n")
+	if r := []rune(desc); len(r) > discordMaxDescription {
+		desc = string(r[:discordMaxDescription-3]) + "..."
+	}
+	return map[string]any{
+		"username": "gsbt",
+		"embeds": []map[string]any{{
+			"title":       m.Title,
+			"description": desc,
+			"color":       levelColors[m.Level],
+			"timestamp":   time.Now().UTC().Format(time.RFC3339),
+		}},
+	}
+}
+
+func slackPayload(m Message) any {
+	return map[string]any{
+		"text": m.Title,
+		"attachments": []map[string]any{{
+			"color":    fmt.Sprintf("#%06x", levelColors[m.Level]),
+			"text":     strings.Join(m.Lines, "\n"),
+			"fallback": m.Text(),
+		}},
+	}
+}
@@ -0,0 +1,205 @@
+// internal/progress/display.go
+package progress
+
+import (
+	"fmt"
+	"github.com/devtheops/gsbt/internal/bytesize"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// redrawInterval limits how often progress within a file redraws the bars
+const redrawInterval = 100 * time.Millisecond
+
+// IsTerminal reports whether w is a terminal that can show live progress
+// bars, enabling ANSI escape sequences where the console needs it
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return enableANSI(f)
+}
+
+// Display draws one live progress bar per server below the log output of a
+// terminal. Log lines must be written through Writer so they appear above
+// the bars instead of through them.
+type Display struct {
+	out   io.Writer
+	width func() int
+	now   func() time.Time
+
+	mu       sync.Mutex
+	bars     []*bar
+	drawn    int
+	lastDraw time.Time
+}
+
+// NewDisplay returns a display drawing on the terminal out
+func NewDisplay(out io.Writer) *Display {
+	width := func() int { return 80 }
+	if f, ok := out.(*os.File); ok {
+		width = func() int {
+			if w, _, err := term.GetSize(int(f.Fd())); err == nil && w > 0 {
+				return w
+			}
+			return 80
+		}
+	}
+	return &Display{out: out, width: width, now: time.Now}
+}
+
+// Writer returns w wrapped to write above the bars; w should be the
+// display's terminal or another stream shown on it, like stderr
+func (d *Display) Writer(w io.Writer) io.Writer {
+	return displayWriter{d: d, w: w}
+}
+
+type displayWriter struct {
+	d *Display
+	w io.Writer
+}
+
+func (dw displayWriter) Write(p []byte) (int, error) {
+	d := dw.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.clear()
+	n, err := dw.w.Write(p)
+	d.draw()
+	return n, err
+}
+
+// Remove takes a server's bar off the display, e.g. when its backup failed
+// before it finished downloading
+func (d *Display) Remove(server string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, b := range d.bars {
+		if b.server == server {
+			d.clear()
+			d.bars = append(d.bars[:i], d.bars[i+1:]...)
+			d.draw()
+			return
+		}
+	}
+}
+
+// Stop clears all bars
+func (d *Display) Stop() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.clear()
+	d.bars = nil
+}
+
+// update changes a server's bar, adding it when new. Changes within a file
+// only redraw every redrawInterval.
+func (d *Display) update(server string, throttle bool, fn func(b *bar)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var b *bar
+	for _, existing := range d.bars {
+		if existing.server == server {
+			b = existing
+		}
+	}
+	if b == nil {
+		b = &bar{server: server, start: d.now()}
+		d.bars = append(d.bars, b)
+		throttle = false
+	}
+	fn(b)
+
+	if throttle && d.now().Sub(d.lastDraw) < redrawInterval {
+		return
+	}
+	d.clear()
+	d.draw()
+}
+
+// clear erases the drawn bars, leaving the cursor where they started
+func (d *Display) clear() {
+	if d.drawn > 0 {
+		fmt.Fprintf(d.out, "\x1b[%dA\r\x1b[J", d.drawn)
+		d.drawn = 0
+	}
+}
+
+func (d *Display) draw() {
+	if len(d.bars) == 0 {
+		return
+	}
+	now := d.now()
+	// Lines one short of the width never wrap, which would break clear
+	width := d.width() - 1
+	nameWidth := 0
+	for _, b := range d.bars {
+		nameWidth = max(nameWidth, utf8.RuneCountInString(b.server))
+	}
+	var sb strings.Builder
+	for _, b := range d.bars {
+		sb.WriteString(truncate(b.render(nameWidth, now), width))
+		sb.WriteByte('\n')
+	}
+	io.WriteString(d.out, sb.String())
+	d.drawn = len(d.bars)
+	d.lastDraw = now
+}
+
+// bar is one server's download progress
+type bar struct {
+	server string
+	start  time.Time
+	total  int64
+	// done counts the files finished so far
+	done     int64
+	file     string
+	fileSize int64
+	written  int64
+}
+
+// render formats the bar as
+// "name [#####-----]  45%  1.2 GiB/2.6 GiB  12.3 MiB/s  ETA 1m52s  file"
+func (b *bar) render(nameWidth int, now time.Time) string {
+	const barWidth = 20
+	current := b.done + b.written
+	fraction := 1.0
+	if b.total > 0 {
+		fraction = min(float64(current)/float64(b.total), 1)
+	}
+	filled := int(fraction * barWidth)
+
+	line := fmt.Sprintf("%-*s [%s%s] %3.0f%%  %s/%s", nameWidth, b.server,
+		strings.Repeat("#", filled), strings.Repeat("-", barWidth-filled),
+		fraction*100, bytesize.Format(current), bytesize.Format(b.total))
+	if elapsed := now.Sub(b.start).Seconds(); elapsed > 0 && current > 0 {
+		rate := float64(current) / elapsed
+		eta := time.Duration(float64(max(b.total-current, 0)) / rate * float64(time.Second))
+		line += fmt.Sprintf("  %s/s  ETA %s", bytesize.Format(int64(rate)), eta.Round(time.Second))
+	}
+	if b.file != "" {
+		line += "  " + b.file
+	}
+	return line
+}
+
+// truncate cuts s to width runes
+func truncate(s string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width])
+}
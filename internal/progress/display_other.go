@@ -0,0 +1,11 @@
+// internal/progress/display_other.go
+//go:build !windows
+
+package progress
+
+import "os"
+
+// enableANSI is a no-op: terminals here understand escape sequences
+func enableANSI(f *os.File) bool {
+	return true
+}
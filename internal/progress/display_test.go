@@ -0,0 +1,130 @@
+// internal/progress/display_test.go
+package progress
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devtheops/gsbt/internal/log"
+)
+
+// testDisplay returns a display on out with a fixed width and a clock the
+// test moves
+func testDisplay(out *bytes.Buffer, width int) (*Display, *time.Time) {
+	now := time.Date(2026, 3, 1, 4, 0, 0, 0, time.UTC)
+	d := &Display{out: out, width: func() int { return width }, now: func() time.Time { return now }}
+	return d, &now
+}
+
+func TestBarRender(t *testing.T) {
+	start := time.Date(2026, 3, 1, 4, 0, 0, 0, time.UTC)
+	b := &bar{server: "ark", start: start, total: 2 << 30, done: 1<<30 - 100<<20,
+		file: "saves/world.dat", written: 100 << 20}
+
+	got := b.render(7, start.Add(100*time.Second))
+	want := "ark     [##########----------]  50%  1.0 GiB/2.0 GiB  10.2 MiB/s  ETA 1m40s  saves/world.dat"
+	if got != want {
+		t.Errorf("render =\n%q\nwant\n%q", got, want)
+	}
+
+	// nothing downloaded yet: no throughput or ETA
+	b = &bar{server: "ark", start: start, total: 1000}
+	if got := b.render(3, start.Add(time.Second)); got != "ark [--------------------]   0%  0 B/1000 B" {
+		t.Errorf("render = %q", got)
+	}
+}
+
+func TestDisplay(t *testing.T) {
+	var out bytes.Buffer
+	d, now := testDisplay(&out, 40)
+
+	d.update("alpha", false, func(b *bar) { b.total = 100 })
+	d.update("beta", false, func(b *bar) { b.total = 100 })
+	_, last, _ := strings.Cut(out.String(), "\x1b[1A\r\x1b[J")
+	if strings.Count(last, "\n") != 2 || !strings.HasPrefix(last, "alpha [") {
+		t.Fatalf("draw = %q, want the first bar redrawn with the second", out.String())
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(last, "\n"), "\n") {
+		if len([]rune(line)) > 39 {
+			t.Errorf("line %q is wider than the terminal allows", line)
+		}
+	}
+
+	// progress within a file redraws at most every redrawInterval
+	out.Reset()
+	d.update("alpha", true, func(b *bar) { b.written = 10 })
+	if out.Len() != 0 {
+		t.Errorf("throttled update drew %q", out.String())
+	}
+	*now = now.Add(redrawInterval)
+	d.update("alpha", true, func(b *bar) { b.written = 20 })
+	if got := out.String(); !strings.HasPrefix(got, "\x1b[2A\r\x1b[J") || !strings.Contains(got, " 20%") {
+		t.Errorf("update = %q, want the bars redrawn in place", got)
+	}
+
+	// log lines go above the bars
+	out.Reset()
+	log := log.NewWithWriters(d.Writer(&out), d.Writer(&out))
+	log.Info("saved archive")
+	if got := out.String(); !strings.HasPrefix(got, "\x1b[2A\r\x1b[Jsaved archive\nalpha [") {
+		t.Errorf("log line = %q", got)
+	}
+
+	out.Reset()
+	d.Remove("alpha")
+	if got := out.String(); !strings.HasPrefix(got, "\x1b[2A\r\x1b[Jbeta") || strings.Contains(got, "alpha") {
+		t.Errorf("Remove = %q", got)
+	}
+	out.Reset()
+	d.Stop()
+	if got := out.String(); got != "\x1b[1A\r\x1b[J" {
+		t.Errorf("Stop = %q", got)
+	}
+	var none *Display
+	none.Remove("alpha")
+	none.Stop()
+}
+
+func TestBarProgress(t *testing.T) {
+	var out bytes.Buffer
+	d, _ := testDisplay(&out, 120)
+	logger := log.NewWithWriters(d.Writer(&out), d.Writer(&out))
+
+	p := NewWithOptions(logger, "text", Options{Server: "valheim", Display: d})
+	if _, ok := p.(*barProgress); !ok {
+		t.Fatalf("expected barProgress with a display, got %T", p)
+	}
+	p.Start(300, 2)
+	p.FileStart("a.dat", 100)
+	p.FileDone("a.dat")
+	p.FileStart("b.dat", 200)
+	if !strings.Contains(out.String(), "valheim [######--------------]  33%  100 B/300 B") ||
+		!strings.Contains(out.String(), "b.dat") {
+		t.Errorf("output = %q", out.String())
+	}
+	if strings.Contains(out.String(), "- a.dat") {
+		t.Error("file lines are only logged in verbose mode")
+	}
+	p.Close()
+	if len(d.bars) != 0 {
+		t.Error("Close left the bar on the display")
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	if IsTerminal(&bytes.Buffer{}) {
+		t.Error("a buffer is not a terminal")
+	}
+	f, err := os.Create(filepath.Join(t.TempDir(), "out.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if IsTerminal(f) {
+		t.Error("a file is not a terminal")
+	}
+}
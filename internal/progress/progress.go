@@ -2,9 +2,7 @@
 package progress
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
 	"time"
 
 	"github.com/devtheops/gsbt/internal/log"
@@ -31,16 +29,44 @@ type Reporter interface {
 	Close()
 }
 
+// DefaultInterval is how often progress within a file is reported
+const DefaultInterval = time.Second
+
+// Options configure a reporter
+type Options struct {
+	// Server tags JSON events with the server being backed up
+	Server string
+	// Interval throttles progress updates within a file (DefaultInterval when zero)
+	Interval time.Duration
+	// Display shows text progress as a live bar, keyed by Server
+	Display *Display
+}
+
 // New creates a progress reporter based on output format
 func New(logger *log.Logger, format string) Reporter {
-	if logger.IsQuiet() || format == "json" {
+	return NewWithOptions(logger, format, Options{})
+}
+
+// NewWithOptions creates a progress reporter based on output format. JSON
+// events go through logger, so they never break up its lines.
+func NewWithOptions(logger *log.Logger, format string, opts Options) Reporter {
+	if logger.IsQuiet() {
 		return &nullProgress{}
 	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
 
+	switch {
+	case format == "json":
+		return &jsonProgress{logger: logger, server: opts.Server, interval: opts.Interval}
+	case opts.Display != nil:
+		return &barProgress{logger: logger, display: opts.Display, server: opts.Server}
+	}
 	return &simpleProgress{logger: logger}
 }
 
-// nullProgress is a no-op reporter for quiet/json modes
+// nullProgress is a no-op reporter for quiet mode
 type nullProgress struct{}
 
 func (n *nullProgress) Start(totalBytes int64, fileCount int)             {}
@@ -77,14 +103,69 @@ func (s *simpleProgress) Message(msg string) {
 
 func (s *simpleProgress) Close() {}
 
+// barProgress shows progress as the server's bar on a terminal display,
+// logging only what simpleProgress logs outside of files
+type barProgress struct {
+	logger  *log.Logger
+	display *Display
+	server  string
+}
+
+func (p *barProgress) Start(totalBytes int64, fileCount int) {
+	p.logger.Info(fmt.Sprintf("Files: %d, Total: %.1f MB", fileCount, float64(totalBytes)/1e6))
+	p.display.update(p.server, false, func(b *bar) {
+		b.total, b.done = totalBytes, 0
+	})
+}
+
+func (p *barProgress) FileStart(name string, size int64) {
+	p.logger.Debug(fmt.Sprintf("- %s (%.1f MB)", name, float64(size)/1e6))
+	p.display.update(p.server, false, func(b *bar) {
+		b.file, b.fileSize, b.written = name, size, 0
+	})
+}
+
+func (p *barProgress) FileProgress(name string, written int64, size int64) {
+	p.display.update(p.server, true, func(b *bar) {
+		b.written = written
+	})
+}
+
+func (p *barProgress) FileDone(name string) {
+	p.logger.Debug(fmt.Sprintf("  done %s", name))
+	p.display.update(p.server, true, func(b *bar) {
+		b.done += b.fileSize
+		b.file, b.fileSize, b.written = "", 0, 0
+	})
+}
+
+func (p *barProgress) Message(msg string) {
+	p.logger.Info(msg)
+}
+
+// Close removes the bar once the downloads are done
+func (p *barProgress) Close() {
+	p.display.Remove(p.server)
+}
+
 // jsonProgress outputs structured JSON progress events
 type jsonProgress struct {
-	out       io.Writer
-	startTime time.Time
+	logger   *log.Logger
+	server   string
+	interval time.Duration
+
+	startTime  time.Time
+	totalBytes int64
+	// doneBytes counts the files finished so far
+	doneBytes  int64
+	fileSize   int64
+	lastReport time.Time
 }
 
 func (j *jsonProgress) Start(totalBytes int64, fileCount int) {
 	j.startTime = time.Now()
+	j.totalBytes = totalBytes
+	j.doneBytes = 0
 	j.emit("start", map[string]interface{}{
 		"total_bytes": totalBytes,
 		"file_count":  fileCount,
@@ -92,22 +173,47 @@ func (j *jsonProgress) Start(totalBytes int64, fileCount int) {
 }
 
 func (j *jsonProgress) FileStart(name string, size int64) {
+	j.fileSize = size
 	j.emit("file_start", map[string]interface{}{
 		"file": name,
 		"size": size,
 	})
 }
 
+// FileProgress is throttled to one event per interval
 func (j *jsonProgress) FileProgress(name string, written int64, size int64) {
-	j.emit("file_progress", map[string]interface{}{
-		"file":    name,
-		"written": written,
-		"size":    size,
-		"percent": float64(written) / float64(size) * 100,
-	})
+	now := time.Now()
+	if now.Sub(j.lastReport) < j.interval {
+		return
+	}
+	j.lastReport = now
+
+	percent := 100.0
+	if size > 0 {
+		percent = float64(written) / float64(size) * 100
+	}
+	data := map[string]interface{}{
+		"file":        name,
+		"written":     written,
+		"size":        size,
+		"percent":     percent,
+		"total_bytes": j.totalBytes,
+		"done_bytes":  j.doneBytes + written,
+	}
+	// Throughput and ETA are over the whole backup, which smooths out
+	// small files and stalls
+	if elapsed := now.Sub(j.startTime).Seconds(); elapsed > 0 {
+		rate := float64(j.doneBytes+written) / elapsed
+		data["bytes_per_sec"] = rate
+		if rate > 0 {
+			data["eta_sec"] = max(float64(j.totalBytes-j.doneBytes-written), 0) / rate
+		}
+	}
+	j.emit("file_progress", data)
 }
 
 func (j *jsonProgress) FileDone(name string) {
+	j.doneBytes += j.fileSize
 	j.emit("file_done", map[string]interface{}{
 		"file": name,
 	})
@@ -131,6 +237,8 @@ func (j *jsonProgress) emit(event string, data map[string]interface{}) {
 		"timestamp": time.Now().Format(time.RFC3339),
 		"data":      data,
 	}
-	raw, _ := json.Marshal(entry)
-	fmt.Fprintln(j.out, string(raw))
-}
\ No newline at end of file
+	if j.server != "" {
+		entry["server"] = j.server
+	}
+	j.logger.Event(entry)
+}
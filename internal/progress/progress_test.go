@@ -3,8 +3,10 @@ package progress
 
 import (
 	"bytes"
+	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/devtheops/gsbt/internal/log"
 )
@@ -75,11 +77,11 @@ func TestNewReturnsCorrectType(t *testing.T) {
 		t.Errorf("expected nullProgress in quiet mode, got %T", p)
 	}
 
-	// JSON mode should return nullProgress
+	// JSON mode should return jsonProgress
 	logger.SetQuiet(false)
 	p = New(logger, "json")
-	if _, ok := p.(*nullProgress); !ok {
-		t.Errorf("expected nullProgress in json mode, got %T", p)
+	if _, ok := p.(*jsonProgress); !ok {
+		t.Errorf("expected jsonProgress in json mode, got %T", p)
 	}
 
 	// Text mode should return simpleProgress
@@ -87,4 +89,58 @@ func TestNewReturnsCorrectType(t *testing.T) {
 	if _, ok := p.(*simpleProgress); !ok {
 		t.Errorf("expected simpleProgress in text mode, got %T", p)
 	}
-}
\ No newline at end of file
+}
+func TestJSONProgress(t *testing.T) {
+	var out bytes.Buffer
+	logger := log.NewWithWriters(&out, &out)
+	logger.SetOutputFormat("json")
+
+	p := NewWithOptions(logger.WithPrefix("valheim"), "json", Options{Server: "valheim", Interval: time.Hour})
+	j := p.(*jsonProgress)
+
+	p.Start(1000, 2)
+	p.FileStart("a.dat", 400)
+	p.FileDone("a.dat")
+	logger.Info("interleaved log line")
+	p.FileStart("b.dat", 600)
+	// pretend the backup has run for 2s, so 500 bytes make 250 B/s
+	j.startTime = j.startTime.Add(-2 * time.Second)
+	p.FileProgress("b.dat", 100, 600)
+	p.FileProgress("b.dat", 200, 600) // throttled
+	p.FileDone("b.dat")
+	p.Close()
+
+	var events []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		if entry["event"] != nil {
+			events = append(events, entry)
+		}
+	}
+
+	var names []string
+	for _, e := range events {
+		names = append(names, e["event"].(string))
+		if e["server"] != "valheim" {
+			t.Errorf("event %v not tagged with the server", e)
+		}
+	}
+	want := "start file_start file_done file_start file_progress file_done complete"
+	if got := strings.Join(names, " "); got != want {
+		t.Fatalf("events = %s, want %s", got, want)
+	}
+
+	data := events[4]["data"].(map[string]interface{})
+	if data["done_bytes"] != 500.0 || data["total_bytes"] != 1000.0 {
+		t.Errorf("file_progress = %v", data)
+	}
+	if rate := data["bytes_per_sec"].(float64); rate < 240 || rate > 250 {
+		t.Errorf("bytes_per_sec = %v, want about 250", rate)
+	}
+	if eta := data["eta_sec"].(float64); eta < 2 || eta > 2.1 {
+		t.Errorf("eta_sec = %v, want about 2", eta)
+	}
+}
@@ -0,0 +1,183 @@
+// internal/rcon/client.go
+package rcon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Protocol selects the RCON dialect spoken by a game server
+type Protocol string
+
+const (
+	// ProtocolSource is Valve's Source RCON (ARK, Rust, CS, Valheim plugins, ...)
+	ProtocolSource Protocol = "source"
+	// ProtocolMinecraft is the Minecraft variant, which cannot split responses with an empty trailer packet
+	ProtocolMinecraft Protocol = "minecraft"
+)
+
+// DefaultTimeout bounds dialing and each command round trip when the context has no deadline
+const DefaultTimeout = 10 * time.Second
+
+// ErrAuthFailed is returned when the server rejects the RCON password
+var ErrAuthFailed = errors.New("rcon authentication failed")
+
+// ParseProtocol validates a protocol name, defaulting to source when empty
+func ParseProtocol(s string) (Protocol, error) {
+	switch strings.ToLower(s) {
+	case "", string(ProtocolSource):
+		return ProtocolSource, nil
+	case string(ProtocolMinecraft):
+		return ProtocolMinecraft, nil
+	default:
+		return "", fmt.Errorf("unsupported rcon protocol: %s", s)
+	}
+}
+
+// DefaultPort returns the conventional RCON port for the protocol
+func (p Protocol) DefaultPort() int {
+	if p == ProtocolMinecraft {
+		return 25575
+	}
+	return 27015
+}
+
+// Client is an authenticated RCON connection
+type Client struct {
+	conn     net.Conn
+	protocol Protocol
+	timeout  time.Duration
+	nextID   int32
+	mu       sync.Mutex
+}
+
+// Dial connects to addr and authenticates with password
+func Dial(ctx context.Context, addr, password string, protocol Protocol) (*Client, error) {
+	dialer := net.Dialer{Timeout: DefaultTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to rcon: %w", err)
+	}
+
+	c := &Client{
+		conn:     conn,
+		protocol: protocol,
+		timeout:  DefaultTimeout,
+	}
+
+	if err := c.auth(ctx, password); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *Client) auth(ctx context.Context, password string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.setDeadline(ctx)
+	id := c.newID()
+	if err := writePacket(c.conn, packet{ID: id, Type: typeAuth, Body: password}); err != nil {
+		return fmt.Errorf("rcon auth: %w", err)
+	}
+
+	// Source servers send an empty RESPONSE_VALUE before the AUTH_RESPONSE,
+	// Minecraft only sends the AUTH_RESPONSE. Skip anything until the latter.
+	for {
+		p, err := readPacket(c.conn)
+		if err != nil {
+			return fmt.Errorf("rcon auth: %w", err)
+		}
+		if p.Type != typeAuthResponse {
+			continue
+		}
+		if p.ID == -1 {
+			return ErrAuthFailed
+		}
+		if p.ID != id {
+			return fmt.Errorf("rcon auth: unexpected response id %d", p.ID)
+		}
+		return nil
+	}
+}
+
+// Execute runs a console command and returns its output
+func (c *Client) Execute(ctx context.Context, command string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.setDeadline(ctx)
+	id := c.newID()
+	if err := writePacket(c.conn, packet{ID: id, Type: typeExecCommand, Body: command}); err != nil {
+		return "", fmt.Errorf("rcon %q: %w", command, err)
+	}
+
+	if c.protocol == ProtocolMinecraft {
+		return c.readSingle(id, command)
+	}
+	return c.readMulti(id, command)
+}
+
+// readSingle reads the one response packet Minecraft sends per command
+func (c *Client) readSingle(id int32, command string) (string, error) {
+	for {
+		p, err := readPacket(c.conn)
+		if err != nil {
+			return "", fmt.Errorf("rcon %q: %w", command, err)
+		}
+		if p.ID == id && p.Type == typeResponseValue {
+			return p.Body, nil
+		}
+	}
+}
+
+// readMulti uses the Source trick of sending an empty RESPONSE_VALUE after the
+// command: the server mirrors it back once the command output is complete, so
+// every packet before the mirror belongs to the response.
+func (c *Client) readMulti(id int32, command string) (string, error) {
+	trailer := c.newID()
+	if err := writePacket(c.conn, packet{ID: trailer, Type: typeResponseValue}); err != nil {
+		return "", fmt.Errorf("rcon %q: %w", command, err)
+	}
+
+	var out strings.Builder
+	for {
+		p, err := readPacket(c.conn)
+		if err != nil {
+			return "", fmt.Errorf("rcon %q: %w", command, err)
+		}
+		switch p.ID {
+		case id:
+			out.WriteString(p.Body)
+		case trailer:
+			// Some servers follow the mirror with a second packet; it carries
+			// the trailer id and is skipped by the next Execute.
+			return out.String(), nil
+		}
+	}
+}
+
+// Close terminates the connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) newID() int32 {
+	c.nextID++
+	return c.nextID
+}
+
+func (c *Client) setDeadline(ctx context.Context) {
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	c.conn.SetDeadline(deadline)
+}
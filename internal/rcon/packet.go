@@ -0,0 +1,65 @@
+// internal/rcon/packet.go
+package rcon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Packet types defined by the Source RCON protocol. Minecraft reuses the same values.
+const (
+	typeResponseValue int32 = 0
+	typeExecCommand   int32 = 2
+	typeAuthResponse  int32 = 2
+	typeAuth          int32 = 3
+)
+
+// maxPacketSize is the largest packet (excluding the size field) we accept
+const maxPacketSize = 4096 + 10
+
+// packet is a single RCON protocol frame
+type packet struct {
+	ID   int32
+	Type int32
+	Body string
+}
+
+// writePacket encodes p as: size, id, type, body, two NUL terminators (little endian)
+func writePacket(w io.Writer, p packet) error {
+	var buf bytes.Buffer
+	size := int32(len(p.Body) + 10)
+	binary.Write(&buf, binary.LittleEndian, size)
+	binary.Write(&buf, binary.LittleEndian, p.ID)
+	binary.Write(&buf, binary.LittleEndian, p.Type)
+	buf.WriteString(p.Body)
+	buf.Write([]byte{0, 0})
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// readPacket decodes a single packet from r
+func readPacket(r io.Reader) (packet, error) {
+	var size int32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return packet{}, err
+	}
+	if size < 10 || size > maxPacketSize {
+		return packet{}, fmt.Errorf("invalid packet size %d", size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return packet{}, err
+	}
+
+	p := packet{
+		ID:   int32(binary.LittleEndian.Uint32(data[0:4])),
+		Type: int32(binary.LittleEndian.Uint32(data[4:8])),
+	}
+	// Body is NUL-terminated and followed by an empty NUL-terminated string
+	p.Body = string(bytes.TrimRight(data[8:], "\x00"))
+	return p, nil
+}
@@ -0,0 +1,295 @@
+// internal/rcon/rcon_test.go
+package rcon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer is an in-process RCON server that records executed commands.
+type fakeServer struct {
+	ln       net.Listener
+	password string
+	protocol Protocol
+
+	mu       sync.Mutex
+	commands []string
+}
+
+func newFakeServer(t *testing.T, password string, protocol Protocol) *fakeServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &fakeServer{ln: ln, password: password, protocol: protocol}
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *fakeServer) Addr() string { return s.ln.Addr().String() }
+
+func (s *fakeServer) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+func (s *fakeServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	authed := false
+	for {
+		p, err := readPacket(conn)
+		if err != nil {
+			return
+		}
+
+		switch {
+		case p.Type == typeAuth:
+			if s.protocol == ProtocolSource {
+				writePacket(conn, packet{ID: p.ID, Type: typeResponseValue})
+			}
+			if p.Body != s.password {
+				writePacket(conn, packet{ID: -1, Type: typeAuthResponse})
+				return
+			}
+			authed = true
+			writePacket(conn, packet{ID: p.ID, Type: typeAuthResponse})
+
+		case !authed:
+			return
+
+		case p.Type == typeExecCommand:
+			s.mu.Lock()
+			s.commands = append(s.commands, p.Body)
+			s.mu.Unlock()
+
+			if p.Body == "status" && s.protocol == ProtocolSource {
+				// Split the response across packets like a real Source server
+				writePacket(conn, packet{ID: p.ID, Type: typeResponseValue, Body: "players: 0\n"})
+				writePacket(conn, packet{ID: p.ID, Type: typeResponseValue, Body: "map: TheIsland"})
+				continue
+			}
+			writePacket(conn, packet{ID: p.ID, Type: typeResponseValue, Body: "ok " + p.Body})
+
+		case p.Type == typeResponseValue:
+			// Mirror the empty trailer followed by the odd extra packet Source servers send
+			writePacket(conn, packet{ID: p.ID, Type: typeResponseValue})
+			writePacket(conn, packet{ID: p.ID, Type: typeResponseValue, Body: "\x00\x01"})
+		}
+	}
+}
+
+func TestParseProtocol(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Protocol
+		wantErr bool
+	}{
+		{"", ProtocolSource, false},
+		{"source", ProtocolSource, false},
+		{"Minecraft", ProtocolMinecraft, false},
+		{"telnet", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseProtocol(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseProtocol(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseProtocol(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestClientExecute(t *testing.T) {
+	for _, protocol := range []Protocol{ProtocolSource, ProtocolMinecraft} {
+		t.Run(string(protocol), func(t *testing.T) {
+			srv := newFakeServer(t, "secret", protocol)
+			ctx := context.Background()
+
+			client, err := Dial(ctx, srv.Addr(), "secret", protocol)
+			if err != nil {
+				t.Fatalf("Dial: %v", err)
+			}
+			defer client.Close()
+
+			for _, cmd := range []string{"save-off", "save-all flush"} {
+				resp, err := client.Execute(ctx, cmd)
+				if err != nil {
+					t.Fatalf("Execute(%q): %v", cmd, err)
+				}
+				if resp != "ok "+cmd {
+					t.Errorf("Execute(%q) = %q", cmd, resp)
+				}
+			}
+		})
+	}
+}
+
+func TestClientExecuteMultiPacket(t *testing.T) {
+	srv := newFakeServer(t, "secret", ProtocolSource)
+	ctx := context.Background()
+
+	client, err := Dial(ctx, srv.Addr(), "secret", ProtocolSource)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+
+	resp, err := client.Execute(ctx, "status")
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if resp != "players: 0\nmap: TheIsland" {
+		t.Errorf("unexpected response: %q", resp)
+	}
+
+	// The stray trailer packet must not leak into the next response
+	resp, err = client.Execute(ctx, "saveworld")
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if resp != "ok saveworld" {
+		t.Errorf("unexpected response: %q", resp)
+	}
+}
+
+func TestDialWrongPassword(t *testing.T) {
+	for _, protocol := range []Protocol{ProtocolSource, ProtocolMinecraft} {
+		t.Run(string(protocol), func(t *testing.T) {
+			srv := newFakeServer(t, "secret", protocol)
+
+			_, err := Dial(context.Background(), srv.Addr(), "wrong", protocol)
+			if !errors.Is(err, ErrAuthFailed) {
+				t.Fatalf("expected ErrAuthFailed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAroundRunsCommandsInOrder(t *testing.T) {
+	srv := newFakeServer(t, "secret", ProtocolMinecraft)
+
+	var responses []string
+	cfg := Config{
+		Address:      srv.Addr(),
+		Password:     "secret",
+		Protocol:     ProtocolMinecraft,
+		PreCommands:  []string{"save-off", "save-all flush"},
+		PostCommands: []string{"save-on"},
+		WaitAfter:    10 * time.Millisecond,
+		OnResponse: func(command, response string) {
+			responses = append(responses, response)
+		},
+	}
+
+	called := false
+	err := Around(context.Background(), cfg, func() error {
+		called = true
+		if got := srv.Commands(); len(got) != 2 {
+			t.Errorf("expected pre commands before fn, got %v", got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Around: %v", err)
+	}
+	if !called {
+		t.Fatal("fn was not called")
+	}
+
+	want := "save-off,save-all flush,save-on"
+	if got := strings.Join(srv.Commands(), ","); got != want {
+		t.Errorf("commands = %s, want %s", got, want)
+	}
+	if len(responses) != 3 {
+		t.Errorf("expected 3 responses, got %d", len(responses))
+	}
+}
+
+func TestAroundRunsPostCommandsOnFailure(t *testing.T) {
+	srv := newFakeServer(t, "secret", ProtocolSource)
+
+	cfg := Config{
+		Address:      srv.Addr(),
+		Password:     "secret",
+		Protocol:     ProtocolSource,
+		PreCommands:  []string{"saveworld"},
+		PostCommands: []string{"broadcast backup done"},
+	}
+
+	downloadErr := errors.New("download failed")
+	err := Around(context.Background(), cfg, func() error { return downloadErr })
+	if !errors.Is(err, downloadErr) {
+		t.Fatalf("expected download error, got %v", err)
+	}
+
+	want := "saveworld,broadcast backup done"
+	if got := strings.Join(srv.Commands(), ","); got != want {
+		t.Errorf("commands = %s, want %s", got, want)
+	}
+}
+
+func TestAroundRunsPostCommandsWhenCancelled(t *testing.T) {
+	srv := newFakeServer(t, "secret", ProtocolMinecraft)
+
+	cfg := Config{
+		Address:      srv.Addr(),
+		Password:     "secret",
+		Protocol:     ProtocolMinecraft,
+		PreCommands:  []string{"save-off"},
+		PostCommands: []string{"save-on"},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := Around(ctx, cfg, func() error {
+		cancel()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	want := "save-off,save-on"
+	if got := strings.Join(srv.Commands(), ","); got != want {
+		t.Errorf("commands = %s, want %s", got, want)
+	}
+}
+
+func TestAroundPreCommandFailureSkipsFn(t *testing.T) {
+	srv := newFakeServer(t, "secret", ProtocolSource)
+
+	cfg := Config{
+		Address:     srv.Addr(),
+		Password:    "wrong",
+		Protocol:    ProtocolSource,
+		PreCommands: []string{"saveworld"},
+	}
+
+	err := Around(context.Background(), cfg, func() error {
+		t.Fatal("fn should not run when pre commands fail")
+		return nil
+	})
+	if !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("expected ErrAuthFailed, got %v", err)
+	}
+}
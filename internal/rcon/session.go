@@ -0,0 +1,91 @@
+// internal/rcon/session.go
+package rcon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Config describes an RCON endpoint and the commands to run around a backup
+type Config struct {
+	Address      string
+	Password     string
+	Protocol     Protocol
+	PreCommands  []string
+	PostCommands []string
+	WaitAfter    time.Duration
+
+	// OnResponse, when set, receives the output of every executed command
+	OnResponse func(command, response string)
+}
+
+// Around runs cfg.PreCommands, waits cfg.WaitAfter, calls fn and finally runs
+// cfg.PostCommands. Post commands run even if fn or a pre command fails so a
+// server is never left with world saving disabled.
+func Around(ctx context.Context, cfg Config, fn func() error) error {
+	if err := Run(ctx, cfg, cfg.PreCommands); err != nil {
+		if postErr := runPost(ctx, cfg); postErr != nil {
+			return errors.Join(fmt.Errorf("pre commands: %w", err), fmt.Errorf("post commands: %w", postErr))
+		}
+		return fmt.Errorf("pre commands: %w", err)
+	}
+
+	if cfg.WaitAfter > 0 && len(cfg.PreCommands) > 0 {
+		select {
+		case <-time.After(cfg.WaitAfter):
+		case <-ctx.Done():
+			if postErr := runPost(ctx, cfg); postErr != nil {
+				return errors.Join(ctx.Err(), fmt.Errorf("post commands: %w", postErr))
+			}
+			return ctx.Err()
+		}
+	}
+
+	fnErr := fn()
+
+	if postErr := runPost(ctx, cfg); postErr != nil {
+		if fnErr != nil {
+			return errors.Join(fnErr, fmt.Errorf("post commands: %w", postErr))
+		}
+		return fmt.Errorf("post commands: %w", postErr)
+	}
+
+	return fnErr
+}
+
+// runPost executes post commands detached from ctx cancellation so that a
+// cancelled backup still re-enables saving on the server.
+func runPost(ctx context.Context, cfg Config) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), DefaultTimeout*time.Duration(len(cfg.PostCommands)+1))
+	defer cancel()
+	return Run(ctx, cfg, cfg.PostCommands)
+}
+
+// Run opens a connection, executes commands in order and closes it again.
+// A fresh connection is used per batch because game servers tend to drop idle
+// RCON sessions during long downloads.
+func Run(ctx context.Context, cfg Config, commands []string) error {
+	if len(commands) == 0 {
+		return nil
+	}
+
+	client, err := Dial(ctx, cfg.Address, cfg.Password, cfg.Protocol)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	for _, command := range commands {
+		resp, err := client.Execute(ctx, command)
+		if err != nil {
+			return err
+		}
+		if cfg.OnResponse != nil {
+			cfg.OnResponse(command, resp)
+		}
+	}
+
+	return nil
+}
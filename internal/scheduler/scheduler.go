@@ -0,0 +1,161 @@
+// internal/scheduler/scheduler.go
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Clock abstracts time so the scheduler can be driven by tests
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+var parser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Parse parses a five-field cron expression or a shorthand such as
+// "@hourly", "@daily" or "@every 6h". Times are in timezone (an IANA name
+// such as "Europe/Berlin"), or local time when empty.
+func Parse(spec, timezone string) (cron.Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+		}
+		spec = "CRON_TZ=" + timezone + " " + spec
+	}
+	s, err := parser.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+	}
+	return s, nil
+}
+
+// Job is work run on a schedule. Runs of jobs with the same name never overlap.
+type Job struct {
+	Name     string
+	Schedule cron.Schedule
+	// Jitter delays each run by a random duration up to this long
+	Jitter time.Duration
+	Run    func()
+}
+
+// Scheduler runs jobs at their scheduled times
+type Scheduler struct {
+	// OnNext is called with each job's next run time
+	OnNext func(job string, at time.Time)
+	// OnSkip is called when a run is skipped because the previous one is still going
+	OnSkip func(job string)
+
+	clock  Clock
+	jitter func(max time.Duration) time.Duration
+
+	mu      sync.Mutex
+	running map[string]bool
+	wg      sync.WaitGroup
+}
+
+// New returns a scheduler driven by clock, or the system clock when nil
+func New(clock Clock) *Scheduler {
+	if clock == nil {
+		clock = realClock{}
+	}
+	return &Scheduler{
+		clock:   clock,
+		jitter:  func(max time.Duration) time.Duration { return rand.N(max) },
+		running: map[string]bool{},
+	}
+}
+
+// Run starts jobs when they are due until ctx is done. Cancelling ctx stops
+// scheduling but not runs already started; the scheduler remembers them, so
+// Run can be called again with a new set of jobs (e.g. after a config
+// reload) without overlapping them.
+func (s *Scheduler) Run(ctx context.Context, jobs []Job) error {
+	next := make([]time.Time, len(jobs))
+	for i, job := range jobs {
+		next[i] = s.next(job, s.clock.Now())
+	}
+
+	for {
+		if len(jobs) == 0 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+
+		earliest := next[0]
+		for _, t := range next[1:] {
+			if t.Before(earliest) {
+				earliest = t
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.clock.After(earliest.Sub(s.clock.Now())):
+		}
+
+		now := s.clock.Now()
+		for i, job := range jobs {
+			if next[i].After(now) {
+				continue
+			}
+			s.start(job)
+			next[i] = s.next(job, now)
+		}
+	}
+}
+
+// Wait blocks until all started runs have finished
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+// next returns the first run of job after now, including jitter
+func (s *Scheduler) next(job Job, now time.Time) time.Time {
+	at := job.Schedule.Next(now)
+	if job.Jitter > 0 {
+		at = at.Add(s.jitter(job.Jitter))
+	}
+	if s.OnNext != nil {
+		s.OnNext(job.Name, at)
+	}
+	return at
+}
+
+func (s *Scheduler) start(job Job) {
+	s.mu.Lock()
+	if s.running[job.Name] {
+		s.mu.Unlock()
+		if s.OnSkip != nil {
+			s.OnSkip(job.Name)
+		}
+		return
+	}
+	s.running[job.Name] = true
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			delete(s.running, job.Name)
+			s.mu.Unlock()
+			s.wg.Done()
+		}()
+		job.Run()
+	}()
+}
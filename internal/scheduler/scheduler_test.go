@@ -0,0 +1,216 @@
+// internal/scheduler/scheduler_test.go
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock only moves when Advance is called
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+}
+
+type waiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, waiter{c.now.Add(d), ch})
+	return ch
+}
+
+// Advance moves the clock once the scheduler is waiting on it and fires
+// the timers that are due
+func (c *fakeClock) Advance(t *testing.T, d time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c.mu.Lock()
+		if len(c.waiters) > 0 {
+			break
+		}
+		c.mu.Unlock()
+		if time.Now().After(deadline) {
+			t.Fatal("scheduler is not waiting on the clock")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+func TestParse(t *testing.T) {
+	from := time.Date(2026, 3, 1, 10, 30, 0, 0, time.UTC)
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	for _, tc := range []struct {
+		spec, tz string
+		want     time.Time
+	}{
+		{"@hourly", "", time.Date(2026, 3, 1, 11, 0, 0, 0, time.UTC)},
+		{"@every 6h", "", from.Add(6 * time.Hour)},
+		{"15 */4 * * *", "UTC", time.Date(2026, 3, 1, 12, 15, 0, 0, time.UTC)},
+		{"0 4 * * *", "Europe/Berlin", time.Date(2026, 3, 2, 4, 0, 0, 0, berlin)},
+	} {
+		s, err := Parse(tc.spec, tc.tz)
+		if err != nil {
+			t.Fatalf("Parse(%q, %q) error: %v", tc.spec, tc.tz, err)
+		}
+		if got := s.Next(from); !got.Equal(tc.want) {
+			t.Errorf("Parse(%q, %q).Next = %v, want %v", tc.spec, tc.tz, got, tc.want)
+		}
+	}
+
+	for _, spec := range []string{"", "every hour", "0 4 * *", "61 * * * *"} {
+		if _, err := Parse(spec, ""); err == nil {
+			t.Errorf("Parse(%q) expected error", spec)
+		}
+	}
+	if _, err := Parse("@daily", "Mars/Olympus"); err == nil {
+		t.Error("expected error for unknown timezone")
+	}
+}
+
+func TestSchedulerRun(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	hourly, _ := Parse("@hourly", "UTC")
+
+	runs := make(chan string, 10)
+	release := make(chan struct{})
+	s := New(clock)
+	var mu sync.Mutex
+	var next []time.Time
+	var skipped []string
+	s.OnNext = func(job string, at time.Time) {
+		mu.Lock()
+		defer mu.Unlock()
+		next = append(next, at)
+	}
+	s.OnSkip = func(job string) {
+		mu.Lock()
+		defer mu.Unlock()
+		skipped = append(skipped, job)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Run(ctx, []Job{{Name: "alpha", Schedule: hourly, Run: func() {
+			runs <- "alpha"
+			<-release
+		}}})
+	}()
+
+	clock.Advance(t, 30*time.Minute)
+	select {
+	case <-runs:
+		t.Fatal("job ran before it was due")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	clock.Advance(t, 30*time.Minute)
+	if job := <-runs; job != "alpha" {
+		t.Fatalf("ran %q, want alpha", job)
+	}
+
+	// still running an hour later: the run is skipped
+	clock.Advance(t, time.Hour)
+	clock.Advance(t, 0)
+	mu.Lock()
+	if len(skipped) != 1 {
+		t.Errorf("skipped = %v, want one skipped run", skipped)
+	}
+	mu.Unlock()
+
+	close(release)
+	s.Wait()
+	clock.Advance(t, time.Hour)
+	if job := <-runs; job != "alpha" {
+		t.Fatalf("ran %q, want alpha", job)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run = %v, want context.Canceled", err)
+	}
+	s.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []time.Time{start.Add(time.Hour), start.Add(2 * time.Hour), start.Add(3 * time.Hour), start.Add(4 * time.Hour)}
+	if len(next) != len(want) {
+		t.Fatalf("next runs = %v, want %v", next, want)
+	}
+	for i := range want {
+		if !next[i].Equal(want[i]) {
+			t.Errorf("next[%d] = %v, want %v", i, next[i], want[i])
+		}
+	}
+}
+
+func TestSchedulerJitter(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	hourly, _ := Parse("@hourly", "UTC")
+
+	s := New(clock)
+	s.jitter = func(max time.Duration) time.Duration {
+		if max != 10*time.Minute {
+			t.Errorf("jitter max = %v, want 10m", max)
+		}
+		return 5 * time.Minute
+	}
+	var first time.Time
+	s.OnNext = func(job string, at time.Time) {
+		if first.IsZero() {
+			first = at
+		}
+	}
+
+	runs := make(chan struct{}, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx, []Job{{Name: "alpha", Schedule: hourly, Jitter: 10 * time.Minute, Run: func() { runs <- struct{}{} }}})
+
+	clock.Advance(t, time.Hour)
+	select {
+	case <-runs:
+		t.Fatal("job ran before its jitter elapsed")
+	case <-time.After(20 * time.Millisecond):
+	}
+	clock.Advance(t, 5*time.Minute)
+	<-runs
+	if want := start.Add(65 * time.Minute); !first.Equal(want) {
+		t.Errorf("first run at %v, want %v", first, want)
+	}
+}
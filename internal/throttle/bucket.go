@@ -0,0 +1,176 @@
+// internal/throttle/bucket.go
+package throttle
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// chunkSize bounds how much is written between token checks, so transfers
+// sharing a bucket interleave smoothly
+const chunkSize = 16 * 1024
+
+// Bucket is a token bucket shared by every transfer it throttles. The rate
+// comes from a schedule and is looked up on each call, so a time-of-day
+// window takes effect in the middle of a long transfer.
+type Bucket struct {
+	schedule Schedule
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+
+	// now and sleep are replaced in tests
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewBucket returns a bucket for the schedule, or nil if it never limits
+func NewBucket(s Schedule) *Bucket {
+	if s.Unlimited() {
+		return nil
+	}
+	return &Bucket{schedule: s, now: time.Now, sleep: sleepContext}
+}
+
+// Rate returns the rate currently in effect
+func (b *Bucket) Rate() int64 {
+	return b.schedule.RateAt(b.now())
+}
+
+// Wait blocks until n bytes may pass. Callers take tokens up front and may
+// push the bucket into debt; the debt is paid off by waiting, which keeps
+// concurrent transfers fair without a queue.
+func (b *Bucket) Wait(ctx context.Context, n int) error {
+	b.mu.Lock()
+	now := b.now()
+	rate := float64(b.schedule.RateAt(now))
+	if rate <= 0 {
+		b.tokens, b.last = 0, now
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+
+	// Allow a quarter second of burst, enough for a chunk at low rates
+	burst := max(rate/4, chunkSize)
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else {
+		b.tokens = min(b.tokens+now.Sub(b.last).Seconds()*rate, burst)
+	}
+	b.last = now
+	b.tokens -= float64(n)
+	debt := -b.tokens
+	b.mu.Unlock()
+
+	if debt <= 0 {
+		return ctx.Err()
+	}
+	return b.sleep(ctx, time.Duration(debt/rate*float64(time.Second)))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Limiter applies several buckets at once, e.g. a server's own limit and
+// the global cap shared by all servers
+type Limiter []*Bucket
+
+// NewLimiter drops nil (unlimited) buckets
+func NewLimiter(buckets ...*Bucket) Limiter {
+	var l Limiter
+	for _, b := range buckets {
+		if b != nil {
+			l = append(l, b)
+		}
+	}
+	return l
+}
+
+// Rate returns the lowest rate currently in effect, 0 if none limits
+func (l Limiter) Rate() int64 {
+	var rate int64
+	for _, b := range l {
+		if r := b.Rate(); r > 0 && (rate == 0 || r < rate) {
+			rate = r
+		}
+	}
+	return rate
+}
+
+func (l Limiter) wait(ctx context.Context, n int) error {
+	for _, b := range l {
+		if err := b.Wait(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Writer returns w throttled by the limiter
+func (l Limiter) Writer(ctx context.Context, w io.Writer) io.Writer {
+	if len(l) == 0 {
+		return w
+	}
+	return &writer{ctx: ctx, w: w, l: l}
+}
+
+// Reader returns r throttled by the limiter
+func (l Limiter) Reader(ctx context.Context, r io.Reader) io.Reader {
+	if len(l) == 0 {
+		return r
+	}
+	return &reader{ctx: ctx, r: r, l: l}
+}
+
+type writer struct {
+	ctx context.Context
+	w   io.Writer
+	l   Limiter
+}
+
+func (t *writer) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p[:min(len(p), chunkSize)]
+		if err := t.l.wait(t.ctx, len(chunk)); err != nil {
+			return written, err
+		}
+		n, err := t.w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+type reader struct {
+	ctx context.Context
+	r   io.Reader
+	l   Limiter
+}
+
+func (t *reader) Read(p []byte) (int, error) {
+	if len(p) > chunkSize {
+		p = p[:chunkSize]
+	}
+	n, err := t.r.Read(p)
+	if n > 0 {
+		if werr := t.l.wait(t.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
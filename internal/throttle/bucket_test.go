@@ -0,0 +1,94 @@
+// internal/throttle/bucket_test.go
+package throttle
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock advances only when the bucket sleeps
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return ctx.Err()
+}
+
+func newTestBucket(s Schedule, clock *fakeClock) *Bucket {
+	b := NewBucket(s)
+	b.now = clock.Now
+	b.sleep = clock.Sleep
+	return b
+}
+
+func TestBucketRate(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	clock := &fakeClock{now: start}
+	b := newTestBucket(Schedule{Rate: 1 << 20}, clock)
+
+	// 10 MiB at 1 MiB/s takes 10s, less the initial quarter second burst
+	w := NewLimiter(b).Writer(context.Background(), io.Discard)
+	if _, err := w.Write(make([]byte, 10<<20)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got := clock.Now().Sub(start); got < 9700*time.Millisecond || got > 9800*time.Millisecond {
+		t.Errorf("10 MiB took %v, want ~9.75s", got)
+	}
+}
+
+func TestBucketSchedule(t *testing.T) {
+	// Throttled until 12:00:05, unlimited afterwards
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	clock := &fakeClock{now: start}
+	s := Schedule{Rate: 0, Windows: []Window{{Start: 11 * time.Hour, End: 12*time.Hour + 5*time.Second, Rate: 1 << 20}}}
+	b := newTestBucket(s, clock)
+
+	r := NewLimiter(b).Reader(context.Background(), bytes.NewReader(make([]byte, 100<<20)))
+	n, err := io.Copy(io.Discard, r)
+	if err != nil || n != 100<<20 {
+		t.Fatalf("Copy = %d, %v", n, err)
+	}
+	if got := clock.Now().Sub(start); got < 5*time.Second || got > 6*time.Second {
+		t.Errorf("copy took %v, want just over the 5s window", got)
+	}
+}
+
+func TestLimiterUsesLowestRate(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)}
+	l := NewLimiter(nil, newTestBucket(Schedule{Rate: 4 << 20}, clock), NewBucket(Schedule{}), newTestBucket(Schedule{Rate: 1 << 20}, clock))
+	if len(l) != 2 {
+		t.Fatalf("NewLimiter kept %d buckets, want 2", len(l))
+	}
+	if l.Rate() != 1<<20 {
+		t.Errorf("Rate() = %d", l.Rate())
+	}
+	if NewLimiter().Writer(context.Background(), io.Discard) != io.Discard {
+		t.Error("empty limiter should not wrap")
+	}
+}
+
+func TestWriterCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := NewLimiter(NewBucket(Schedule{Rate: 1024})).Writer(ctx, io.Discard)
+	if _, err := io.Copy(w, strings.NewReader(strings.Repeat("x", 1<<20))); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+// internal/throttle/schedule.go
+package throttle
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window overrides the rate during a daily time range. End before Start
+// wraps past midnight.
+type Window struct {
+	Start time.Duration // offset from local midnight
+	End   time.Duration
+	Rate  int64
+}
+
+// ParseHours parses a daily range such as "08:00-18:30" or "22:00-06:00"
+func ParseHours(s string) (start, end time.Duration, err error) {
+	from, to, ok := strings.Cut(strings.ReplaceAll(s, " ", ""), "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid hours %q (want HH:MM-HH:MM)", s)
+	}
+	if start, err = parseClock(from); err == nil {
+		end, err = parseClock(to)
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid hours %q: %w", s, err)
+	}
+	if start == end {
+		return 0, 0, fmt.Errorf("invalid hours %q: empty range", s)
+	}
+	return start, end, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	if s == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("bad time %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// contains reports whether the time of day falls in the window
+func (w Window) contains(tod time.Duration) bool {
+	if w.Start < w.End {
+		return tod >= w.Start && tod < w.End
+	}
+	return tod >= w.Start || tod < w.End
+}
+
+// Schedule is a base rate with optional time-of-day overrides. The first
+// matching window wins. A rate of 0 means unlimited.
+type Schedule struct {
+	Rate    int64
+	Windows []Window
+}
+
+// RateAt returns the rate in effect at t (in t's location)
+func (s Schedule) RateAt(t time.Time) int64 {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	tod := t.Sub(midnight)
+	for _, w := range s.Windows {
+		if w.contains(tod) {
+			return w.Rate
+		}
+	}
+	return s.Rate
+}
+
+// Unlimited reports whether the schedule never limits
+func (s Schedule) Unlimited() bool {
+	if s.Rate > 0 {
+		return false
+	}
+	for _, w := range s.Windows {
+		if w.Rate > 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+// internal/throttle/schedule_test.go
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseHours(t *testing.T) {
+	tests := []struct {
+		in         string
+		start, end time.Duration
+		wantErr    bool
+	}{
+		{"08:00-18:30", 8 * time.Hour, 18*time.Hour + 30*time.Minute, false},
+		{"22:00 - 06:00", 22 * time.Hour, 6 * time.Hour, false},
+		{"00:00-24:00", 0, 24 * time.Hour, false},
+		{"08:00", 0, 0, true},
+		{"8am-5pm", 0, 0, true},
+		{"10:00-10:00", 0, 0, true},
+	}
+	for _, tt := range tests {
+		start, end, err := ParseHours(tt.in)
+		if (err != nil) != tt.wantErr || start != tt.start || end != tt.end {
+			t.Errorf("ParseHours(%q) = %v, %v, %v", tt.in, start, end, err)
+		}
+	}
+}
+
+func TestScheduleRateAt(t *testing.T) {
+	s := Schedule{
+		Rate: 100,
+		Windows: []Window{
+			{Start: 18 * time.Hour, End: 23 * time.Hour, Rate: 10},
+			{Start: 22 * time.Hour, End: 6 * time.Hour, Rate: 0}, // night, unlimited
+		},
+	}
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 1, h, m, 0, 0, time.Local) }
+
+	tests := []struct {
+		t    time.Time
+		want int64
+	}{
+		{at(12, 0), 100},
+		{at(18, 0), 10},
+		{at(22, 30), 10}, // first matching window wins
+		{at(23, 0), 0},
+		{at(3, 0), 0},
+		{at(6, 0), 100},
+	}
+	for _, tt := range tests {
+		if got := s.RateAt(tt.t); got != tt.want {
+			t.Errorf("RateAt(%s) = %d, want %d", tt.t.Format("15:04"), got, tt.want)
+		}
+	}
+
+	if s.Unlimited() || !(Schedule{Windows: []Window{{Start: 0, End: time.Hour}}}).Unlimited() {
+		t.Error("Unlimited() mismatch")
+	}
+}